@@ -0,0 +1,143 @@
+// Package cfrbr implements CFR-BR, in which one player learns by CFR
+// while all other players play a best response to its current strategy.
+//
+// When the learning player's strategy is restricted to an abstraction of
+// the game, but the best response is computed in the real game, the average
+// strategy of the learning player converges to the least exploitable strategy
+// that can be represented in the abstraction.
+//
+// See: Johanson et al., "Finding Optimal Abstract Strategies in Extensive-Form Games" (AAAI 2012).
+//
+// Best responses are computed by walking the full game tree, so CFR-BR is
+// only tractable for games that are small enough to enumerate.
+package cfrbr
+
+import (
+	"github.com/timpalpant/go-cfr"
+	"github.com/timpalpant/go-cfr/exploitability"
+)
+
+// Params configure how often best responses are recomputed.
+type Params struct {
+	// Number of iterations between computing new best responses to the
+	// learning player's current strategy. If zero, new best responses are
+	// computed on every iteration, as in the original CFR-BR. Larger intervals
+	// reduce the cost of each iteration, but the learning player then exploits
+	// stale best responses and converges more slowly.
+	BestResponseInterval int
+}
+
+// CFRBR implements cfr.Solver for CFR-BR.
+type CFRBR struct {
+	player          int
+	params          Params
+	strategyProfile cfr.StrategyProfile
+	responses       *responseProfile
+	solver          *cfr.CFR
+}
+
+// New returns a new CFR-BR solver in which the given player learns by vanilla
+// CFR, and its regrets and average strategy are accumulated in strategyProfile.
+func New(strategyProfile cfr.StrategyProfile, player int, params Params) *CFRBR {
+	responses := &responseProfile{
+		StrategyProfile: strategyProfile,
+		player:          player,
+	}
+
+	return &CFRBR{
+		player:          player,
+		params:          params,
+		strategyProfile: strategyProfile,
+		responses:       responses,
+		solver:          cfr.New(responses),
+	}
+}
+
+// Player returns the learning player.
+func (c *CFRBR) Player() int {
+	return c.player
+}
+
+// StrategyProfile implements cfr.Solver. Only the learning player's
+// InfoSets are updated.
+func (c *CFRBR) StrategyProfile() cfr.StrategyProfile {
+	return c.strategyProfile
+}
+
+// Seed implements cfr.Solver. It has no effect since CFR-BR is deterministic.
+func (c *CFRBR) Seed(seed int64) {}
+
+// Run implements cfr.Solver. It performs one iteration of CFR for the learning
+// player against the current best responses, first computing new best responses
+// if they are due. It returns the expected value of the game for the first player.
+func (c *CFRBR) Run(root cfr.GameTreeNode) float32 {
+	if c.needsBestResponse() {
+		c.updateBestResponses(root)
+	}
+
+	return c.solver.Run(root)
+}
+
+func (c *CFRBR) needsBestResponse() bool {
+	if c.responses.bestResponses == nil {
+		return true
+	}
+
+	interval := c.params.BestResponseInterval
+	if interval <= 0 {
+		interval = 1
+	}
+
+	return c.strategyProfile.Iter()%interval == 0
+}
+
+// updateBestResponses computes a new best response for each other player
+// in turn, to the current strategy of the learning player and the most
+// recent best responses of the remaining players.
+func (c *CFRBR) updateBestResponses(root cfr.GameTreeNode) {
+	nPlayers := cfr.NumPlayers(root)
+	if c.responses.bestResponses == nil {
+		c.responses.bestResponses = make([]*cfr.FixedStrategyProfile, nPlayers)
+		for player := range c.responses.bestResponses {
+			// Until the first best response is computed, other players play
+			// uniformly at random.
+			c.responses.bestResponses[player] = cfr.NewFixedStrategyProfile()
+		}
+	}
+
+	current := currentStrategy{c.responses}
+	for player := 0; player < nPlayers; player++ {
+		if player != c.player {
+			br := exploitability.NewBestResponse(root, player, current)
+			c.responses.bestResponses[player] = br.StrategyProfile()
+		}
+	}
+}
+
+// responseProfile is a cfr.StrategyProfile in which the learning player's
+// InfoSets are delegated to the embedded StrategyProfile, and all other
+// players play fixed best responses that are not updated.
+type responseProfile struct {
+	cfr.StrategyProfile
+	player        int
+	bestResponses []*cfr.FixedStrategyProfile
+}
+
+// GetPolicy implements cfr.StrategyProfile.
+func (p *responseProfile) GetPolicy(node cfr.GameTreeNode) cfr.NodePolicy {
+	if player := node.Player(); player != p.player {
+		return p.bestResponses[player].GetPolicy(node)
+	}
+
+	return p.StrategyProfile.GetPolicy(node)
+}
+
+// currentStrategy is an exploitability.Policy that plays the
+// current (rather than average) strategy of a StrategyProfile.
+type currentStrategy struct {
+	profile cfr.StrategyProfile
+}
+
+func (s currentStrategy) GetPolicy(node cfr.GameTreeNode) []float32 {
+	return s.profile.GetPolicy(node).GetStrategy()
+}
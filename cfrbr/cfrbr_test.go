@@ -0,0 +1,75 @@
+package cfrbr
+
+import (
+	"math"
+	"testing"
+
+	"github.com/timpalpant/go-cfr"
+	"github.com/timpalpant/go-cfr/exploitability"
+	"github.com/timpalpant/go-cfr/kuhn"
+)
+
+// The value of Kuhn poker for the first player.
+const kuhnValue = -1.0 / 18
+
+func TestKuhn(t *testing.T) {
+	for player := 0; player < 2; player++ {
+		for _, tc := range []struct {
+			interval  int
+			tolerance float64
+		}{
+			{0, 0.01},
+			// Stale best responses converge more slowly.
+			{10, 0.05},
+		} {
+			interval := tc.interval
+			root := kuhn.NewGame()
+			profile := cfr.NewPolicyTable(cfr.DiscountParams{})
+			opt := New(profile, player, Params{BestResponseInterval: interval})
+			for i := 0; i < 2000; i++ {
+				opt.Run(root)
+				profile.Update()
+			}
+
+			// The value of the opponent's best response to the learning player's
+			// average strategy must approach the value of the game.
+			opponent := 1 - player
+			brValue := exploitability.BestResponseValue(root, opponent, profile)
+			expected := kuhnValue
+			if opponent == 1 {
+				expected = -kuhnValue
+			}
+
+			t.Logf("player %d, interval %d: best response value: %.4f", player, interval, brValue)
+			if math.Abs(brValue-expected) > tc.tolerance {
+				t.Errorf("player %d, interval %d: expected best response value %v, got %v",
+					player, interval, expected, brValue)
+			}
+		}
+	}
+}
+
+func TestOnlyLearnerIsUpdated(t *testing.T) {
+	root := kuhn.NewGame()
+	profile := cfr.NewPolicyTable(cfr.DiscountParams{})
+	opt := New(profile, 0, Params{})
+	for i := 0; i < 10; i++ {
+		opt.Run(root)
+		profile.Update()
+	}
+
+	var visit func(node cfr.GameTreeNode)
+	visit = func(node cfr.GameTreeNode) {
+		if node.Type() == cfr.PlayerNodeType && node.Player() == 1 {
+			if !profile.GetPolicy(node).IsEmpty() {
+				t.Errorf("expected no regret for opponent InfoSet %s", node.InfoSetKey(1))
+			}
+		}
+
+		for i := 0; i < node.NumChildren(); i++ {
+			visit(node.GetChild(i))
+		}
+	}
+
+	visit(root)
+}
@@ -0,0 +1,39 @@
+package cfr
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// SampleChanceNode samples one child of the given chance node according to
+// its probability distribution, using the given random number generator.
+// It returns the sampled child and the probability with which it was sampled.
+//
+// If the node implements RandChanceNode then its SampleChildRand is used,
+// otherwise a child is selected by scanning over GetChildProbability.
+//
+// Solvers use this rather than GameTreeNode.SampleChild so that their
+// traversals are reproducible for a given seed.
+func SampleChanceNode(rng *rand.Rand, node GameTreeNode) (GameTreeNode, float64) {
+	if rn, ok := node.(RandChanceNode); ok {
+		return rn.SampleChildRand(rng)
+	}
+
+	x := rng.Float64()
+	var cumProb float64
+	n := node.NumChildren()
+	for i := 0; i < n; i++ {
+		p := node.GetChildProbability(i)
+		cumProb += p
+		if cumProb > x {
+			return node.GetChild(i), p
+		}
+	}
+
+	if cumProb < 1.0-eps { // Leave room for floating point error.
+		panic(fmt.Errorf("probability distribution sums to %v != 1! node: %v, num children: %v",
+			cumProb, node, n))
+	}
+
+	return node.GetChild(n - 1), node.GetChildProbability(n - 1)
+}
@@ -1,6 +1,8 @@
 package cfr
 
 import (
+	"math/rand"
+
 	"github.com/timpalpant/go-cfr/internal/f32"
 )
 
@@ -9,48 +11,73 @@ const eps = 1e-3
 type ChanceSamplingCFR struct {
 	strategyProfile StrategyProfile
 	slicePool       *floatSlicePool
+	rng             *rand.Rand
+	randState
+
+	nPlayers int
 }
 
-func NewChanceSampling(strategyProfile StrategyProfile) *ChanceSamplingCFR {
+func NewChanceSampling(rng *rand.Rand, strategyProfile StrategyProfile) *ChanceSamplingCFR {
 	return &ChanceSamplingCFR{
 		strategyProfile: strategyProfile,
 		slicePool:       &floatSlicePool{},
+		rng:             rng,
 	}
 }
 
+// StrategyProfile implements Solver.
+func (c *ChanceSamplingCFR) StrategyProfile() StrategyProfile {
+	return c.strategyProfile
+}
+
+// Seed implements Solver.
+func (c *ChanceSamplingCFR) Seed(seed int64) {
+	c.rng.Seed(seed)
+}
+
+// Run performs one iteration of chance-sampled CFR, updating the regrets of
+// all players, and returns the sampled value of the game for the first player.
 func (c *ChanceSamplingCFR) Run(node GameTreeNode) float32 {
-	return c.runHelper(node, node.Player(), 1.0, 1.0)
+	c.nPlayers = NumPlayers(node)
+	reach := c.slicePool.alloc(c.nPlayers)
+	defer c.slicePool.free(reach)
+	fillOnes(reach)
+	ev := c.runHelper(node, reach)
+	defer c.slicePool.free(ev)
+	return ev[0]
 }
 
-func (c *ChanceSamplingCFR) runHelper(node GameTreeNode, lastPlayer int, reachP0, reachP1 float32) float32 {
-	var ev float32
+// runHelper returns the sampled utility of each player at the given node.
+// The returned slice must be freed by the caller.
+func (c *ChanceSamplingCFR) runHelper(node GameTreeNode, reach []float32) []float32 {
+	var ev []float32
 	switch node.Type() {
 	case TerminalNodeType:
-		ev = float32(node.Utility(lastPlayer))
+		ev = c.slicePool.alloc(c.nPlayers)
+		getUtilities(node, ev)
 	case ChanceNodeType:
-		ev = c.handleChanceNode(node, lastPlayer, reachP0, reachP1)
+		ev = c.handleChanceNode(node, reach)
 	default:
-		sgn := getSign(lastPlayer, node.Player())
-		ev = sgn * c.handlePlayerNode(node, reachP0, reachP1)
+		ev = c.handlePlayerNode(node, reach)
 	}
 
 	node.Close()
 	return ev
 }
 
-func (c *ChanceSamplingCFR) handleChanceNode(node GameTreeNode, lastPlayer int, reachP0, reachP1 float32) float32 {
-	child, _ := node.SampleChild()
+func (c *ChanceSamplingCFR) handleChanceNode(node GameTreeNode, reach []float32) []float32 {
+	child, _ := SampleChanceNode(c.rng, node)
 	// Sampling probabilities cancel out in the calculation of counterfactual value.
-	return c.runHelper(child, lastPlayer, reachP0, reachP1)
+	return c.runHelper(child, reach)
 }
 
-func (c *ChanceSamplingCFR) handlePlayerNode(node GameTreeNode, reachP0, reachP1 float32) float32 {
+func (c *ChanceSamplingCFR) handlePlayerNode(node GameTreeNode, reach []float32) []float32 {
 	player := node.Player()
 	nChildren := node.NumChildren()
 	if nChildren == 1 {
 		// Optimization to skip trivial nodes with no real choice.
 		child := node.GetChild(0)
-		return c.runHelper(child, player, reachP0, reachP1)
+		return c.runHelper(child, reach)
 	}
 
 	policy := c.strategyProfile.GetPolicy(node)
@@ -58,32 +85,28 @@ func (c *ChanceSamplingCFR) handlePlayerNode(node GameTreeNode, reachP0, reachP1
 
 	regrets := c.slicePool.alloc(nChildren)
 	defer c.slicePool.free(regrets)
-	var cfValue float32
+	ev := c.slicePool.alloc(c.nPlayers)
+	reachPlayer := reach[player]
 	for i := 0; i < nChildren; i++ {
 		child := node.GetChild(i)
 		p := strategy[i]
-		var util float32
-		if player == 0 {
-			util = c.runHelper(child, player, p*reachP0, reachP1)
-		} else {
-			util = c.runHelper(child, player, reachP0, p*reachP1)
-		}
-
-		regrets[i] = util
-		cfValue += p * util
+		reach[player] = p * reachPlayer
+		childEV := c.runHelper(child, reach)
+		regrets[i] = childEV[player]
+		f32.AxpyUnitary(p, childEV, ev)
+		c.slicePool.free(childEV)
 	}
+	reach[player] = reachPlayer
 
 	// Transform action utilities into instantaneous regrets by
 	// subtracting out the expected utility over all possible actions.
-	f32.AddConst(-cfValue, regrets)
-	counterFactualP := counterFactualProb(player, reachP0, reachP1, 1.0)
+	f32.AddConst(-ev[player], regrets)
+	counterFactualP := counterFactualProb(player, reach, 1.0)
 	ones := c.slicePool.alloc(nChildren)
 	defer c.slicePool.free(ones)
-	for i := range ones {
-		ones[i] = 1.0
-	}
+	fillOnes(ones)
 	policy.AddRegret(counterFactualP, ones, regrets)
-	reachP := reachProb(player, reachP0, reachP1, 1.0)
+	reachP := reachProb(player, reach, 1.0)
 	policy.AddStrategyWeight(reachP)
-	return cfValue
+	return ev
 }
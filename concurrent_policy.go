@@ -0,0 +1,264 @@
+package cfr
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/timpalpant/go-cfr/internal/policy"
+)
+
+const numPolicyShards = 256
+
+func init() {
+	gob.Register(&ConcurrentPolicyTable{})
+}
+
+// ConcurrentPolicyTable is a PolicyTable that is safe for concurrent use by
+// multiple goroutines, so that many CFR traversals may be run in parallel
+// between calls to Update.
+//
+// Policies are partitioned into shards by InfoSet Key to reduce lock contention,
+// and each policy guards its accumulated regrets and strategy with its own lock.
+// Update must not be called concurrently with any other method.
+type ConcurrentPolicyTable struct {
+	params    DiscountParams
+	minimizer RegretMinimizer
+	iter      int
+
+	shards      [numPolicyShards]policyShard
+	numInfosets int64
+}
+
+type policyShard struct {
+	mx sync.Mutex
+	// Map of InfoSet Key -> the policy for that infoset.
+	policiesByKey map[string]*lockedPolicy
+	mayNeedUpdate map[*lockedPolicy]struct{}
+}
+
+// NewConcurrentPolicyTable creates a new ConcurrentPolicyTable with the given DiscountParams.
+func NewConcurrentPolicyTable(params DiscountParams) *ConcurrentPolicyTable {
+	return NewConcurrentPolicyTableWithRegretMinimizer(params, nil)
+}
+
+// NewConcurrentPolicyTableWithRegretMinimizer creates a new ConcurrentPolicyTable
+// that uses the given RegretMinimizer to compute the strategy at each InfoSet.
+// If rm is nil, regret matching is used.
+func NewConcurrentPolicyTableWithRegretMinimizer(params DiscountParams, rm RegretMinimizer) *ConcurrentPolicyTable {
+	pt := &ConcurrentPolicyTable{
+		params:    params,
+		minimizer: rm,
+		iter:      1,
+	}
+
+	pt.reset()
+	return pt
+}
+
+func (pt *ConcurrentPolicyTable) reset() {
+	for i := range pt.shards {
+		pt.shards[i].policiesByKey = make(map[string]*lockedPolicy)
+		pt.shards[i].mayNeedUpdate = make(map[*lockedPolicy]struct{})
+	}
+
+	pt.numInfosets = 0
+}
+
+func (pt *ConcurrentPolicyTable) getShard(key []byte) *policyShard {
+	h := fnv.New32a()
+	h.Write(key)
+	return &pt.shards[h.Sum32()%numPolicyShards]
+}
+
+// Update performs regret matching for all nodes within this strategy profile that have
+// been touched since the last call to Update(). Shards are updated in parallel.
+func (pt *ConcurrentPolicyTable) Update() {
+	params := pt.params.updateParams(pt.iter, pt.minimizer)
+	var wg sync.WaitGroup
+	for i := range pt.shards {
+		wg.Add(1)
+		go func(shard *policyShard) {
+			for p := range shard.mayNeedUpdate {
+				p.Policy.NextStrategy(params)
+				delete(shard.mayNeedUpdate, p)
+			}
+
+			wg.Done()
+		}(&pt.shards[i])
+	}
+
+	wg.Wait()
+	pt.iter++
+}
+
+func (pt *ConcurrentPolicyTable) Iter() int {
+	return pt.iter
+}
+
+func (pt *ConcurrentPolicyTable) Close() error {
+	return nil
+}
+
+func (pt *ConcurrentPolicyTable) GetPolicy(node GameTreeNode) NodePolicy {
+	key := node.InfoSetKey(node.Player())
+	shard := pt.getShard(key)
+	shard.mx.Lock()
+	defer shard.mx.Unlock()
+
+	np, ok := shard.policiesByKey[string(key)]
+	if !ok {
+		np = &lockedPolicy{Policy: policy.New(node.NumChildren())}
+		shard.policiesByKey[string(key)] = np
+		numInfosets.Set(atomic.AddInt64(&pt.numInfosets, 1))
+	} else if np.NumActions() != node.NumChildren() {
+		panic(fmt.Errorf("strategy has n_actions=%v but node has n_children=%v: %v",
+			np.NumActions(), node.NumChildren(), node))
+	}
+
+	shard.mayNeedUpdate[np] = struct{}{}
+	return np
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+//
+// The serialized format is identical to that of PolicyTable.
+func (pt *ConcurrentPolicyTable) UnmarshalBinary(buf []byte) error {
+	r := bytes.NewReader(buf)
+	dec := gob.NewDecoder(r)
+	if err := dec.Decode(&pt.params); err != nil {
+		return err
+	}
+
+	if err := dec.Decode(&pt.iter); err != nil {
+		return err
+	}
+
+	var nStrategies int
+	if err := dec.Decode(&nStrategies); err != nil {
+		return err
+	}
+
+	pt.reset()
+	for i := 0; i < nStrategies; i++ {
+		var key string
+		if err := dec.Decode(&key); err != nil {
+			return err
+		}
+
+		var p policy.Policy
+		if err := dec.Decode(&p); err != nil {
+			return err
+		}
+
+		shard := pt.getShard([]byte(key))
+		shard.policiesByKey[key] = &lockedPolicy{Policy: &p}
+	}
+
+	rm, err := decodeRegretMinimizer(dec)
+	if err != nil {
+		return err
+	}
+
+	pt.minimizer = rm
+
+	pt.numInfosets = int64(nStrategies)
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+//
+// The serialized format is identical to that of PolicyTable.
+func (pt *ConcurrentPolicyTable) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+	if err := enc.Encode(pt.params); err != nil {
+		return nil, err
+	}
+
+	if err := enc.Encode(pt.iter); err != nil {
+		return nil, err
+	}
+
+	nStrategies := 0
+	for i := range pt.shards {
+		nStrategies += len(pt.shards[i].policiesByKey)
+	}
+
+	if err := enc.Encode(nStrategies); err != nil {
+		return nil, err
+	}
+
+	for i := range pt.shards {
+		for key, p := range pt.shards[i].policiesByKey {
+			if err := enc.Encode(key); err != nil {
+				return nil, err
+			}
+
+			if err := enc.Encode(p.Policy); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := encodeRegretMinimizer(enc, pt.minimizer); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// lockedPolicy implements NodePolicy by guarding all updates to
+// the underlying policy.Policy with a mutex.
+//
+// The current strategy and strategy sum are only modified by NextStrategy,
+// and so may be read without holding the lock during traversals.
+type lockedPolicy struct {
+	mx sync.Mutex
+	*policy.Policy
+}
+
+func (l *lockedPolicy) AddRegret(w float32, samplingQ, instantaneousRegrets []float32) {
+	l.mx.Lock()
+	l.Policy.AddRegret(w, samplingQ, instantaneousRegrets)
+	l.mx.Unlock()
+}
+
+func (l *lockedPolicy) AddStrategyWeight(w float32) {
+	l.mx.Lock()
+	l.Policy.AddStrategyWeight(w)
+	l.mx.Unlock()
+}
+
+func (l *lockedPolicy) GetBaseline() []float32 {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	baseline := l.Policy.GetBaseline()
+	result := make([]float32, len(baseline))
+	copy(result, baseline)
+	return result
+}
+
+func (l *lockedPolicy) GetRegretSum() []float32 {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	regretSum := l.Policy.GetRegretSum()
+	result := make([]float32, len(regretSum))
+	copy(result, regretSum)
+	return result
+}
+
+func (l *lockedPolicy) UpdateBaseline(w float32, action int, value float32) {
+	l.mx.Lock()
+	l.Policy.UpdateBaseline(w, action, value)
+	l.mx.Unlock()
+}
+
+func (l *lockedPolicy) IsEmpty() bool {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	return l.Policy.IsEmpty()
+}
@@ -0,0 +1,161 @@
+package deepcfr
+
+import (
+	"encoding/gob"
+	"sync"
+	"time"
+
+	"github.com/timpalpant/go-cfr"
+)
+
+// BatchParams configure how concurrent calls to Predict are coalesced.
+type BatchParams struct {
+	// Maximum number of InfoSets predicted in one batch. Defaults to 256.
+	MaxBatchSize int
+	// Maximum time that a call to Predict waits for other calls to
+	// join its batch. Defaults to 1ms.
+	MaxDelay time.Duration
+}
+
+func (p BatchParams) maxBatchSize() int {
+	if p.MaxBatchSize <= 0 {
+		return 256
+	}
+
+	return p.MaxBatchSize
+}
+
+func (p BatchParams) maxDelay() time.Duration {
+	if p.MaxDelay <= 0 {
+		return time.Millisecond
+	}
+
+	return p.MaxDelay
+}
+
+// BatchingModel wraps a Model so that its TrainedModels are BatchedModels.
+type BatchingModel struct {
+	Model  Model
+	Params BatchParams
+}
+
+// NewBatchingModel returns a Model that trains the given model,
+// and coalesces concurrent predictions with the given parameters.
+func NewBatchingModel(model Model, params BatchParams) *BatchingModel {
+	return &BatchingModel{model, params}
+}
+
+// Train implements Model.
+func (m *BatchingModel) Train(buf Buffer) TrainedModel {
+	return NewBatchedModel(m.Model.Train(buf), m.Params)
+}
+
+// BatchedModel is a TrainedModel that coalesces concurrent calls to Predict,
+// for example from parallel CFR traversals, into calls to BatchPredict
+// on the underlying model.
+//
+// The first call to Predict in a batch waits up to MaxDelay for other calls
+// to join it, or until the batch is full, and then predicts the batch.
+// This adds latency to each call, so it is only worthwhile when many
+// traversals are run in parallel. If the underlying model panics, every
+// call in the batch panics with the same value.
+type BatchedModel struct {
+	Model  TrainedModel
+	Params BatchParams
+
+	mx      sync.Mutex
+	pending *predictBatch
+}
+
+// NewBatchedModel returns a new BatchedModel for the given model.
+func NewBatchedModel(model TrainedModel, params BatchParams) *BatchedModel {
+	return &BatchedModel{
+		Model:  model,
+		Params: params,
+	}
+}
+
+type predictBatch struct {
+	infoSets []cfr.InfoSet
+	nActions []int
+	results  [][]float32
+	// The value that BatchPredict panicked with, if it did.
+	panicked interface{}
+	full     chan struct{}
+	done     chan struct{}
+}
+
+func newPredictBatch(maxSize int) *predictBatch {
+	return &predictBatch{
+		infoSets: make([]cfr.InfoSet, 0, maxSize),
+		nActions: make([]int, 0, maxSize),
+		full:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Predict implements TrainedModel.
+func (m *BatchedModel) Predict(infoSet cfr.InfoSet, nActions int) []float32 {
+	maxBatchSize := m.Params.maxBatchSize()
+	m.mx.Lock()
+	b := m.pending
+	if b == nil {
+		b = newPredictBatch(maxBatchSize)
+		m.pending = b
+	}
+
+	idx := len(b.infoSets)
+	b.infoSets = append(b.infoSets, infoSet)
+	b.nActions = append(b.nActions, nActions)
+	if len(b.infoSets) >= maxBatchSize {
+		// No more requests can join this batch.
+		m.pending = nil
+		close(b.full)
+	}
+	m.mx.Unlock()
+
+	if idx == 0 {
+		// The first request in the batch is responsible for running it.
+		timer := time.NewTimer(m.Params.maxDelay())
+		select {
+		case <-b.full:
+			timer.Stop()
+		case <-timer.C:
+			m.mx.Lock()
+			if m.pending == b {
+				m.pending = nil
+			}
+			m.mx.Unlock()
+		}
+
+		b.run(m.Model)
+	}
+
+	<-b.done
+	if b.panicked != nil {
+		panic(b.panicked)
+	}
+
+	return b.results[idx]
+}
+
+// run predicts the batch with the given model. If the model panics, the panic
+// is stored, so that it can be raised in each of the callers waiting on done.
+func (b *predictBatch) run(model TrainedModel) {
+	defer close(b.done)
+	defer func() {
+		b.panicked = recover()
+	}()
+
+	b.results = BatchPredict(model, b.infoSets, b.nActions)
+}
+
+// BatchPredict implements BatchPredictor.
+func (m *BatchedModel) BatchPredict(infoSets []cfr.InfoSet, nActions []int) [][]float32 {
+	return BatchPredict(m.Model, infoSets, nActions)
+}
+
+func init() {
+	gob.Register(&BatchingModel{})
+	gob.Register(&BatchedModel{})
+}
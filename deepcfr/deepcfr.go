@@ -0,0 +1,256 @@
+package deepcfr
+
+import (
+	"bytes"
+	"encoding/gob"
+
+	"github.com/timpalpant/go-cfr"
+)
+
+// DeepCFR implements cfr.StrategyProfile for the original Deep CFR algorithm.
+// Like SingleDeepCFR, the current strategy of each player is estimated by a
+// model of its advantages. Unlike SingleDeepCFR, only the most recent advantage
+// model is kept, and the average strategy is estimated by a separate model
+// trained on samples of the current strategy, so that GetAverageStrategy
+// requires only a single prediction.
+//
+// During CFR iterations, regret samples are added to the advantage buffers,
+// and strategy samples are added to the strategy buffers. When Update is called,
+// the advantage model of the current player is retrained. The average strategy
+// models are only trained when TrainAverageStrategy is called, which is
+// typically done once at the end of training.
+//
+// Strategy samples are RegretSamples whose Advantages are the current strategy,
+// so that the same Model implementations can be used to fit both.
+type DeepCFR struct {
+	model           Model
+	buffers         []Buffer
+	trainedModels   []TrainedModel
+	strategyModel   Model
+	strategyBuffers []Buffer
+	strategyModels  []TrainedModel
+	iter            int
+}
+
+// NewDeepCFR returns a new DeepCFR policy with the given advantage model and
+// buffers, and average strategy model and buffers.
+func NewDeepCFR(model Model, buffers []Buffer, strategyModel Model, strategyBuffers []Buffer) *DeepCFR {
+	d := &DeepCFR{
+		model:           model,
+		buffers:         buffers,
+		trainedModels:   make([]TrainedModel, len(buffers)),
+		strategyModel:   strategyModel,
+		strategyBuffers: strategyBuffers,
+		strategyModels:  make([]TrainedModel, len(buffers)),
+		iter:            1,
+	}
+
+	setIter(d.iter, d.buffers)
+	setIter(d.iter, d.strategyBuffers)
+	return d
+}
+
+func (d *DeepCFR) GetBuffer(player int) Buffer {
+	return d.buffers[player]
+}
+
+func (d *DeepCFR) GetStrategyBuffer(player int) Buffer {
+	return d.strategyBuffers[player]
+}
+
+func (d *DeepCFR) currentPlayer() int {
+	return d.iter % len(d.buffers)
+}
+
+// GetPolicy implements cfr.StrategyProfile.
+func (d *DeepCFR) GetPolicy(node cfr.GameTreeNode) cfr.NodePolicy {
+	player := node.Player()
+	return &deepCFRPolicy{
+		node:          node,
+		buf:           d.buffers[player],
+		model:         d.trainedModels[player],
+		strategyBuf:   d.strategyBuffers[player],
+		strategyModel: d.strategyModels[player],
+		iter:          d.iter,
+		nPlayers:      len(d.buffers),
+	}
+}
+
+// Update implements cfr.StrategyProfile.
+func (d *DeepCFR) Update() {
+	player := d.currentPlayer()
+	buf := d.buffers[player]
+	trained := d.model.Train(buf)
+	d.trainedModels[player] = &AdvantageModel{trained}
+
+	d.iter++
+	setIter(d.iter, d.buffers)
+	setIter(d.iter, d.strategyBuffers)
+}
+
+// TrainAverageStrategy trains the average strategy model of each player
+// on the strategy samples collected so far.
+func (d *DeepCFR) TrainAverageStrategy() {
+	for player, buf := range d.strategyBuffers {
+		d.strategyModels[player] = d.strategyModel.Train(buf)
+	}
+}
+
+// Iter implements cfr.StrategyProfile.
+func (d *DeepCFR) Iter() int {
+	return d.iter
+}
+
+func (d *DeepCFR) Close() error {
+	for _, buf := range d.buffers {
+		if err := buf.Close(); err != nil {
+			return err
+		}
+	}
+
+	for _, buf := range d.strategyBuffers {
+		if err := buf.Close(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+// Note that to be able to use this method, the concrete types
+// implementing the Model, TrainedModel, and Buffers must be registered
+// with gob.
+func (d *DeepCFR) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+
+	// Need to pass pointer to interface so that Gob sees the interface rather
+	// than the concrete type. See the example in encoding/gob.
+	if err := enc.Encode(&d.model); err != nil {
+		return nil, err
+	}
+
+	if err := enc.Encode(d.buffers); err != nil {
+		return nil, err
+	}
+
+	if err := enc.Encode(d.trainedModels); err != nil {
+		return nil, err
+	}
+
+	if err := enc.Encode(&d.strategyModel); err != nil {
+		return nil, err
+	}
+
+	if err := enc.Encode(d.strategyBuffers); err != nil {
+		return nil, err
+	}
+
+	if err := enc.Encode(d.strategyModels); err != nil {
+		return nil, err
+	}
+
+	if err := enc.Encode(d.iter); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (d *DeepCFR) UnmarshalBinary(buf []byte) error {
+	r := bytes.NewReader(buf)
+	dec := gob.NewDecoder(r)
+
+	if err := dec.Decode(&d.model); err != nil {
+		return err
+	}
+
+	if err := dec.Decode(&d.buffers); err != nil {
+		return err
+	}
+
+	if err := dec.Decode(&d.trainedModels); err != nil {
+		return err
+	}
+
+	if err := dec.Decode(&d.strategyModel); err != nil {
+		return err
+	}
+
+	if err := dec.Decode(&d.strategyBuffers); err != nil {
+		return err
+	}
+
+	if err := dec.Decode(&d.strategyModels); err != nil {
+		return err
+	}
+
+	if err := dec.Decode(&d.iter); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type deepCFRPolicy struct {
+	node          cfr.GameTreeNode
+	buf           Buffer
+	model         TrainedModel
+	strategyBuf   Buffer
+	strategyModel TrainedModel
+	iter          int
+	nPlayers      int
+
+	strategy []float32
+}
+
+func (d *deepCFRPolicy) AddRegret(weight float32, samplingQ, instantaneousRegrets []float32) {
+	weight *= linearWeight(d.iter, d.nPlayers)
+	sample := NewRegretSample(d.node, instantaneousRegrets, weight)
+	d.buf.AddSample(sample)
+}
+
+func (d *deepCFRPolicy) IsEmpty() bool {
+	return d.model == nil
+}
+
+func (d *deepCFRPolicy) GetStrategy() []float32 {
+	if d.strategy == nil {
+		if d.model == nil {
+			d.strategy = uniformDist(d.node.NumChildren())
+		} else {
+			infoSet := d.node.InfoSet(d.node.Player())
+			d.strategy = d.model.Predict(infoSet, d.node.NumChildren())
+		}
+	}
+
+	return d.strategy
+}
+
+func (d *deepCFRPolicy) GetBaseline() []float32 {
+	return make([]float32, d.node.NumChildren())
+}
+
+func (d *deepCFRPolicy) UpdateBaseline(w float32, action int, value float32) {}
+
+func (d *deepCFRPolicy) AddStrategyWeight(w float32) {
+	w *= linearWeight(d.iter, d.nPlayers)
+	sample := NewRegretSample(d.node, d.GetStrategy(), w)
+	d.strategyBuf.AddSample(sample)
+}
+
+func (d *deepCFRPolicy) GetAverageStrategy() []float32 {
+	nChildren := d.node.NumChildren()
+	if d.strategyModel == nil {
+		return uniformDist(nChildren)
+	}
+
+	infoSet := d.node.InfoSet(d.node.Player())
+	return normalizeStrategy(d.strategyModel.Predict(infoSet, nChildren))
+}
+
+func init() {
+	gob.Register(&DeepCFR{})
+}
@@ -0,0 +1,307 @@
+package deepcfr
+
+import (
+	"bytes"
+	"encoding/gob"
+
+	"github.com/timpalpant/go-cfr"
+)
+
+// DREAM implements cfr.StrategyProfile for DREAM (Deep Regret minimization
+// with Advantage baselines and Model-free learning), a deep CFR variant that
+// only needs to sample a single outcome on each traversal. This makes it
+// tractable for games with large branching factors, where external sampling
+// is infeasible.
+//
+// It is intended to be used with cfr.VRMCCFR and outcome sampling for both
+// the traversing and non-traversing players, for example in a two-player
+// zero-sum game:
+//
+//	cfr.NewVRMCCFR(rng, dream, sampling.NewOutcomeSampler(rng, 0.5), sampling.NewOutcomeSampler(rng, 0))
+//
+// or cfr.NewGeneralSumVRMCCFR with the same arguments in other games.
+//
+// The variance of the sampled regrets is reduced with a learned Q-baseline,
+// which is trained on ExperienceTuples of the sampled value of each action.
+// Since the baseline estimates the value of a history rather than an InfoSet,
+// its samples and predictions use the HistoryInfoSet of each node.
+// The baseline is learned from the perspective of the player acting at each
+// node, and VRMCCFR uses it to correct that player's sampled utility. The
+// zero-sum VRMCCFR of cfr.NewVRMCCFR (or cfr.NewConstantSumVRMCCFR) also uses
+// the negated baseline to correct the other player's utility; with
+// cfr.NewGeneralSumVRMCCFR the baseline only reduces the variance of the
+// utility of the player acting at each node.
+//
+// Unlike VRSingleDeepCFR, which only saves the regrets of sampled actions,
+// the full vector of baseline-corrected regrets is saved as a RegretSample
+// on each visit to an InfoSet. As in SingleDeepCFR, the advantage model of
+// every iteration is kept to reconstruct the average strategy.
+//
+// See: Steinberger, Lerer and Brown, "DREAM: Deep Regret minimization with
+// Advantage baselines and Model-free learning" (https://arxiv.org/abs/2006.10410).
+type DREAM struct {
+	model           Model
+	buffers         []Buffer
+	trainedModels   [][]TrainedModel
+	baselineModel   Model
+	baselineBuffers []Buffer
+	baselineModels  []TrainedModel
+	iter            int
+}
+
+// NewDREAM returns a new DREAM policy with the given advantage model and
+// buffers, and baseline model and buffers.
+func NewDREAM(model Model, buffers []Buffer, baselineModel Model, baselineBuffers []Buffer) *DREAM {
+	d := &DREAM{
+		model:           model,
+		buffers:         buffers,
+		trainedModels:   make([][]TrainedModel, len(buffers)),
+		baselineModel:   baselineModel,
+		baselineBuffers: baselineBuffers,
+		baselineModels:  make([]TrainedModel, len(buffers)),
+		iter:            1,
+	}
+
+	setIter(d.iter, d.buffers)
+	setIter(d.iter, d.baselineBuffers)
+	return d
+}
+
+func (d *DREAM) GetBuffer(player int) Buffer {
+	return d.buffers[player]
+}
+
+func (d *DREAM) GetBaselineBuffer(player int) Buffer {
+	return d.baselineBuffers[player]
+}
+
+func (d *DREAM) currentPlayer() int {
+	return d.iter % len(d.buffers)
+}
+
+// GetPolicy implements cfr.StrategyProfile.
+func (d *DREAM) GetPolicy(node cfr.GameTreeNode) cfr.NodePolicy {
+	player := node.Player()
+	return &dreamPolicy{
+		node:          node,
+		buf:           d.buffers[player],
+		baselineBuf:   d.baselineBuffers[player],
+		models:        d.trainedModels[player],
+		baselineModel: d.baselineModels[player],
+		iter:          d.iter,
+		nPlayers:      len(d.buffers),
+	}
+}
+
+// Update implements cfr.StrategyProfile.
+func (d *DREAM) Update() {
+	player := d.currentPlayer()
+	buf := d.buffers[player]
+	trained := d.model.Train(buf)
+	model := &AdvantageModel{trained}
+	d.trainedModels[player] = append(d.trainedModels[player], model)
+
+	baselineBuf := d.baselineBuffers[player]
+	d.baselineModels[player] = d.baselineModel.Train(baselineBuf)
+
+	d.iter++
+	setIter(d.iter, d.buffers)
+	setIter(d.iter, d.baselineBuffers)
+}
+
+// Iter implements cfr.StrategyProfile.
+func (d *DREAM) Iter() int {
+	return d.iter
+}
+
+func (d *DREAM) Close() error {
+	for _, buf := range d.buffers {
+		if err := buf.Close(); err != nil {
+			return err
+		}
+	}
+
+	for _, buf := range d.baselineBuffers {
+		if err := buf.Close(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+// Note that to be able to use this method, the concrete types
+// implementing the Model, TrainedModel, and Buffers must be registered
+// with gob.
+func (d *DREAM) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+
+	// Need to pass pointer to interface so that Gob sees the interface rather
+	// than the concrete type. See the example in encoding/gob.
+	if err := enc.Encode(&d.model); err != nil {
+		return nil, err
+	}
+
+	if err := enc.Encode(d.buffers); err != nil {
+		return nil, err
+	}
+
+	if err := enc.Encode(d.trainedModels); err != nil {
+		return nil, err
+	}
+
+	if err := enc.Encode(&d.baselineModel); err != nil {
+		return nil, err
+	}
+
+	if err := enc.Encode(d.baselineBuffers); err != nil {
+		return nil, err
+	}
+
+	if err := enc.Encode(d.baselineModels); err != nil {
+		return nil, err
+	}
+
+	if err := enc.Encode(d.iter); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (d *DREAM) UnmarshalBinary(buf []byte) error {
+	r := bytes.NewReader(buf)
+	dec := gob.NewDecoder(r)
+
+	if err := dec.Decode(&d.model); err != nil {
+		return err
+	}
+
+	if err := dec.Decode(&d.buffers); err != nil {
+		return err
+	}
+
+	if err := dec.Decode(&d.trainedModels); err != nil {
+		return err
+	}
+
+	if err := dec.Decode(&d.baselineModel); err != nil {
+		return err
+	}
+
+	if err := dec.Decode(&d.baselineBuffers); err != nil {
+		return err
+	}
+
+	if err := dec.Decode(&d.baselineModels); err != nil {
+		return err
+	}
+
+	if err := dec.Decode(&d.iter); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type dreamPolicy struct {
+	node          cfr.GameTreeNode
+	buf           Buffer
+	baselineBuf   Buffer
+	models        []TrainedModel
+	baselineModel TrainedModel
+	iter          int
+	nPlayers      int
+
+	history  *HistoryInfoSet
+	strategy []float32
+	baseline []float32
+}
+
+func (d *dreamPolicy) currentModel() TrainedModel {
+	if len(d.models) == 0 {
+		return nil
+	}
+
+	return d.models[len(d.models)-1]
+}
+
+func (d *dreamPolicy) getHistory() *HistoryInfoSet {
+	if d.history == nil {
+		d.history = NewHistoryInfoSet(d.node)
+	}
+
+	return d.history
+}
+
+func (d *dreamPolicy) IsEmpty() bool {
+	return d.currentModel() == nil
+}
+
+func (d *dreamPolicy) AddRegret(weight float32, samplingQ, instantaneousRegrets []float32) {
+	// With a baseline, the regrets of actions that were not sampled are
+	// also estimated, so we save the full vector of regrets.
+	weight *= linearWeight(d.iter, d.nPlayers)
+	sample := NewRegretSample(d.node, instantaneousRegrets, weight)
+	d.buf.AddSample(sample)
+}
+
+func (d *dreamPolicy) GetStrategy() []float32 {
+	if d.strategy == nil {
+		model := d.currentModel()
+		if model == nil {
+			d.strategy = uniformDist(d.node.NumChildren())
+		} else {
+			infoSet := d.node.InfoSet(d.node.Player())
+			d.strategy = model.Predict(infoSet, d.node.NumChildren())
+		}
+	}
+
+	return d.strategy
+}
+
+func (d *dreamPolicy) GetBaseline() []float32 {
+	if d.baseline == nil {
+		if d.baselineModel == nil {
+			d.baseline = make([]float32, d.node.NumChildren())
+		} else {
+			d.baseline = d.baselineModel.Predict(d.getHistory(), d.node.NumChildren())
+		}
+	}
+
+	return d.baseline
+}
+
+func (d *dreamPolicy) UpdateBaseline(w float32, action int, value float32) {
+	w *= linearWeight(d.iter, d.nPlayers)
+	history, err := d.getHistory().MarshalBinary()
+	if err != nil {
+		panic(err)
+	}
+
+	sample := &ExperienceTuple{
+		Weight:  w,
+		InfoSet: history,
+		Action:  uint16(action),
+		Value:   value,
+	}
+
+	d.baselineBuf.AddSample(sample)
+}
+
+func (d *dreamPolicy) AddStrategyWeight(w float32) {
+	// As in SD-CFR, the average strategy is reconstructed from the
+	// advantage models, so we don't save strategy weight samples.
+}
+
+func (d *dreamPolicy) GetAverageStrategy() []float32 {
+	return getAverageStrategy(d.node, d.models, nil)
+}
+
+func init() {
+	gob.Register(&DREAM{})
+	gob.Register(&HistoryInfoSet{})
+}
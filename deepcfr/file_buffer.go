@@ -0,0 +1,573 @@
+package deepcfr
+
+import (
+	"bufio"
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Sample type tags used in FileBuffer records.
+const (
+	recordRegretSample    byte = 1
+	recordExperienceTuple byte = 2
+)
+
+const shardPattern = "shard-%08d.samples"
+
+// FileBuffer is a StreamingBuffer that appends samples to a sequence of
+// shard files in a directory, so that it can hold many more samples than
+// fit in memory. Every sample that is added is kept.
+//
+// Each shard holds up to ShardSize samples, as a sequence of records:
+// a 1-byte sample type (1 for RegretSample, 2 for ExperienceTuple),
+// a 4-byte little-endian length, and the sample's MarshalBinary.
+//
+// Shuffle reads from all shards at once, drawing each next record from
+// a shard chosen in proportion to the records it has left, and passes them
+// through an in-memory pool of ShardSize samples from which they are
+// returned in random order. Samples from every part of the buffer are thus
+// mixed together, but samples that are close to each other on disk are more
+// likely to be returned close together. A larger ShardSize mixes the
+// samples better, at the cost of holding more of them in memory; Shuffle
+// also keeps one file open for each shard that has not been read through.
+//
+// It is safe to call AddSample concurrently from multiple goroutines,
+// and to iterate while samples are being added. Iterators see the samples
+// that were added before they were created.
+type FileBuffer struct {
+	dir       string
+	shardSize int
+
+	mx     sync.Mutex
+	counts []int // Number of samples in each shard.
+	f      *os.File
+	w      *bufio.Writer
+	n      int
+}
+
+// NewFileBuffer returns a FileBuffer that stores samples in the given
+// directory, with up to shardSize samples per file. If the directory
+// already contains shards, new samples are appended to them.
+func NewFileBuffer(dir string, shardSize int) (*FileBuffer, error) {
+	if shardSize <= 0 {
+		return nil, fmt.Errorf("shard size must be positive, got %d", shardSize)
+	}
+
+	b := &FileBuffer{dir: dir, shardSize: shardSize}
+	if err := b.open(); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// open loads the counts of samples in any existing shards.
+func (b *FileBuffer) open() error {
+	if err := os.MkdirAll(b.dir, 0755); err != nil {
+		return err
+	}
+
+	paths, err := filepath.Glob(filepath.Join(b.dir, "shard-*.samples"))
+	if err != nil {
+		return err
+	}
+
+	sort.Strings(paths)
+	b.counts = nil
+	b.n = 0
+	for i, path := range paths {
+		if path != b.shardPath(i) {
+			return fmt.Errorf("unexpected shard file %s", path)
+		}
+
+		count, err := countRecords(path)
+		if err != nil {
+			return err
+		}
+
+		b.counts = append(b.counts, count)
+		b.n += count
+	}
+
+	return nil
+}
+
+// countRecords returns the number of complete records in the shard.
+// A trailing partial record (for example, from a crash while writing)
+// is truncated.
+func countRecords(path string) (int, error) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	var count int
+	var offset int64
+	for {
+		var header [5]byte
+		if _, err := io.ReadFull(r, header[:]); err == io.EOF {
+			return count, nil
+		} else if err == io.ErrUnexpectedEOF {
+			return count, f.Truncate(offset)
+		} else if err != nil {
+			return 0, err
+		}
+
+		n := int64(binary.LittleEndian.Uint32(header[1:]))
+		if m, err := r.Discard(int(n)); int64(m) < n {
+			if err == io.EOF {
+				return count, f.Truncate(offset)
+			}
+
+			return 0, err
+		}
+
+		offset += int64(len(header)) + n
+		count++
+	}
+}
+
+func (b *FileBuffer) shardPath(i int) string {
+	return filepath.Join(b.dir, fmt.Sprintf(shardPattern, i))
+}
+
+// AddSample implements Buffer. It panics if the sample cannot be written.
+func (b *FileBuffer) AddSample(sample Sample) {
+	record, err := encodeRecord(sample)
+	if err != nil {
+		panic(err)
+	}
+
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	if err := b.ensureWritable(); err != nil {
+		panic(err)
+	}
+
+	if _, err := b.w.Write(record); err != nil {
+		panic(err)
+	}
+
+	b.counts[len(b.counts)-1]++
+	b.n++
+}
+
+// ensureWritable opens the shard that new samples are appended to,
+// starting a new shard if the last one is full.
+func (b *FileBuffer) ensureWritable() error {
+	last := len(b.counts) - 1
+	if b.f != nil && b.counts[last] < b.shardSize {
+		return nil
+	}
+
+	if err := b.closeShard(); err != nil {
+		return err
+	}
+
+	if last < 0 || b.counts[last] >= b.shardSize {
+		b.counts = append(b.counts, 0)
+		last++
+	}
+
+	f, err := os.OpenFile(b.shardPath(last), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	b.f = f
+	b.w = bufio.NewWriter(f)
+	return nil
+}
+
+func (b *FileBuffer) closeShard() error {
+	if b.f == nil {
+		return nil
+	}
+
+	if err := b.w.Flush(); err != nil {
+		return err
+	}
+
+	err := b.f.Close()
+	b.f = nil
+	b.w = nil
+	return err
+}
+
+// snapshot flushes any buffered samples and returns the current shard counts.
+func (b *FileBuffer) snapshot() []int {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	if b.w != nil {
+		if err := b.w.Flush(); err != nil {
+			panic(err)
+		}
+	}
+
+	return append([]int(nil), b.counts...)
+}
+
+// GetSample implements Buffer. It reads through the shard containing
+// the sample, so random access is slow.
+func (b *FileBuffer) GetSample(idx int) Sample {
+	counts := b.snapshot()
+	shard := 0
+	for shard < len(counts) && idx >= counts[shard] {
+		idx -= counts[shard]
+		shard++
+	}
+
+	if shard == len(counts) {
+		panic(fmt.Errorf("sample index out of range"))
+	}
+
+	it := b.iterate([]int{shard}, counts)
+	defer it.Close()
+	for i := 0; i <= idx; i++ {
+		if !it.Next() {
+			panic(it.Err())
+		}
+	}
+
+	return it.Sample()
+}
+
+// GetSamples implements Buffer. It reads all samples into memory;
+// use Iterate or Shuffle for large buffers.
+func (b *FileBuffer) GetSamples() []Sample {
+	result := make([]Sample, 0, b.Len())
+	it := b.Iterate()
+	defer it.Close()
+	for it.Next() {
+		result = append(result, it.Sample())
+	}
+
+	if err := it.Err(); err != nil {
+		panic(err)
+	}
+
+	return result
+}
+
+// Len implements Buffer.
+func (b *FileBuffer) Len() int {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	return b.n
+}
+
+// Iterate implements StreamingBuffer. Samples are returned in the order
+// they were added.
+func (b *FileBuffer) Iterate() SampleIterator {
+	counts := b.snapshot()
+	shards := make([]int, len(counts))
+	for i := range shards {
+		shards[i] = i
+	}
+
+	return b.iterate(shards, counts)
+}
+
+// Shuffle implements StreamingBuffer. Records are interleaved across all
+// shards and shuffled through a pool of ShardSize samples.
+func (b *FileBuffer) Shuffle(rng *rand.Rand) SampleIterator {
+	counts := b.snapshot()
+	it := &shuffleIterator{
+		rng:      rng,
+		shards:   make([]*fileIterator, len(counts)),
+		left:     append([]int(nil), counts...),
+		poolSize: b.shardSize,
+	}
+
+	for i, count := range counts {
+		it.shards[i] = b.iterate([]int{i}, counts)
+		it.remaining += count
+	}
+
+	return it
+}
+
+func (b *FileBuffer) iterate(shards, counts []int) *fileIterator {
+	return &fileIterator{
+		b:      b,
+		shards: shards,
+		counts: counts,
+	}
+}
+
+// Close implements io.Closer.
+func (b *FileBuffer) Close() error {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	return b.closeShard()
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+// Only the location of the buffer is saved; the samples remain in their files.
+func (b *FileBuffer) MarshalBinary() ([]byte, error) {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	if b.w != nil {
+		if err := b.w.Flush(); err != nil {
+			return nil, err
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+
+	if err := enc.Encode(b.dir); err != nil {
+		return nil, err
+	}
+
+	if err := enc.Encode(b.shardSize); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (b *FileBuffer) UnmarshalBinary(buf []byte) error {
+	r := bytes.NewReader(buf)
+	dec := gob.NewDecoder(r)
+
+	if err := dec.Decode(&b.dir); err != nil {
+		return err
+	}
+
+	if err := dec.Decode(&b.shardSize); err != nil {
+		return err
+	}
+
+	return b.open()
+}
+
+// fileIterator reads the given shards in order, up to the number of samples
+// each had when the iterator was created.
+type fileIterator struct {
+	b      *FileBuffer
+	shards []int
+	counts []int
+
+	f         *os.File
+	r         *bufio.Reader
+	remaining int
+
+	sample Sample
+	err    error
+}
+
+func (it *fileIterator) Next() bool {
+	for it.err == nil {
+		if it.remaining > 0 {
+			it.sample, it.err = readRecord(it.r)
+			it.remaining--
+			return it.err == nil
+		}
+
+		if len(it.shards) == 0 {
+			return false
+		}
+
+		it.err = it.openShard(it.shards[0])
+		it.shards = it.shards[1:]
+	}
+
+	return false
+}
+
+func (it *fileIterator) openShard(shard int) error {
+	if err := it.Close(); err != nil {
+		return err
+	}
+
+	f, err := os.Open(it.b.shardPath(shard))
+	if err != nil {
+		return err
+	}
+
+	it.f = f
+	it.r = bufio.NewReader(f)
+	it.remaining = it.counts[shard]
+	return nil
+}
+
+func (it *fileIterator) Sample() Sample {
+	return it.sample
+}
+
+func (it *fileIterator) Err() error {
+	return it.err
+}
+
+func (it *fileIterator) Close() error {
+	if it.f == nil {
+		return nil
+	}
+
+	err := it.f.Close()
+	it.f = nil
+	it.r = nil
+	return err
+}
+
+// shuffleIterator interleaves the records of all shards, choosing the shard
+// to read from next in proportion to the number of records it has left,
+// and returns them in random order from a pool of up to poolSize samples.
+type shuffleIterator struct {
+	rng       *rand.Rand
+	shards    []*fileIterator
+	left      []int // Number of records not yet read from each shard.
+	remaining int   // Total number of records not yet read.
+	poolSize  int
+	pool      []Sample
+
+	sample Sample
+	err    error
+}
+
+func (it *shuffleIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	for len(it.pool) < it.poolSize && it.remaining > 0 {
+		sample, err := it.readRandom()
+		if err != nil {
+			it.err = err
+			return false
+		}
+
+		it.pool = append(it.pool, sample)
+	}
+
+	if len(it.pool) == 0 {
+		return false
+	}
+
+	i := it.rng.Intn(len(it.pool))
+	last := len(it.pool) - 1
+	it.sample = it.pool[i]
+	it.pool[i] = it.pool[last]
+	it.pool[last] = nil
+	it.pool = it.pool[:last]
+	return true
+}
+
+// readRandom reads the next record of a shard chosen with probability
+// proportional to the number of records it has left.
+func (it *shuffleIterator) readRandom() (Sample, error) {
+	k := it.rng.Intn(it.remaining)
+	for i, shard := range it.shards {
+		if k >= it.left[i] {
+			k -= it.left[i]
+			continue
+		}
+
+		it.left[i]--
+		it.remaining--
+		if !shard.Next() {
+			if err := shard.Err(); err != nil {
+				return nil, err
+			}
+
+			return nil, io.ErrUnexpectedEOF
+		}
+
+		if it.left[i] == 0 {
+			if err := shard.Close(); err != nil {
+				return nil, err
+			}
+		}
+
+		return shard.Sample(), nil
+	}
+
+	panic("unreachable")
+}
+
+func (it *shuffleIterator) Sample() Sample {
+	return it.sample
+}
+
+func (it *shuffleIterator) Err() error {
+	return it.err
+}
+
+func (it *shuffleIterator) Close() error {
+	var result error
+	for _, shard := range it.shards {
+		if err := shard.Close(); err != nil && result == nil {
+			result = err
+		}
+	}
+
+	return result
+}
+
+func encodeRecord(sample Sample) ([]byte, error) {
+	var recordType byte
+	switch sample.(type) {
+	case *RegretSample:
+		recordType = recordRegretSample
+	case *ExperienceTuple:
+		recordType = recordExperienceTuple
+	default:
+		return nil, fmt.Errorf("unsupported sample type %T", sample)
+	}
+
+	buf, err := sample.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	record := make([]byte, 5+len(buf))
+	record[0] = recordType
+	binary.LittleEndian.PutUint32(record[1:], uint32(len(buf)))
+	copy(record[5:], buf)
+	return record, nil
+}
+
+func readRecord(r io.Reader) (Sample, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, binary.LittleEndian.Uint32(header[1:]))
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, err
+	}
+
+	var sample encoding.BinaryUnmarshaler
+	switch header[0] {
+	case recordRegretSample:
+		sample = &RegretSample{}
+	case recordExperienceTuple:
+		sample = &ExperienceTuple{}
+	default:
+		return nil, fmt.Errorf("unknown sample type %d", header[0])
+	}
+
+	if err := sample.UnmarshalBinary(buf); err != nil {
+		return nil, err
+	}
+
+	return sample, nil
+}
+
+func init() {
+	gob.Register(&FileBuffer{})
+}
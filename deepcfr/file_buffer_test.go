@@ -0,0 +1,212 @@
+package deepcfr
+
+import (
+	"bytes"
+	"encoding/gob"
+	"math/rand"
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func newTestSample(i int) Sample {
+	if i%2 == 0 {
+		return &RegretSample{
+			Weight:     float32(i),
+			InfoSet:    []byte{byte(i)},
+			Advantages: []float32{float32(i), -1},
+		}
+	}
+
+	return &ExperienceTuple{
+		Weight:  float32(i),
+		InfoSet: []byte{byte(i)},
+		Action:  1,
+		Value:   float32(i),
+	}
+}
+
+func sampleWeight(s Sample) int {
+	switch s := s.(type) {
+	case *RegretSample:
+		return int(s.Weight)
+	case *ExperienceTuple:
+		return int(s.Weight)
+	}
+
+	panic("unknown sample type")
+}
+
+func TestFileBuffer(t *testing.T) {
+	dir := t.TempDir()
+	buf, err := NewFileBuffer(dir, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 10; i++ {
+		buf.AddSample(newTestSample(i))
+	}
+
+	if buf.Len() != 10 {
+		t.Errorf("expected 10 samples, got %d", buf.Len())
+	}
+
+	// Samples are iterated in the order they were added.
+	samples := buf.GetSamples()
+	for i, sample := range samples {
+		if !reflect.DeepEqual(sample, newTestSample(i)) {
+			t.Errorf("sample %d: expected %v, got %v", i, newTestSample(i), sample)
+		}
+	}
+
+	if sample := buf.GetSample(6); !reflect.DeepEqual(sample, newTestSample(6)) {
+		t.Errorf("expected %v, got %v", newTestSample(6), sample)
+	}
+
+	// Each epoch visits every sample once.
+	rng := rand.New(rand.NewSource(123))
+	it := ShuffleSamples(rng, buf)
+	var seen []int
+	batch := make([]Sample, 3)
+	for n := NextBatch(it, batch, nil); n > 0; n = NextBatch(it, batch, nil) {
+		for _, sample := range batch[:n] {
+			seen = append(seen, sampleWeight(sample))
+		}
+	}
+
+	if err := it.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if sort.IntsAreSorted(seen) {
+		t.Errorf("expected samples to be shuffled, got %v", seen)
+	}
+
+	sort.Ints(seen)
+	if !reflect.DeepEqual(seen, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}) {
+		t.Errorf("expected each sample once, got %v", seen)
+	}
+
+	// Reopening the directory appends to the existing shards.
+	if err := buf.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewFileBuffer(dir, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+
+	reopened.AddSample(newTestSample(10))
+	samples = reopened.GetSamples()
+	if len(samples) != 11 || !reflect.DeepEqual(samples[10], newTestSample(10)) {
+		t.Errorf("expected 11 samples after reopening, got %v", samples)
+	}
+}
+
+func TestFileBuffer_ShuffleAcrossShards(t *testing.T) {
+	buf, err := NewFileBuffer(t.TempDir(), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer buf.Close()
+
+	for i := 0; i < 100; i++ {
+		buf.AddSample(newTestSample(i))
+	}
+
+	// Consecutive samples are drawn from many different shards,
+	// rather than from one shard at a time.
+	rng := rand.New(rand.NewSource(123))
+	nTrials := 100
+	var nShards int
+	for trial := 0; trial < nTrials; trial++ {
+		it := buf.Shuffle(rng)
+		shards := make(map[int]bool)
+		for i := 0; i < 5 && it.Next(); i++ {
+			shards[sampleWeight(it.Sample())/5] = true
+		}
+
+		if err := it.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		nShards += len(shards)
+	}
+
+	if mean := float64(nShards) / float64(nTrials); mean < 4 {
+		t.Errorf("expected the first 5 samples to come from ~5 shards, got %v", mean)
+	}
+}
+
+func TestFileBuffer_TruncatedRecord(t *testing.T) {
+	dir := t.TempDir()
+	buf, err := NewFileBuffer(dir, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		buf.AddSample(newTestSample(i))
+	}
+
+	buf.Close()
+
+	// Simulate a crash partway through writing the last record.
+	path := buf.shardPath(0)
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Truncate(path, info.Size()-2); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewFileBuffer(dir, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+
+	reopened.AddSample(newTestSample(3))
+	weights := make([]int, 0)
+	for _, sample := range reopened.GetSamples() {
+		weights = append(weights, sampleWeight(sample))
+	}
+
+	if !reflect.DeepEqual(weights, []int{0, 1, 3}) {
+		t.Errorf("expected samples [0 1 3], got %v", weights)
+	}
+}
+
+func TestFileBuffer_Marshal(t *testing.T) {
+	buf, err := NewFileBuffer(t.TempDir(), 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer buf.Close()
+
+	for i := 0; i < 5; i++ {
+		buf.AddSample(newTestSample(i))
+	}
+
+	var b bytes.Buffer
+	var buffer Buffer = buf
+	if err := gob.NewEncoder(&b).Encode(&buffer); err != nil {
+		t.Fatal(err)
+	}
+
+	var reloaded Buffer
+	if err := gob.NewDecoder(&b).Decode(&reloaded); err != nil {
+		t.Fatal(err)
+	}
+	defer reloaded.Close()
+
+	if !reflect.DeepEqual(reloaded.GetSamples(), buf.GetSamples()) {
+		t.Errorf("expected %v, got %v", buf.GetSamples(), reloaded.GetSamples())
+	}
+}
@@ -0,0 +1,85 @@
+package deepcfr
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/timpalpant/go-cfr"
+)
+
+// HistoryInfoSet implements cfr.InfoSet for the full history of a game node,
+// as seen by all players. It is used as the input to learned baselines, which
+// may depend on information that is hidden from the acting player.
+//
+// Parts holds the binary-marshaled InfoSet of each player at the node.
+// Models may featurize a HistoryInfoSet by unmarshaling each part into
+// the InfoSet type of the game.
+type HistoryInfoSet struct {
+	Parts [][]byte
+}
+
+// NewHistoryInfoSet returns the HistoryInfoSet of the given node.
+func NewHistoryInfoSet(node cfr.GameTreeNode) *HistoryInfoSet {
+	nPlayers := cfr.NumPlayers(node)
+	parts := make([][]byte, nPlayers)
+	for player := range parts {
+		buf, err := node.InfoSet(player).MarshalBinary()
+		if err != nil {
+			panic(err)
+		}
+
+		parts[player] = buf
+	}
+
+	return &HistoryInfoSet{parts}
+}
+
+// Key implements cfr.InfoSet.
+func (h *HistoryInfoSet) Key() []byte {
+	buf, _ := h.MarshalBinary()
+	return buf
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+// Each part is prefixed by its length.
+func (h *HistoryInfoSet) MarshalBinary() ([]byte, error) {
+	n := 0
+	for _, part := range h.Parts {
+		n += 4 + len(part)
+	}
+
+	result := make([]byte, n)
+	buf := result
+	for _, part := range h.Parts {
+		binary.LittleEndian.PutUint32(buf, uint32(len(part)))
+		buf = buf[4:]
+		copy(buf, part)
+		buf = buf[len(part):]
+	}
+
+	return result, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (h *HistoryInfoSet) UnmarshalBinary(buf []byte) error {
+	h.Parts = nil
+	for len(buf) > 0 {
+		if len(buf) < 4 {
+			return fmt.Errorf("invalid history info set: %d trailing bytes", len(buf))
+		}
+
+		n := int(binary.LittleEndian.Uint32(buf))
+		buf = buf[4:]
+		if n > len(buf) {
+			return fmt.Errorf("invalid history info set: part length %d exceeds %d remaining bytes", n, len(buf))
+		}
+
+		// UnmarshalBinary must copy the data it wishes to keep.
+		part := make([]byte, n)
+		copy(part, buf)
+		h.Parts = append(h.Parts, part)
+		buf = buf[n:]
+	}
+
+	return nil
+}
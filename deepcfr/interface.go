@@ -29,3 +29,25 @@ type Model interface {
 type TrainedModel interface {
 	Predict(infoSet cfr.InfoSet, nActions int) (advantages []float32)
 }
+
+// BatchPredictor is an optional interface that a TrainedModel may implement
+// if it can predict many InfoSets at once more efficiently than one at a time,
+// for example by evaluating a network on a batch of inputs.
+type BatchPredictor interface {
+	BatchPredict(infoSets []cfr.InfoSet, nActions []int) [][]float32
+}
+
+// BatchPredict returns the predictions of the model for each of the given
+// InfoSets, using BatchPredictor if the model implements it.
+func BatchPredict(model TrainedModel, infoSets []cfr.InfoSet, nActions []int) [][]float32 {
+	if bp, ok := model.(BatchPredictor); ok {
+		return bp.BatchPredict(infoSets, nActions)
+	}
+
+	result := make([][]float32, len(infoSets))
+	for i, infoSet := range infoSets {
+		result[i] = model.Predict(infoSet, nActions[i])
+	}
+
+	return result
+}
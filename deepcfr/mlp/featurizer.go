@@ -0,0 +1,46 @@
+package mlp
+
+import (
+	"encoding/gob"
+	"hash/fnv"
+)
+
+// Featurizer converts a binary-marshaled InfoSet into the input vector
+// of a network. Implementations must be registered with gob so that
+// Models and Networks that use them can be serialized.
+type Featurizer interface {
+	// NumFeatures returns the length of the feature vector.
+	NumFeatures() int
+	// Featurize fills features (which is zeroed, of length NumFeatures())
+	// with the features of the given InfoSet.
+	Featurize(infoSet []byte, features []float32)
+}
+
+// HashingFeaturizer is a generic Featurizer that hashes each (position, byte)
+// of the InfoSet into one of Size buckets. It requires no knowledge of the
+// game, but a featurizer designed for the game will generalize much better.
+type HashingFeaturizer struct {
+	Size int
+}
+
+// NumFeatures implements Featurizer.
+func (h HashingFeaturizer) NumFeatures() int {
+	return h.Size
+}
+
+// Featurize implements Featurizer.
+func (h HashingFeaturizer) Featurize(infoSet []byte, features []float32) {
+	hasher := fnv.New32a()
+	var buf [5]byte
+	for i, b := range infoSet {
+		buf[0], buf[1], buf[2], buf[3] = byte(i), byte(i>>8), byte(i>>16), byte(i>>24)
+		buf[4] = b
+		hasher.Reset()
+		hasher.Write(buf[:])
+		features[int(hasher.Sum32()%uint32(h.Size))] += 1.0
+	}
+}
+
+func init() {
+	gob.Register(HashingFeaturizer{})
+}
@@ -0,0 +1,135 @@
+package mlp
+
+import (
+	"bytes"
+	"encoding/gob"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/timpalpant/go-cfr/deepcfr"
+)
+
+type testInfoSet string
+
+func (s testInfoSet) Key() []byte                       { return []byte(s) }
+func (s testInfoSet) MarshalBinary() ([]byte, error)    { return []byte(s), nil }
+func (s *testInfoSet) UnmarshalBinary(buf []byte) error { *s = testInfoSet(buf); return nil }
+
+func newTestModel(rng *rand.Rand) *Model {
+	return NewModel(rng, HashingFeaturizer{Size: 32}, Params{
+		HiddenLayers: []int{32, 32},
+		MaxActions:   3,
+		BatchSize:    32,
+		NumBatches:   2000,
+		LearningRate: 0.01,
+	})
+}
+
+func TestTrain_RegretSamples(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
+	targets := map[string][]float32{
+		"a-1": {1.0, -1.0, 0.5},
+		"a-2": {-0.5, 2.0, 0.0},
+		"b-1": {0.0, 0.0, -2.0},
+		"b-2": {1.5, 0.5, 1.0},
+	}
+
+	// Keys are sorted so that the samples do not depend on map iteration order.
+	keys := make([]string, 0, len(targets))
+	for key := range targets {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	buf := deepcfr.NewReservoirBuffer(rng, 1000, 1)
+	for i := 0; i < 100; i++ {
+		for _, key := range keys {
+			advantages := targets[key]
+			// Noisy samples, whose weighted mean is the target.
+			noise := float32(rng.NormFloat64() * 0.1)
+			sample := &deepcfr.RegretSample{
+				Weight:     1.0 + float32(i%3),
+				InfoSet:    []byte(key),
+				Advantages: []float32{advantages[0] + noise, advantages[1] - noise, advantages[2]},
+			}
+
+			buf.AddSample(sample)
+		}
+	}
+
+	model := newTestModel(rng)
+	trained := model.Train(buf)
+	for key, expected := range targets {
+		infoSet := testInfoSet(key)
+		predicted := trained.Predict(&infoSet, len(expected))
+		for i, x := range expected {
+			if math.Abs(float64(predicted[i]-x)) > 0.1 {
+				t.Errorf("%s: expected %v, got %v", key, expected, predicted)
+				break
+			}
+		}
+	}
+}
+
+func TestTrain_ExperienceTuples(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
+	buf := deepcfr.NewReservoirBuffer(rng, 1000, 1)
+	for i := 0; i < 100; i++ {
+		buf.AddSample(&deepcfr.ExperienceTuple{Weight: 1.0, InfoSet: []byte("x"), Action: 0, Value: 1.0})
+		buf.AddSample(&deepcfr.ExperienceTuple{Weight: 1.0, InfoSet: []byte("x"), Action: 2, Value: -1.0})
+	}
+
+	trained := newTestModel(rng).Train(buf)
+	infoSet := testInfoSet("x")
+	predicted := trained.Predict(&infoSet, 3)
+	if math.Abs(float64(predicted[0]-1.0)) > 0.1 || math.Abs(float64(predicted[2]+1.0)) > 0.1 {
+		t.Errorf("expected [1, ?, -1], got %v", predicted)
+	}
+}
+
+func TestTrain_FileBuffer(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
+	buf, err := deepcfr.NewFileBuffer(t.TempDir(), 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer buf.Close()
+
+	for i := 0; i < 100; i++ {
+		buf.AddSample(&deepcfr.RegretSample{Weight: 1.0, InfoSet: []byte("x"), Advantages: []float32{1, 0, -1}})
+	}
+
+	trained := newTestModel(rng).Train(buf)
+	infoSet := testInfoSet("x")
+	predicted := trained.Predict(&infoSet, 3)
+	if math.Abs(float64(predicted[0]-1.0)) > 0.1 || math.Abs(float64(predicted[2]+1.0)) > 0.1 {
+		t.Errorf("expected [1, 0, -1], got %v", predicted)
+	}
+}
+
+func TestMarshalSingleDeepCFR(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
+	buf := deepcfr.NewReservoirBuffer(rng, 10, 1)
+	buf.AddSample(&deepcfr.RegretSample{Weight: 1.0, InfoSet: []byte("x"), Advantages: []float32{1, 0, -1}})
+	model := newTestModel(rng)
+	profile := deepcfr.NewSingleDeepCFR(model, []deepcfr.Buffer{buf, buf})
+	profile.Update()
+
+	var b bytes.Buffer
+	if err := gob.NewEncoder(&b).Encode(profile); err != nil {
+		t.Fatal(err)
+	}
+
+	var reloaded deepcfr.SingleDeepCFR
+	if err := gob.NewDecoder(&b).Decode(&reloaded); err != nil {
+		t.Fatal(err)
+	}
+
+	// The reloaded model can still be trained.
+	reloaded.Update()
+	if reloaded.Iter() != 3 {
+		t.Errorf("expected iter 3, got %d", reloaded.Iter())
+	}
+}
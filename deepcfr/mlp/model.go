@@ -0,0 +1,306 @@
+// Package mlp implements deepcfr.Model with a multilayer perceptron
+// written in pure Go, so that the deepcfr package can be used without
+// an external machine learning framework.
+//
+// Networks are trained from scratch on each call to Train by mini-batch
+// gradient descent with Adam, minimizing the weighted mean squared error
+// of the samples in the buffer. RegretSamples are fit on all of their
+// advantages, and ExperienceTuples (used for baselines) are fit only on
+// the output of the sampled action. Mini-batches are drawn from shuffled
+// epochs of the buffer, which is streamed if it is a deepcfr.StreamingBuffer.
+package mlp
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/golang/glog"
+	"github.com/timpalpant/go-cfr/deepcfr"
+	"github.com/timpalpant/go-cfr/internal/f32"
+	"github.com/timpalpant/go-cfr/internal/randutil"
+)
+
+// Params are the hyperparameters of a Model.
+type Params struct {
+	// Number of units in each hidden layer.
+	HiddenLayers []int
+	// Number of outputs, which is the maximum number of actions at any InfoSet.
+	MaxActions int
+	// Number of samples in each mini-batch. Defaults to 256.
+	BatchSize int
+	// Number of mini-batches (gradient steps) per call to Train. Defaults to 1000.
+	NumBatches int
+	// Adam learning rate. Defaults to 0.001.
+	LearningRate float32
+}
+
+func (p Params) batchSize() int {
+	if p.BatchSize <= 0 {
+		return 256
+	}
+
+	return p.BatchSize
+}
+
+func (p Params) numBatches() int {
+	if p.NumBatches <= 0 {
+		return 1000
+	}
+
+	return p.NumBatches
+}
+
+func (p Params) learningRate() float32 {
+	if p.LearningRate <= 0 {
+		return 0.001
+	}
+
+	return p.LearningRate
+}
+
+// Model implements deepcfr.Model by training a new Network on each call to Train.
+type Model struct {
+	featurizer Featurizer
+	params     Params
+	src        *randutil.Source
+	rng        *rand.Rand
+}
+
+// NewModel returns a new Model with the given InfoSet Featurizer and
+// hyperparameters. The random number generators used to initialize and
+// train each Network are seeded from rng.
+func NewModel(rng *rand.Rand, featurizer Featurizer, params Params) *Model {
+	if params.MaxActions <= 0 {
+		panic(fmt.Errorf("mlp: MaxActions must be positive, got %d", params.MaxActions))
+	}
+
+	src := randutil.NewSource(rng.Int63())
+	return &Model{
+		featurizer: featurizer,
+		params:     params,
+		src:        src,
+		rng:        rand.New(src),
+	}
+}
+
+// Train implements deepcfr.Model.
+func (m *Model) Train(buffer deepcfr.Buffer) deepcfr.TrainedModel {
+	rng := rand.New(rand.NewSource(m.rng.Int63()))
+	net := newNetwork(rng, m.featurizer, m.params.HiddenLayers, m.params.MaxActions)
+	// Samples are streamed from the buffer in shuffled epochs,
+	// so that the buffer need not fit in memory.
+	it := deepcfr.ShuffleSamples(rng, buffer)
+	defer func() { it.Close() }()
+
+	opt := newAdam(net, m.params.learningRate())
+	grads := net.zeroLayers()
+	nOutputs := net.NumOutputs()
+	samples := make([]deepcfr.Sample, m.params.batchSize())
+	importance := make([]float32, m.params.batchSize())
+	for batch := 0; batch < m.params.numBatches(); batch++ {
+		n := deepcfr.NextBatch(it, samples, importance)
+		if n < len(samples) {
+			it = nextEpoch(rng, buffer, it)
+			n += deepcfr.NextBatch(it, samples[n:], importance[n:])
+			if n == 0 { // The buffer is empty.
+				return net
+			}
+		}
+
+		zero(grads)
+		var totalWeight, loss float64
+		for i, sample := range samples[:n] {
+			infoSet, weight, target, mask := getTarget(sample, nOutputs)
+			weight *= importance[i] // Correct for biased buffers.
+			if weight == 0 {
+				continue
+			}
+
+			activations := net.forward(net.featurize(infoSet))
+			output := activations[len(activations)-1]
+			dOut := make([]float32, nOutputs)
+			for k, y := range output {
+				if mask[k] {
+					err := y - target[k]
+					loss += float64(weight * err * err)
+					dOut[k] = 2 * weight * err
+				}
+			}
+
+			net.backward(activations, dOut, grads)
+			totalWeight += float64(weight)
+		}
+
+		if totalWeight == 0 {
+			continue
+		}
+
+		scale(grads, float32(1.0/totalWeight))
+		opt.step(net, grads)
+		glog.V(3).Infof("[batch=%d] loss: %v", batch, loss/totalWeight)
+	}
+
+	return net
+}
+
+// nextEpoch closes the iterator over the previous epoch, panicking if
+// it failed, and returns an iterator over a new shuffled epoch.
+func nextEpoch(rng *rand.Rand, buffer deepcfr.Buffer, it deepcfr.SampleIterator) deepcfr.SampleIterator {
+	if err := it.Err(); err != nil {
+		panic(err)
+	}
+
+	if err := it.Close(); err != nil {
+		panic(err)
+	}
+
+	return deepcfr.ShuffleSamples(rng, buffer)
+}
+
+// getTarget returns the InfoSet, weight, and target outputs of a sample,
+// along with a mask of which outputs contribute to the loss.
+func getTarget(sample deepcfr.Sample, nOutputs int) ([]byte, float32, []float32, []bool) {
+	target := make([]float32, nOutputs)
+	mask := make([]bool, nOutputs)
+	switch s := sample.(type) {
+	case *deepcfr.RegretSample:
+		if len(s.Advantages) > nOutputs {
+			panic(fmt.Errorf("mlp: sample has %d advantages but MaxActions is %d",
+				len(s.Advantages), nOutputs))
+		}
+
+		copy(target, s.Advantages)
+		for i := range s.Advantages {
+			mask[i] = true
+		}
+
+		return s.InfoSet, s.Weight, target, mask
+	case *deepcfr.ExperienceTuple:
+		if int(s.Action) >= nOutputs {
+			panic(fmt.Errorf("mlp: sample has action %d but MaxActions is %d",
+				s.Action, nOutputs))
+		}
+
+		target[s.Action] = s.Value
+		mask[s.Action] = true
+		return s.InfoSet, s.Weight, target, mask
+	default:
+		panic(fmt.Errorf("mlp: unsupported sample type %T", sample))
+	}
+}
+
+func zero(layers []*Layer) {
+	for _, l := range layers {
+		for i := range l.W {
+			l.W[i] = 0
+		}
+
+		for i := range l.B {
+			l.B[i] = 0
+		}
+	}
+}
+
+func scale(layers []*Layer, alpha float32) {
+	for _, l := range layers {
+		f32.ScalUnitary(alpha, l.W)
+		f32.ScalUnitary(alpha, l.B)
+	}
+}
+
+// adam implements the Adam optimizer.
+// See: Kingma and Ba, "Adam: A Method for Stochastic Optimization" (https://arxiv.org/abs/1412.6980).
+type adam struct {
+	learningRate float32
+	beta1, beta2 float64
+	eps          float32
+	t            int
+	m, v         []*Layer
+}
+
+func newAdam(net *Network, learningRate float32) *adam {
+	return &adam{
+		learningRate: learningRate,
+		beta1:        0.9,
+		beta2:        0.999,
+		eps:          1e-8,
+		m:            net.zeroLayers(),
+		v:            net.zeroLayers(),
+	}
+}
+
+func (a *adam) step(net *Network, grads []*Layer) {
+	a.t++
+	// Fold bias correction of the moments into the step size.
+	c1 := 1 - math.Pow(a.beta1, float64(a.t))
+	c2 := 1 - math.Pow(a.beta2, float64(a.t))
+	lr := a.learningRate * float32(math.Sqrt(c2)/c1)
+	for i, layer := range net.Layers {
+		a.update(layer.W, grads[i].W, a.m[i].W, a.v[i].W, lr)
+		a.update(layer.B, grads[i].B, a.m[i].B, a.v[i].B, lr)
+	}
+}
+
+func (a *adam) update(params, grads, m, v []float32, lr float32) {
+	b1, b2 := float32(a.beta1), float32(a.beta2)
+	for i, g := range grads {
+		m[i] = b1*m[i] + (1-b1)*g
+		v[i] = b2*v[i] + (1-b2)*g*g
+		params[i] -= lr * m[i] / (float32(math.Sqrt(float64(v[i]))) + a.eps)
+	}
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+// Note that the concrete type of the Featurizer must be registered with gob.
+// The state of the random number generator is saved, so that a
+// restored Model trains the same Networks as this one from now on.
+func (m *Model) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+
+	// Need to pass pointer to interface so that Gob sees the interface rather
+	// than the concrete type. See the example in encoding/gob.
+	if err := enc.Encode(&m.featurizer); err != nil {
+		return nil, err
+	}
+
+	if err := enc.Encode(m.params); err != nil {
+		return nil, err
+	}
+
+	if err := enc.Encode(m.src.State()); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (m *Model) UnmarshalBinary(buf []byte) error {
+	r := bytes.NewReader(buf)
+	dec := gob.NewDecoder(r)
+
+	if err := dec.Decode(&m.featurizer); err != nil {
+		return err
+	}
+
+	if err := dec.Decode(&m.params); err != nil {
+		return err
+	}
+
+	src, err := randutil.Decode(dec)
+	if err != nil {
+		return err
+	}
+
+	m.src = src
+	m.rng = rand.New(src)
+	return nil
+}
+
+func init() {
+	gob.Register(&Model{})
+}
@@ -0,0 +1,182 @@
+package mlp
+
+import (
+	"encoding/gob"
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/timpalpant/go-cfr"
+	"github.com/timpalpant/go-cfr/internal/f32"
+)
+
+// Layer is a dense layer with weights W (stored row-major, one row of
+// length In for each of the Out outputs) and biases B.
+type Layer struct {
+	In, Out int
+	W       []float32
+	B       []float32
+}
+
+func newLayer(rng *rand.Rand, in, out int) *Layer {
+	// He initialization, for ReLU activations.
+	stddev := math.Sqrt(2.0 / float64(in))
+	w := make([]float32, in*out)
+	for i := range w {
+		w[i] = float32(rng.NormFloat64() * stddev)
+	}
+
+	return &Layer{
+		In:  in,
+		Out: out,
+		W:   w,
+		B:   make([]float32, out),
+	}
+}
+
+func (l *Layer) row(j int) []float32 {
+	return l.W[j*l.In : (j+1)*l.In]
+}
+
+// forward sets out = W x + b.
+func (l *Layer) forward(x, out []float32) {
+	for j := range out {
+		out[j] = f32.DotUnitary(l.row(j), x) + l.B[j]
+	}
+}
+
+// backward accumulates the gradients of the weights and biases into grad, given
+// the layer's input x and the gradient dOut of its output. If dx is not nil, the
+// gradient of the layer's input is accumulated into it.
+func (l *Layer) backward(x, dOut, dx []float32, grad *Layer) {
+	f32.Add(grad.B, dOut)
+	for j, g := range dOut {
+		if g == 0 {
+			continue
+		}
+
+		f32.AxpyUnitary(g, x, grad.row(j))
+		if dx != nil {
+			f32.AxpyUnitary(g, l.row(j), dx)
+		}
+	}
+}
+
+// Network is a multilayer perceptron with ReLU activations on the hidden layers
+// and linear outputs. It implements deepcfr.TrainedModel.
+type Network struct {
+	Featurizer Featurizer
+	Layers     []*Layer
+}
+
+func newNetwork(rng *rand.Rand, featurizer Featurizer, hiddenLayers []int, nOutputs int) *Network {
+	var layers []*Layer
+	in := featurizer.NumFeatures()
+	sizes := append(append([]int(nil), hiddenLayers...), nOutputs)
+	for _, out := range sizes {
+		layers = append(layers, newLayer(rng, in, out))
+		in = out
+	}
+
+	return &Network{
+		Featurizer: featurizer,
+		Layers:     layers,
+	}
+}
+
+// NumOutputs returns the number of outputs of the network,
+// which is the maximum number of actions it can predict.
+func (n *Network) NumOutputs() int {
+	return n.Layers[len(n.Layers)-1].Out
+}
+
+// Predict implements deepcfr.TrainedModel.
+func (n *Network) Predict(infoSet cfr.InfoSet, nActions int) []float32 {
+	if nActions > n.NumOutputs() {
+		panic(fmt.Errorf("cannot predict %d actions with a network that has %d outputs",
+			nActions, n.NumOutputs()))
+	}
+
+	buf, err := infoSet.MarshalBinary()
+	if err != nil {
+		panic(err)
+	}
+
+	activations := n.forward(n.featurize(buf))
+	output := activations[len(activations)-1]
+	return output[:nActions]
+}
+
+func (n *Network) featurize(infoSet []byte) []float32 {
+	features := make([]float32, n.Featurizer.NumFeatures())
+	n.Featurizer.Featurize(infoSet, features)
+	return features
+}
+
+// forward returns the input followed by the output of each layer.
+func (n *Network) forward(x []float32) [][]float32 {
+	activations := make([][]float32, len(n.Layers)+1)
+	activations[0] = x
+	for i, layer := range n.Layers {
+		out := make([]float32, layer.Out)
+		layer.forward(activations[i], out)
+		if i < len(n.Layers)-1 {
+			relu(out)
+		}
+
+		activations[i+1] = out
+	}
+
+	return activations
+}
+
+// backward accumulates the gradients of all parameters into grads, given the
+// activations of a forward pass and the gradient of the loss with respect to
+// the output.
+func (n *Network) backward(activations [][]float32, dOut []float32, grads []*Layer) {
+	for i := len(n.Layers) - 1; i >= 0; i-- {
+		var dx []float32
+		if i > 0 {
+			dx = make([]float32, n.Layers[i].In)
+		}
+
+		n.Layers[i].backward(activations[i], dOut, dx, grads[i])
+		if dx != nil {
+			// Gradient of the ReLU on the previous layer's output.
+			for k, a := range activations[i] {
+				if a <= 0 {
+					dx[k] = 0
+				}
+			}
+		}
+
+		dOut = dx
+	}
+}
+
+// zeroLayers returns layers of the same shape as the network, with all parameters zero.
+func (n *Network) zeroLayers() []*Layer {
+	result := make([]*Layer, len(n.Layers))
+	for i, layer := range n.Layers {
+		result[i] = &Layer{
+			In:  layer.In,
+			Out: layer.Out,
+			W:   make([]float32, len(layer.W)),
+			B:   make([]float32, len(layer.B)),
+		}
+	}
+
+	return result
+}
+
+func relu(x []float32) {
+	for i, v := range x {
+		if v < 0 {
+			x[i] = 0
+		}
+	}
+}
+
+func init() {
+	gob.Register(&Network{})
+}
@@ -0,0 +1,160 @@
+package deepcfr
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/timpalpant/go-cfr"
+)
+
+type testInfoSet string
+
+func (s testInfoSet) Key() []byte                       { return []byte(s) }
+func (s testInfoSet) MarshalBinary() ([]byte, error)    { return []byte(s), nil }
+func (s *testInfoSet) UnmarshalBinary(buf []byte) error { *s = testInfoSet(buf); return nil }
+
+func newInfoSet(key string) *testInfoSet {
+	is := testInfoSet(key)
+	return &is
+}
+
+// countingModel predicts the length of the InfoSet key for every action,
+// and records the number of InfoSets in each call.
+type countingModel struct {
+	mx      sync.Mutex
+	batches []int
+}
+
+func (m *countingModel) Predict(infoSet cfr.InfoSet, nActions int) []float32 {
+	return m.BatchPredict([]cfr.InfoSet{infoSet}, []int{nActions})[0]
+}
+
+func (m *countingModel) BatchPredict(infoSets []cfr.InfoSet, nActions []int) [][]float32 {
+	m.mx.Lock()
+	m.batches = append(m.batches, len(infoSets))
+	m.mx.Unlock()
+
+	result := make([][]float32, len(infoSets))
+	for i, infoSet := range infoSets {
+		result[i] = make([]float32, nActions[i])
+		for j := range result[i] {
+			result[i][j] = float32(len(infoSet.Key()))
+		}
+	}
+
+	return result
+}
+
+func TestBatchedModel(t *testing.T) {
+	model := &countingModel{}
+	batched := NewBatchedModel(model, BatchParams{
+		MaxBatchSize: 10,
+		MaxDelay:     time.Second,
+	})
+
+	// With a long delay, batches are only sent once they are full.
+	var wg sync.WaitGroup
+	for i := 0; i < 30; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := string(make([]byte, i))
+			prediction := batched.Predict(newInfoSet(key), 2)
+			if len(prediction) != 2 || prediction[0] != float32(i) {
+				t.Errorf("expected [%d %d], got %v", i, i, prediction)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	if len(model.batches) != 3 {
+		t.Errorf("expected 3 batches of 10, got %v", model.batches)
+	}
+
+	// A partial batch is sent after the delay.
+	batched.Params.MaxDelay = time.Millisecond
+	if prediction := batched.Predict(newInfoSet("abc"), 1); prediction[0] != 3 {
+		t.Errorf("expected [3], got %v", prediction)
+	}
+}
+
+type panickingModel struct{}
+
+func (m panickingModel) Predict(infoSet cfr.InfoSet, nActions int) []float32 {
+	panic("prediction failed")
+}
+
+func TestBatchedModel_Panic(t *testing.T) {
+	batched := NewBatchedModel(panickingModel{}, BatchParams{
+		MaxBatchSize: 5,
+		MaxDelay:     time.Second,
+	})
+
+	// Every call in the batch panics, rather than waiting forever.
+	panics := make(chan interface{})
+	for i := 0; i < 5; i++ {
+		go func() {
+			defer func() { panics <- recover() }()
+			batched.Predict(newInfoSet("x"), 2)
+		}()
+	}
+
+	for i := 0; i < 5; i++ {
+		select {
+		case r := <-panics:
+			if r != "prediction failed" {
+				t.Errorf("expected panic with prediction failed, got %v", r)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatal("timed out waiting for Predict to return")
+		}
+	}
+}
+
+func TestPredictionCache(t *testing.T) {
+	model := &countingModel{}
+	cache := NewPredictionCache(2)
+	a, b := cache.Wrap(model), cache.Wrap(model)
+
+	a.Predict(newInfoSet("x"), 2)
+	a.Predict(newInfoSet("x"), 2)
+	if hits, misses := cache.Stats(); hits != 1 || misses != 1 {
+		t.Errorf("expected 1 hit and 1 miss, got %d and %d", hits, misses)
+	}
+
+	// Predictions are cached per model.
+	b.Predict(newInfoSet("x"), 2)
+	if len(model.batches) != 2 {
+		t.Errorf("expected 2 predictions, got %d", len(model.batches))
+	}
+
+	// Callers may modify the returned predictions.
+	prediction := a.Predict(newInfoSet("x"), 2)
+	prediction[0] = -1
+	if prediction := a.Predict(newInfoSet("x"), 2); prediction[0] != 1 {
+		t.Errorf("cached prediction was modified: %v", prediction)
+	}
+
+	// The least recently used entry (b, "x") is evicted.
+	a.Predict(newInfoSet("yy"), 2)
+	if cache.Len() != 2 {
+		t.Errorf("expected 2 cached predictions, got %d", cache.Len())
+	}
+
+	b.Predict(newInfoSet("x"), 2)
+	if len(model.batches) != 4 {
+		t.Errorf("expected 4 predictions, got %d", len(model.batches))
+	}
+
+	// Only cache misses are batch predicted by the underlying model.
+	result := a.BatchPredict(
+		[]cfr.InfoSet{newInfoSet("yy"), newInfoSet("zzz")}, []int{2, 1})
+	if result[0][0] != 2 || result[1][0] != 3 {
+		t.Errorf("expected [[2 2] [3]], got %v", result)
+	}
+
+	if last := model.batches[len(model.batches)-1]; last != 1 {
+		t.Errorf("expected a batch of 1 cache miss, got %d", last)
+	}
+}
@@ -0,0 +1,201 @@
+package deepcfr
+
+import (
+	"container/list"
+	"encoding/gob"
+	"sync"
+
+	"github.com/timpalpant/go-cfr"
+)
+
+// PredictionCache is a least-recently-used cache of model predictions,
+// keyed by model and InfoSet. A single cache may be shared by many models,
+// so that its size bounds the total memory used by all of them.
+//
+// It is safe to use concurrently from multiple goroutines.
+type PredictionCache struct {
+	mx      sync.Mutex
+	maxSize int
+	entries map[predictionKey]*list.Element
+	lru     *list.List
+	nextID  uint64
+
+	hits, misses int64
+}
+
+type predictionKey struct {
+	model    uint64
+	infoSet  string
+	nActions int
+}
+
+type predictionEntry struct {
+	key        predictionKey
+	prediction []float32
+}
+
+// NewPredictionCache returns a new PredictionCache that holds
+// at most maxSize predictions.
+func NewPredictionCache(maxSize int) *PredictionCache {
+	return &PredictionCache{
+		maxSize: maxSize,
+		entries: make(map[predictionKey]*list.Element, maxSize),
+		lru:     list.New(),
+	}
+}
+
+// Wrap returns a TrainedModel that caches the predictions of model in this cache.
+func (c *PredictionCache) Wrap(model TrainedModel) *CachedModel {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	id := c.nextID
+	c.nextID++
+	return &CachedModel{
+		Model: model,
+		cache: c,
+		id:    id,
+	}
+}
+
+// Len returns the number of predictions in the cache.
+func (c *PredictionCache) Len() int {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return c.lru.Len()
+}
+
+// Stats returns the number of cache hits and misses.
+func (c *PredictionCache) Stats() (hits, misses int64) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return c.hits, c.misses
+}
+
+func (c *PredictionCache) get(key predictionKey) ([]float32, bool) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+
+	c.hits++
+	c.lru.MoveToFront(elem)
+	return copyOf(elem.Value.(*predictionEntry).prediction), true
+}
+
+func (c *PredictionCache) put(key predictionKey, prediction []float32) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(elem)
+		return
+	}
+
+	entry := &predictionEntry{key, copyOf(prediction)}
+	c.entries[key] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.maxSize {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*predictionEntry).key)
+	}
+}
+
+func copyOf(x []float32) []float32 {
+	result := make([]float32, len(x))
+	copy(result, x)
+	return result
+}
+
+// CachingModel wraps a Model so that its TrainedModels share a PredictionCache.
+type CachingModel struct {
+	Model Model
+	cache *PredictionCache
+}
+
+// NewCachingModel returns a Model that trains the given model,
+// and caches the predictions of the trained models in cache.
+func NewCachingModel(model Model, cache *PredictionCache) *CachingModel {
+	return &CachingModel{model, cache}
+}
+
+// Train implements Model.
+func (m *CachingModel) Train(buf Buffer) TrainedModel {
+	trained := m.Model.Train(buf)
+	if m.cache == nil {
+		return trained
+	}
+
+	return m.cache.Wrap(trained)
+}
+
+// CachedModel is a TrainedModel whose predictions are cached in a PredictionCache.
+// Predictions are copied into and out of the cache, so callers may modify them.
+//
+// The cache is not serialized: a CachedModel or CachingModel that has been
+// gob-decoded predicts directly with its underlying model.
+type CachedModel struct {
+	Model TrainedModel
+	cache *PredictionCache
+	id    uint64
+}
+
+func (m *CachedModel) key(infoSet cfr.InfoSet, nActions int) predictionKey {
+	return predictionKey{m.id, string(infoSet.Key()), nActions}
+}
+
+// Predict implements TrainedModel.
+func (m *CachedModel) Predict(infoSet cfr.InfoSet, nActions int) []float32 {
+	if m.cache == nil {
+		return m.Model.Predict(infoSet, nActions)
+	}
+
+	key := m.key(infoSet, nActions)
+	if prediction, ok := m.cache.get(key); ok {
+		return prediction
+	}
+
+	prediction := m.Model.Predict(infoSet, nActions)
+	m.cache.put(key, prediction)
+	return prediction
+}
+
+// BatchPredict implements BatchPredictor. Only the InfoSets that are
+// not in the cache are predicted by the underlying model.
+func (m *CachedModel) BatchPredict(infoSets []cfr.InfoSet, nActions []int) [][]float32 {
+	if m.cache == nil {
+		return BatchPredict(m.Model, infoSets, nActions)
+	}
+
+	result := make([][]float32, len(infoSets))
+	var missing []int
+	var missingInfoSets []cfr.InfoSet
+	var missingNActions []int
+	for i, infoSet := range infoSets {
+		if prediction, ok := m.cache.get(m.key(infoSet, nActions[i])); ok {
+			result[i] = prediction
+		} else {
+			missing = append(missing, i)
+			missingInfoSets = append(missingInfoSets, infoSet)
+			missingNActions = append(missingNActions, nActions[i])
+		}
+	}
+
+	if len(missing) == 0 {
+		return result
+	}
+
+	predictions := BatchPredict(m.Model, missingInfoSets, missingNActions)
+	for j, i := range missing {
+		m.cache.put(m.key(infoSets[i], nActions[i]), predictions[j])
+		result[i] = predictions[j]
+	}
+
+	return result
+}
+
+func init() {
+	gob.Register(&CachingModel{})
+	gob.Register(&CachedModel{})
+}
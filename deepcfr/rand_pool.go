@@ -3,21 +3,46 @@ package deepcfr
 import (
 	"math/rand"
 	"sync"
+
+	"github.com/timpalpant/go-cfr/internal/randutil"
 )
 
 type randPool []*lockedRand
 
-func newRandPool(n int) randPool {
+// newRandPool creates a pool of n random number generators,
+// each seeded from the given rng.
+func newRandPool(rng *rand.Rand, n int) randPool {
 	rngs := make([]*lockedRand, n)
 	for i := range rngs {
-		rngs[i] = &lockedRand{
-			rng: rand.New(rand.NewSource(rand.Int63())),
-		}
+		rngs[i] = newLockedRand(randutil.NewSource(rng.Int63()))
+	}
+
+	return randPool(rngs)
+}
+
+// restoreRandPool recreates a pool from the states returned by state.
+func restoreRandPool(states []randutil.State) randPool {
+	rngs := make([]*lockedRand, len(states))
+	for i, state := range states {
+		rngs[i] = newLockedRand(randutil.Restore(state))
 	}
 
 	return randPool(rngs)
 }
 
+// state returns the states from which restoreRandPool recreates
+// a pool that generates the same numbers as this one from now on.
+func (r randPool) state() []randutil.State {
+	states := make([]randutil.State, len(r))
+	for i, lr := range r {
+		lr.mx.Lock()
+		states[i] = lr.src.State()
+		lr.mx.Unlock()
+	}
+
+	return states
+}
+
 func (r randPool) Intn(n int) int {
 	k := n % len(r)
 	return r[k].Intn(n)
@@ -25,9 +50,14 @@ func (r randPool) Intn(n int) int {
 
 type lockedRand struct {
 	mx  sync.Mutex
+	src *randutil.Source
 	rng *rand.Rand
 }
 
+func newLockedRand(src *randutil.Source) *lockedRand {
+	return &lockedRand{src: src, rng: rand.New(src)}
+}
+
 func (lr *lockedRand) Intn(n int) int {
 	lr.mx.Lock()
 	result := lr.rng.Intn(n)
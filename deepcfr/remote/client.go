@@ -0,0 +1,527 @@
+package remote
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/timpalpant/go-cfr"
+	"github.com/timpalpant/go-cfr/deepcfr"
+)
+
+// BatchParams configure how concurrent Predict calls are batched.
+type BatchParams struct {
+	// Maximum number of predictions per request. Defaults to 256.
+	MaxBatchSize int
+	// Maximum time to wait for more predictions before sending a batch
+	// that is not full. If zero, a batch is sent as soon as no more
+	// predictions are immediately pending.
+	BatchTimeout time.Duration
+}
+
+func (p BatchParams) maxBatchSize() int {
+	if p.MaxBatchSize <= 0 {
+		return 256
+	}
+
+	return p.MaxBatchSize
+}
+
+type predictRequest struct {
+	modelID  uint64
+	infoSet  []byte
+	nActions int
+	result   chan predictResult
+}
+
+type predictResult struct {
+	prediction []float32
+	err        error
+}
+
+// Client is a connection to a model server. It is safe for concurrent use.
+//
+// If reading or writing the connection fails, the request and response
+// frames may no longer be in step, so the Client is broken and all further
+// requests fail.
+type Client struct {
+	network, address string
+	params           BatchParams
+
+	mx     sync.Mutex // Guards conn and broken.
+	conn   io.ReadWriteCloser
+	broken error
+
+	// Held for the whole of a training session.
+	trainMx sync.Mutex
+
+	requests  chan *predictRequest
+	once      sync.Once
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+var errClosed = errors.New("client is closed")
+
+// NewClient returns a Client that communicates with a model server over
+// the given connection, such as the stdin/stdout of a subprocess.
+//
+// Models that use a Client created by NewClient cannot be reconnected
+// after they are gob-decoded. Use Dial for that.
+func NewClient(conn io.ReadWriteCloser, params BatchParams) *Client {
+	return &Client{
+		params:   params,
+		conn:     conn,
+		requests: make(chan *predictRequest, params.maxBatchSize()),
+		done:     make(chan struct{}),
+	}
+}
+
+// Dial returns a Client that is connected to the model server
+// at the given address, such as ("unix", "/tmp/model.sock").
+func Dial(network, address string, params BatchParams) (*Client, error) {
+	conn, err := net.Dial(network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	c := NewClient(conn, params)
+	c.network = network
+	c.address = address
+	return c, nil
+}
+
+// Close closes the connection to the server, and stops batching predictions.
+// Pending and future requests fail.
+func (c *Client) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		close(c.done)
+		clients.remove(c)
+		err = c.conn.Close()
+	})
+
+	return err
+}
+
+// roundTrip sends one request frame and returns the body of the response.
+func (c *Client) roundTrip(request []byte) (*decoder, error) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if c.broken != nil {
+		return nil, fmt.Errorf("connection to model server is broken: %w", c.broken)
+	}
+
+	if err := writeFrame(c.conn, request); err != nil {
+		if !errors.Is(err, errFrameTooLarge) {
+			c.broken = err
+		}
+
+		return nil, err
+	}
+
+	response, err := readFrame(c.conn)
+	if err != nil {
+		c.broken = err
+		return nil, err
+	}
+
+	d := &decoder{buf: response}
+	if status := d.u8(); status != statusOK {
+		return nil, fmt.Errorf("model server error: %s", d.buf)
+	}
+
+	return d, d.err
+}
+
+// Train sends the samples in buf to the server to train a new model,
+// and returns the ID of the trained model. The samples are streamed
+// in chunks, so buf need not fit in memory.
+func (c *Client) Train(buf deepcfr.Buffer) (uint64, error) {
+	c.trainMx.Lock()
+	defer c.trainMx.Unlock()
+	if _, err := c.roundTrip([]byte{msgTrainBegin}); err != nil {
+		return 0, err
+	}
+
+	it := deepcfr.IterateSamples(buf)
+	defer it.Close()
+	wit, weighted := it.(deepcfr.WeightedSampleIterator)
+	e := newTrainSamples()
+	nSamples := 0
+	for it.Next() {
+		sample := it.Sample()
+		if weighted {
+			sample = reweight(sample, wit.Weight())
+		}
+
+		if err := encodeSample(e, sample); err != nil {
+			return 0, err
+		}
+
+		nSamples++
+		if len(e.buf) >= trainChunkSize {
+			if err := c.sendTrainSamples(e, nSamples); err != nil {
+				return 0, err
+			}
+
+			e = newTrainSamples()
+			nSamples = 0
+		}
+	}
+
+	if err := it.Err(); err != nil {
+		return 0, err
+	}
+
+	if nSamples > 0 {
+		if err := c.sendTrainSamples(e, nSamples); err != nil {
+			return 0, err
+		}
+	}
+
+	d, err := c.roundTrip([]byte{msgTrainEnd})
+	if err != nil {
+		return 0, err
+	}
+
+	modelID := d.u64()
+	return modelID, d.err
+}
+
+// newTrainSamples returns an encoder for a TrainSamples request,
+// whose number of samples is filled in by sendTrainSamples.
+func newTrainSamples() *encoder {
+	e := &encoder{}
+	e.u8(msgTrainSamples)
+	e.u32(0)
+	return e
+}
+
+func (c *Client) sendTrainSamples(e *encoder, nSamples int) error {
+	binary.LittleEndian.PutUint32(e.buf[1:], uint32(nSamples))
+	_, err := c.roundTrip(e.buf)
+	return err
+}
+
+// reweight returns a copy of the sample with its weight multiplied by w.
+func reweight(sample deepcfr.Sample, w float32) deepcfr.Sample {
+	switch s := sample.(type) {
+	case *deepcfr.RegretSample:
+		result := *s
+		result.Weight *= w
+		return &result
+	case *deepcfr.ExperienceTuple:
+		result := *s
+		result.Weight *= w
+		return &result
+	default:
+		return sample
+	}
+}
+
+// Predict returns the prediction of the given model for the InfoSet.
+// Concurrent calls are batched into a single request to the server.
+// The protocol limits nActions to 65535.
+func (c *Client) Predict(modelID uint64, infoSet cfr.InfoSet, nActions int) ([]float32, error) {
+	if err := checkActions(nActions); err != nil {
+		return nil, err
+	}
+
+	buf, err := infoSet.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	c.once.Do(func() { go c.batchPredictions() })
+	req := &predictRequest{
+		modelID:  modelID,
+		infoSet:  buf,
+		nActions: nActions,
+		result:   make(chan predictResult, 1),
+	}
+
+	select {
+	case c.requests <- req:
+	case <-c.done:
+		return nil, errClosed
+	}
+
+	select {
+	case result := <-req.result:
+		return result.prediction, result.err
+	case <-c.done:
+		return nil, errClosed
+	}
+}
+
+// batchPredictions collects pending predict requests into batches
+// and sends them to the server.
+func (c *Client) batchPredictions() {
+	maxBatchSize := c.params.maxBatchSize()
+	for {
+		var req *predictRequest
+		select {
+		case req = <-c.requests:
+		case <-c.done:
+			return
+		}
+
+		batch := []*predictRequest{req}
+		var timeout <-chan time.Time
+		if c.params.BatchTimeout > 0 {
+			timeout = time.After(c.params.BatchTimeout)
+		}
+
+	collect:
+		for len(batch) < maxBatchSize {
+			select {
+			case req := <-c.requests:
+				batch = append(batch, req)
+			case <-timeout:
+				break collect
+			default:
+				if timeout == nil {
+					break collect
+				}
+
+				select {
+				case req := <-c.requests:
+					batch = append(batch, req)
+				case <-timeout:
+					break collect
+				}
+			}
+		}
+
+		results, err := c.predictBatch(batch)
+		for i, req := range batch {
+			if err != nil {
+				req.result <- predictResult{err: err}
+			} else {
+				req.result <- predictResult{prediction: results[i]}
+			}
+		}
+	}
+}
+
+// checkActions returns an error if nActions cannot be sent to the server.
+func checkActions(nActions int) error {
+	if nActions > maxActions {
+		return fmt.Errorf("cannot predict %d actions, the maximum is %d", nActions, maxActions)
+	}
+
+	return nil
+}
+
+func (c *Client) predictBatch(batch []*predictRequest) ([][]float32, error) {
+	for _, req := range batch {
+		if err := checkActions(req.nActions); err != nil {
+			return nil, err
+		}
+	}
+
+	e := &encoder{}
+	e.u8(msgPredict)
+	e.u32(uint32(len(batch)))
+	for _, req := range batch {
+		e.u64(req.modelID)
+		e.u16(uint16(req.nActions))
+		e.bytes(req.infoSet)
+	}
+
+	d, err := c.roundTrip(e.buf)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([][]float32, len(batch))
+	for i := range results {
+		n := int(d.u16())
+		results[i] = make([]float32, n)
+		for j := range results[i] {
+			results[i][j] = d.f32()
+		}
+	}
+
+	return results, d.err
+}
+
+// clients holds the Clients used by gob-decoded Models and TrainedModels,
+// so that all models decoded for the same server share a connection.
+var clients = &clientRegistry{m: make(map[string]*Client)}
+
+type clientRegistry struct {
+	sync.Mutex
+	m map[string]*Client
+}
+
+// remove removes the Client from the registry if it is there, so that
+// models decoded after it is closed dial a new connection.
+func (r *clientRegistry) remove(c *Client) {
+	r.Lock()
+	defer r.Unlock()
+	key := c.network + ":" + c.address
+	if r.m[key] == c {
+		delete(r.m, key)
+	}
+}
+
+func getClient(network, address string, params BatchParams) (*Client, error) {
+	if network == "" {
+		return nil, fmt.Errorf("cannot reconnect to a model server without an address")
+	}
+
+	clients.Lock()
+	defer clients.Unlock()
+	key := network + ":" + address
+	if c, ok := clients.m[key]; ok {
+		return c, nil
+	}
+
+	c, err := Dial(network, address, params)
+	if err != nil {
+		return nil, err
+	}
+
+	clients.m[key] = c
+	return c, nil
+}
+
+// clientInfo is the serialized form of a Client.
+type clientInfo struct {
+	Network, Address string
+	Params           BatchParams
+}
+
+func (c *Client) info() clientInfo {
+	return clientInfo{c.network, c.address, c.params}
+}
+
+// Model implements deepcfr.Model by training models on the server.
+type Model struct {
+	client *Client
+}
+
+// NewModel returns a new Model that trains models with the given Client.
+func NewModel(client *Client) *Model {
+	return &Model{client}
+}
+
+// Train implements deepcfr.Model. It panics if the server returns an error.
+func (m *Model) Train(buf deepcfr.Buffer) deepcfr.TrainedModel {
+	modelID, err := m.client.Train(buf)
+	if err != nil {
+		panic(err)
+	}
+
+	return &TrainedModel{
+		client: m.client,
+		id:     modelID,
+	}
+}
+
+// GobEncode implements gob.GobEncoder.
+func (m *Model) GobEncode() ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(m.client.info())
+	return buf.Bytes(), err
+}
+
+// GobDecode implements gob.GobDecoder. The decoded Model dials the server
+// at the address of the original Client.
+func (m *Model) GobDecode(buf []byte) error {
+	var info clientInfo
+	if err := gob.NewDecoder(bytes.NewReader(buf)).Decode(&info); err != nil {
+		return err
+	}
+
+	client, err := getClient(info.Network, info.Address, info.Params)
+	m.client = client
+	return err
+}
+
+// TrainedModel implements deepcfr.TrainedModel for a model on the server.
+type TrainedModel struct {
+	client *Client
+	id     uint64
+}
+
+// ID returns the server's identifier for this model.
+func (m *TrainedModel) ID() uint64 {
+	return m.id
+}
+
+// Predict implements deepcfr.TrainedModel. It panics if the server returns an error.
+func (m *TrainedModel) Predict(infoSet cfr.InfoSet, nActions int) []float32 {
+	result, err := m.client.Predict(m.id, infoSet, nActions)
+	if err != nil {
+		panic(err)
+	}
+
+	return result
+}
+
+// BatchPredict implements deepcfr.BatchPredictor by sending all of the
+// InfoSets to the server in a single request. It panics if the server
+// returns an error, or if any of nActions is more than 65535.
+func (m *TrainedModel) BatchPredict(infoSets []cfr.InfoSet, nActions []int) [][]float32 {
+	batch := make([]*predictRequest, len(infoSets))
+	for i, infoSet := range infoSets {
+		buf, err := infoSet.MarshalBinary()
+		if err != nil {
+			panic(err)
+		}
+
+		batch[i] = &predictRequest{
+			modelID:  m.id,
+			infoSet:  buf,
+			nActions: nActions[i],
+		}
+	}
+
+	result, err := m.client.predictBatch(batch)
+	if err != nil {
+		panic(err)
+	}
+
+	return result
+}
+
+type trainedModelInfo struct {
+	Client clientInfo
+	ID     uint64
+}
+
+// GobEncode implements gob.GobEncoder.
+func (m *TrainedModel) GobEncode() ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(trainedModelInfo{m.client.info(), m.id})
+	return buf.Bytes(), err
+}
+
+// GobDecode implements gob.GobDecoder. The decoded TrainedModel dials the
+// server at the address of the original Client, which must still have the
+// model with the same ID.
+func (m *TrainedModel) GobDecode(buf []byte) error {
+	var info trainedModelInfo
+	if err := gob.NewDecoder(bytes.NewReader(buf)).Decode(&info); err != nil {
+		return err
+	}
+
+	client, err := getClient(info.Client.Network, info.Client.Address, info.Client.Params)
+	m.client = client
+	m.id = info.ID
+	return err
+}
+
+func init() {
+	gob.Register(&Model{})
+	gob.Register(&TrainedModel{})
+}
@@ -0,0 +1,269 @@
+// Package remote implements deepcfr.Model and deepcfr.TrainedModel by
+// communicating with a model server in another process, so that models
+// can be trained with any machine learning framework.
+//
+// # Protocol
+//
+// The client and server exchange frames over a stream connection, such as
+// a Unix socket or the stdin/stdout of a subprocess. Each frame is a 4-byte
+// little-endian length followed by that many bytes of payload. The first byte
+// of the payload is the message type. All integers are little-endian, and
+// all floats are IEEE 754 float32.
+//
+// The client sends one request frame at a time, and the server replies to
+// each with one response frame. The first byte of a response is a status:
+// 0 for success, or 1 for an error, in which case the remainder of the
+// response is a UTF-8 error message.
+//
+// Training a new model streams the samples of a buffer in chunks, so that
+// neither the client nor the protocol needs to hold the whole buffer in memory:
+//
+//	TrainBegin (type 1) starts a new training session, discarding any
+//	samples from a previous session on the connection that was not ended:
+//	  request:  u8 type=1
+//	  response: u8 status
+//
+//	TrainSamples (type 3) adds a chunk of samples to the session:
+//	  request:  u8 type=3, u32 nSamples, nSamples x (u8 sampleType, u32 len, len bytes)
+//	  response: u8 status
+//
+//	TrainEnd (type 4) trains a new model on all samples of the session:
+//	  request:  u8 type=4
+//	  response: u8 status, u64 modelID
+//
+// where sampleType is 1 for a deepcfr.RegretSample or 2 for a
+// deepcfr.ExperienceTuple, and the bytes are the sample's MarshalBinary.
+// If the buffer's samples have importance weights (see
+// deepcfr.WeightedSampleIterator), they are multiplied into the sample weights.
+// Predict requests may be interleaved with the frames of a training session.
+//
+// Predict (type 2) evaluates a batch of InfoSets, possibly with different models:
+//
+//	request:  u8 type=2, u32 n, n x (u64 modelID, u16 nActions, u32 len, len bytes)
+//	response: u8 status, n x (u16 nActions, nActions x f32)
+//
+// where the bytes are the InfoSet's MarshalBinary.
+package remote
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/timpalpant/go-cfr/deepcfr"
+)
+
+// Message types.
+const (
+	msgTrainBegin   byte = 1
+	msgPredict      byte = 2
+	msgTrainSamples byte = 3
+	msgTrainEnd     byte = 4
+)
+
+// Sample types.
+const (
+	sampleRegret     byte = 1
+	sampleExperience byte = 2
+)
+
+// Response statuses.
+const (
+	statusOK    byte = 0
+	statusError byte = 1
+)
+
+// maxFrameSize bounds the size of frames that will be read, to guard
+// against reading garbage from a misbehaving peer.
+const maxFrameSize = 1 << 30
+
+// maxActions is the largest number of actions that can be encoded
+// in a Predict request or response.
+const maxActions = math.MaxUint16
+
+// trainChunkSize is the approximate size in bytes of each TrainSamples frame.
+const trainChunkSize = 1 << 22
+
+var errFrameTooLarge = errors.New("frame exceeds maximum size")
+
+// writeFrame writes the payload as a single frame. If the payload is
+// larger than maxFrameSize, nothing is written and errFrameTooLarge
+// is returned.
+func writeFrame(w io.Writer, payload []byte) error {
+	if len(payload) > maxFrameSize {
+		return fmt.Errorf("%w: %d bytes", errFrameTooLarge, len(payload))
+	}
+
+	var header [4]byte
+	binary.LittleEndian.PutUint32(header[:], uint32(len(payload)))
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+
+	_, err := w.Write(payload)
+	return err
+}
+
+func readFrame(r io.Reader) ([]byte, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+
+	n := binary.LittleEndian.Uint32(header[:])
+	if n > maxFrameSize {
+		return nil, fmt.Errorf("frame of %d bytes exceeds maximum size", n)
+	}
+
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+// encoder appends little-endian values to a buffer.
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) u8(x byte) {
+	e.buf = append(e.buf, x)
+}
+
+func (e *encoder) u16(x uint16) {
+	e.buf = append(e.buf, byte(x), byte(x>>8))
+}
+
+func (e *encoder) u32(x uint32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], x)
+	e.buf = append(e.buf, b[:]...)
+}
+
+func (e *encoder) u64(x uint64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], x)
+	e.buf = append(e.buf, b[:]...)
+}
+
+func (e *encoder) f32(x float32) {
+	e.u32(math.Float32bits(x))
+}
+
+func (e *encoder) bytes(b []byte) {
+	e.u32(uint32(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+var errShortPayload = errors.New("payload is too short")
+
+// decoder consumes little-endian values from a buffer.
+// After the first error, all further reads return zero values.
+type decoder struct {
+	buf []byte
+	err error
+}
+
+func (d *decoder) next(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+
+	if len(d.buf) < n {
+		d.err = errShortPayload
+		return nil
+	}
+
+	result := d.buf[:n]
+	d.buf = d.buf[n:]
+	return result
+}
+
+func (d *decoder) u8() byte {
+	if b := d.next(1); b != nil {
+		return b[0]
+	}
+
+	return 0
+}
+
+func (d *decoder) u16() uint16 {
+	if b := d.next(2); b != nil {
+		return binary.LittleEndian.Uint16(b)
+	}
+
+	return 0
+}
+
+func (d *decoder) u32() uint32 {
+	if b := d.next(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+
+	return 0
+}
+
+func (d *decoder) u64() uint64 {
+	if b := d.next(8); b != nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+
+	return 0
+}
+
+func (d *decoder) f32() float32 {
+	return math.Float32frombits(d.u32())
+}
+
+func (d *decoder) bytes() []byte {
+	n := d.u32()
+	return d.next(int(n))
+}
+
+func encodeSample(e *encoder, sample deepcfr.Sample) error {
+	var sampleType byte
+	switch sample.(type) {
+	case *deepcfr.RegretSample:
+		sampleType = sampleRegret
+	case *deepcfr.ExperienceTuple:
+		sampleType = sampleExperience
+	default:
+		return fmt.Errorf("unsupported sample type %T", sample)
+	}
+
+	buf, err := sample.(interface {
+		MarshalBinary() ([]byte, error)
+	}).MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	e.u8(sampleType)
+	e.bytes(buf)
+	return nil
+}
+
+func decodeSample(d *decoder) (deepcfr.Sample, error) {
+	sampleType := d.u8()
+	buf := d.bytes()
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	switch sampleType {
+	case sampleRegret:
+		sample := &deepcfr.RegretSample{}
+		err := sample.UnmarshalBinary(buf)
+		return sample, err
+	case sampleExperience:
+		sample := &deepcfr.ExperienceTuple{}
+		err := sample.UnmarshalBinary(buf)
+		return sample, err
+	default:
+		return nil, fmt.Errorf("unknown sample type %d", sampleType)
+	}
+}
@@ -0,0 +1,383 @@
+package remote
+
+import (
+	"bytes"
+	"encoding/gob"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/timpalpant/go-cfr"
+	"github.com/timpalpant/go-cfr/deepcfr"
+)
+
+// meanModel fits each InfoSet to the weighted mean of its advantages.
+type meanModel struct{}
+
+func (meanModel) Train(buf deepcfr.Buffer) deepcfr.TrainedModel {
+	sums := make(map[string][]float32)
+	weights := make(map[string]float32)
+	for _, s := range buf.GetSamples() {
+		sample := s.(*deepcfr.RegretSample)
+		key := string(sample.InfoSet)
+		if sums[key] == nil {
+			sums[key] = make([]float32, len(sample.Advantages))
+		}
+
+		for i, x := range sample.Advantages {
+			sums[key][i] += sample.Weight * x
+		}
+
+		weights[key] += sample.Weight
+	}
+
+	for key, sum := range sums {
+		for i := range sum {
+			sum[i] /= weights[key]
+		}
+	}
+
+	return meanPredictor(sums)
+}
+
+type meanPredictor map[string][]float32
+
+func (m meanPredictor) Predict(infoSet cfr.InfoSet, nActions int) []float32 {
+	result := make([]float32, nActions)
+	copy(result, m[string(infoSet.Key())])
+	return result
+}
+
+type testInfoSet string
+
+func (s testInfoSet) Key() []byte                       { return []byte(s) }
+func (s testInfoSet) MarshalBinary() ([]byte, error)    { return []byte(s), nil }
+func (s *testInfoSet) UnmarshalBinary(buf []byte) error { *s = testInfoSet(buf); return nil }
+
+func newInfoSet(key string) *testInfoSet {
+	is := testInfoSet(key)
+	return &is
+}
+
+// countingConn counts the number of frames written to a connection.
+type countingConn struct {
+	net.Conn
+	mx      sync.Mutex
+	nWrites int
+}
+
+func (c *countingConn) Write(p []byte) (int, error) {
+	c.mx.Lock()
+	c.nWrites++
+	c.mx.Unlock()
+	return c.Conn.Write(p)
+}
+
+func startServer(t *testing.T) string {
+	return startServerWithModel(t, meanModel{})
+}
+
+func startServerWithModel(t *testing.T, model deepcfr.Model) string {
+	addr := filepath.Join(t.TempDir(), "model.sock")
+	l, err := net.Listen("unix", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { l.Close() })
+	go NewServer(model).Serve(l)
+	return addr
+}
+
+func newTestBuffer() deepcfr.Buffer {
+	buf := deepcfr.NewCircularBuffer(10)
+	buf.AddSample(&deepcfr.RegretSample{Weight: 1, InfoSet: []byte("a"), Advantages: []float32{1, 2}})
+	buf.AddSample(&deepcfr.RegretSample{Weight: 3, InfoSet: []byte("a"), Advantages: []float32{-1, 2}})
+	buf.AddSample(&deepcfr.RegretSample{Weight: 1, InfoSet: []byte("b"), Advantages: []float32{0, 1, 5}})
+	return buf
+}
+
+func assertEqual(t *testing.T, expected, actual []float32) {
+	if len(expected) != len(actual) {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+
+	for i := range expected {
+		if expected[i] != actual[i] {
+			t.Fatalf("expected %v, got %v", expected, actual)
+		}
+	}
+}
+
+func TestTrainPredict(t *testing.T) {
+	addr := startServer(t)
+	client, err := Dial("unix", addr, BatchParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	model := NewModel(client)
+	trained := model.Train(newTestBuffer())
+	assertEqual(t, []float32{-0.5, 2}, trained.Predict(newInfoSet("a"), 2))
+	assertEqual(t, []float32{0, 1, 5}, trained.Predict(newInfoSet("b"), 3))
+	assertEqual(t, []float32{0, 0}, trained.Predict(newInfoSet("c"), 2))
+
+	// Models are independent.
+	buf := deepcfr.NewCircularBuffer(1)
+	buf.AddSample(&deepcfr.RegretSample{Weight: 1, InfoSet: []byte("a"), Advantages: []float32{7, 8}})
+	trained2 := model.Train(buf)
+	assertEqual(t, []float32{7, 8}, trained2.Predict(newInfoSet("a"), 2))
+	assertEqual(t, []float32{-0.5, 2}, trained.Predict(newInfoSet("a"), 2))
+}
+
+func TestPredictBatching(t *testing.T) {
+	addr := startServer(t)
+	conn, err := net.Dial("unix", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counter := &countingConn{Conn: conn}
+	client := NewClient(counter, BatchParams{
+		MaxBatchSize: 100,
+		BatchTimeout: 50 * time.Millisecond,
+	})
+	defer client.Close()
+
+	trained := NewModel(client).Train(newTestBuffer())
+	counter.mx.Lock()
+	counter.nWrites = 0
+	counter.mx.Unlock()
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if p := trained.Predict(newInfoSet("a"), 2); p[0] != -0.5 || p[1] != 2 {
+				t.Errorf("expected [-0.5 2], got %v", p)
+			}
+		}()
+	}
+
+	wg.Wait()
+	// Each frame is sent with two writes (header and payload).
+	if nFrames := counter.nWrites / 2; nFrames >= 100 {
+		t.Errorf("expected predictions to be batched, but sent %d requests", nFrames)
+	}
+}
+
+func TestUnknownModel(t *testing.T) {
+	addr := startServer(t)
+	client, err := Dial("unix", addr, BatchParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	if _, err := client.Predict(42, newInfoSet("a"), 2); err == nil {
+		t.Error("expected error predicting with unknown model")
+	}
+
+	// The connection is still usable after an error.
+	modelID, err := client.Train(newTestBuffer())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prediction, err := client.Predict(modelID, newInfoSet("a"), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqual(t, []float32{-0.5, 2}, prediction)
+}
+
+// panickingModel trains models that panic when predicting.
+type panickingModel struct{}
+
+func (panickingModel) Train(buf deepcfr.Buffer) deepcfr.TrainedModel {
+	return panickingModel{}
+}
+
+func (panickingModel) Predict(infoSet cfr.InfoSet, nActions int) []float32 {
+	panic("prediction failed")
+}
+
+func TestPredictPanic(t *testing.T) {
+	addr := startServerWithModel(t, panickingModel{})
+	client, err := Dial("unix", addr, BatchParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	modelID, err := client.Train(newTestBuffer())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A panic in the model is returned as an error, and the
+	// connection is still usable afterwards.
+	for i := 0; i < 2; i++ {
+		_, err := client.Predict(modelID, newInfoSet("a"), 2)
+		if err == nil || !strings.Contains(err.Error(), "prediction failed") {
+			t.Errorf("expected error from panic, got %v", err)
+		}
+	}
+}
+
+func TestPredictTooManyActions(t *testing.T) {
+	addr := startServer(t)
+	client, err := Dial("unix", addr, BatchParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	modelID, err := client.Train(newTestBuffer())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.Predict(modelID, newInfoSet("a"), 1<<16); err == nil {
+		t.Error("expected error predicting 65536 actions")
+	}
+}
+
+func TestBatchPredictTooManyActions(t *testing.T) {
+	addr := startServer(t)
+	client, err := Dial("unix", addr, BatchParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	trained := NewModel(client).Train(newTestBuffer()).(*TrainedModel)
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("expected panic predicting 65536 actions")
+		}
+	}()
+
+	infoSets := []cfr.InfoSet{newInfoSet("a"), newInfoSet("b")}
+	trained.BatchPredict(infoSets, []int{2, 1 << 16})
+}
+
+func TestMarshalTrainedModel(t *testing.T) {
+	addr := startServer(t)
+	client, err := Dial("unix", addr, BatchParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	var trained deepcfr.TrainedModel = NewModel(client).Train(newTestBuffer())
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&trained); err != nil {
+		t.Fatal(err)
+	}
+
+	var reloaded deepcfr.TrainedModel
+	if err := gob.NewDecoder(&buf).Decode(&reloaded); err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqual(t, []float32{-0.5, 2}, reloaded.Predict(newInfoSet("a"), 2))
+	if id := reloaded.(*TrainedModel).ID(); id != trained.(*TrainedModel).ID() {
+		t.Errorf("expected model %d, got %d", trained.(*TrainedModel).ID(), id)
+	}
+}
+
+func TestTrain_Chunked(t *testing.T) {
+	addr := startServer(t)
+	conn, err := net.Dial("unix", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counter := &countingConn{Conn: conn}
+	client := NewClient(counter, BatchParams{})
+	defer client.Close()
+
+	// Enough samples to need more than one TrainSamples frame.
+	n := 100000
+	buf := deepcfr.NewCircularBuffer(n + 1)
+	advantages := make([]float32, 16)
+	for i := 0; i < n; i++ {
+		buf.AddSample(&deepcfr.RegretSample{Weight: 1, InfoSet: []byte("a"), Advantages: advantages})
+	}
+	last := make([]float32, len(advantages))
+	last[0] = 2
+	buf.AddSample(&deepcfr.RegretSample{Weight: float32(n), InfoSet: []byte("a"), Advantages: last})
+
+	modelID, err := client.Train(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Begin, at least two chunks and end, each sent with two writes.
+	if nFrames := counter.nWrites / 2; nFrames < 4 {
+		t.Errorf("expected samples to be sent in chunks, but sent %d frames", nFrames)
+	}
+
+	prediction, err := client.Predict(modelID, newInfoSet("a"), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The mean over all samples, so none were lost.
+	assertEqual(t, []float32{1}, prediction)
+}
+
+func TestBrokenConnection(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	client := NewClient(clientConn, BatchParams{})
+	defer client.Close()
+
+	// The server reads a request and hangs up without responding.
+	go func() {
+		readFrame(serverConn)
+		serverConn.Close()
+	}()
+
+	if _, err := client.Train(newTestBuffer()); err == nil {
+		t.Fatal("expected error when server hangs up")
+	}
+
+	// The stream may be out of sync, so it is not reused.
+	if _, err := client.Predict(0, newInfoSet("a"), 2); err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("expected broken connection error, got %v", err)
+	}
+}
+
+func TestClose(t *testing.T) {
+	addr := startServer(t)
+	client, err := Dial("unix", addr, BatchParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	modelID, err := client.Train(newTestBuffer())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.Predict(modelID, newInfoSet("a"), 2); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.Predict(modelID, newInfoSet("a"), 2); err == nil {
+		t.Error("expected error predicting with closed client")
+	}
+
+	// Closing twice is harmless.
+	client.Close()
+}
@@ -0,0 +1,207 @@
+package remote
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/golang/glog"
+	"github.com/timpalpant/go-cfr/deepcfr"
+)
+
+// Server is a reference implementation of a model server, which trains
+// models in-process with a deepcfr.Model. It can be used to test clients,
+// or to run a pure Go model (such as mlp.Model) in a separate process.
+// Samples streamed for training are held in memory until the TrainEnd
+// request is received.
+type Server struct {
+	model deepcfr.Model
+
+	mx     sync.Mutex
+	models []deepcfr.TrainedModel
+}
+
+// NewServer returns a new Server that trains models with the given Model.
+func NewServer(model deepcfr.Model) *Server {
+	return &Server{model: model}
+}
+
+// Serve accepts connections on the listener and serves each one
+// in a new goroutine. It returns when the listener fails.
+func (s *Server) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+
+		go func() {
+			defer conn.Close()
+			if err := s.ServeConn(conn); err != nil {
+				glog.Errorf("error serving %v: %v", conn.RemoteAddr(), err)
+			}
+		}()
+	}
+}
+
+// ServeConn serves requests on the connection until it is closed.
+func (s *Server) ServeConn(conn io.ReadWriter) error {
+	session := &trainingSession{}
+	for {
+		request, err := readFrame(conn)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		response, err := s.handle(session, request)
+		if err != nil {
+			e := &encoder{}
+			e.u8(statusError)
+			e.buf = append(e.buf, err.Error()...)
+			response = e.buf
+		}
+
+		if err := writeFrame(conn, response); err != nil {
+			return err
+		}
+	}
+}
+
+// trainingSession holds the samples received on a connection
+// since the last TrainBegin.
+type trainingSession struct {
+	started bool
+	samples []deepcfr.Sample
+}
+
+// handle returns the response to a request. If the model panics,
+// the panic is returned as an error.
+func (s *Server) handle(session *trainingSession, request []byte) (response []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	d := &decoder{buf: request}
+	switch msgType := d.u8(); msgType {
+	case msgTrainBegin:
+		session.started = true
+		session.samples = nil
+		return []byte{statusOK}, nil
+	case msgTrainSamples:
+		return s.addSamples(session, d)
+	case msgTrainEnd:
+		return s.train(session)
+	case msgPredict:
+		return s.predict(d)
+	default:
+		return nil, fmt.Errorf("unknown message type %d", msgType)
+	}
+}
+
+func (s *Server) addSamples(session *trainingSession, d *decoder) ([]byte, error) {
+	if !session.started {
+		return nil, fmt.Errorf("training samples sent without a training session")
+	}
+
+	n := int(d.u32())
+	for i := 0; i < n; i++ {
+		sample, err := decodeSample(d)
+		if err != nil {
+			session.started = false
+			session.samples = nil
+			return nil, err
+		}
+
+		session.samples = append(session.samples, sample)
+	}
+
+	return []byte{statusOK}, nil
+}
+
+func (s *Server) train(session *trainingSession) ([]byte, error) {
+	if !session.started {
+		return nil, fmt.Errorf("training ended without a training session")
+	}
+
+	buf := deepcfr.NewCircularBuffer(len(session.samples))
+	for _, sample := range session.samples {
+		buf.AddSample(sample)
+	}
+
+	session.started = false
+	session.samples = nil
+
+	trained := s.model.Train(buf)
+	s.mx.Lock()
+	modelID := uint64(len(s.models))
+	s.models = append(s.models, trained)
+	s.mx.Unlock()
+
+	e := &encoder{}
+	e.u8(statusOK)
+	e.u64(modelID)
+	return e.buf, nil
+}
+
+func (s *Server) predict(d *decoder) ([]byte, error) {
+	n := int(d.u32())
+	e := &encoder{}
+	e.u8(statusOK)
+	for i := 0; i < n; i++ {
+		modelID := d.u64()
+		nActions := int(d.u16())
+		infoSet := rawInfoSet(d.bytes())
+		if d.err != nil {
+			return nil, d.err
+		}
+
+		model, err := s.getModel(modelID)
+		if err != nil {
+			return nil, err
+		}
+
+		prediction := model.Predict(&infoSet, nActions)
+		if len(prediction) > maxActions {
+			return nil, fmt.Errorf("model %d predicted %d actions, the maximum is %d",
+				modelID, len(prediction), maxActions)
+		}
+
+		e.u16(uint16(len(prediction)))
+		for _, x := range prediction {
+			e.f32(x)
+		}
+	}
+
+	return e.buf, d.err
+}
+
+func (s *Server) getModel(modelID uint64) (deepcfr.TrainedModel, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if modelID >= uint64(len(s.models)) {
+		return nil, fmt.Errorf("unknown model %d", modelID)
+	}
+
+	return s.models[modelID], nil
+}
+
+// rawInfoSet is an InfoSet received over the wire as its serialized bytes.
+type rawInfoSet []byte
+
+func (is rawInfoSet) Key() []byte {
+	return is
+}
+
+func (is rawInfoSet) MarshalBinary() ([]byte, error) {
+	return is, nil
+}
+
+func (is *rawInfoSet) UnmarshalBinary(buf []byte) error {
+	*is = append((*is)[:0], buf...)
+	return nil
+}
@@ -3,8 +3,12 @@ package deepcfr
 import (
 	"bytes"
 	"encoding/gob"
+	"io"
+	"math/rand"
 	"sync"
 	"sync/atomic"
+
+	"github.com/timpalpant/go-cfr/internal/randutil"
 )
 
 // ReservoirBuffer is a collection of samples held in memory.
@@ -25,12 +29,13 @@ type ReservoirBuffer struct {
 }
 
 // NewBuffer returns an empty Buffer with the given max size.
-func NewReservoirBuffer(maxSize, maxParallel int) *ReservoirBuffer {
+// The random number generators used for reservoir sampling are seeded from rng.
+func NewReservoirBuffer(rng *rand.Rand, maxSize, maxParallel int) *ReservoirBuffer {
 	return &ReservoirBuffer{
 		maxSize:     maxSize,
 		maxParallel: maxParallel,
 		samples:     make([]Sample, maxSize),
-		rngPool:     newRandPool(2 * maxParallel),
+		rngPool:     newRandPool(rng, 2*maxParallel),
 	}
 }
 
@@ -89,6 +94,8 @@ func (b *ReservoirBuffer) Close() error {
 }
 
 // MarshalBinary implements encoding.BinaryMarshaler.
+// The state of the random number generators is saved, so that
+// a restored buffer samples the same as this one from now on.
 func (b *ReservoirBuffer) MarshalBinary() ([]byte, error) {
 	var buf bytes.Buffer
 	enc := gob.NewEncoder(&buf)
@@ -109,6 +116,10 @@ func (b *ReservoirBuffer) MarshalBinary() ([]byte, error) {
 		return nil, err
 	}
 
+	if err := enc.Encode(b.rngPool.state()); err != nil {
+		return nil, err
+	}
+
 	return buf.Bytes(), nil
 }
 
@@ -133,7 +144,18 @@ func (b *ReservoirBuffer) UnmarshalBinary(buf []byte) error {
 		return err
 	}
 
-	b.rngPool = newRandPool(b.maxParallel)
+	var states []randutil.State
+	if err := dec.Decode(&states); err == io.EOF {
+		// Saved before the state of the random number generators was
+		// recorded, so they are seeded from the global source.
+		rng := rand.New(rand.NewSource(rand.Int63()))
+		b.rngPool = newRandPool(rng, 2*b.maxParallel)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	b.rngPool = restoreRandPool(states)
 	return nil
 }
 
@@ -2,16 +2,61 @@ package deepcfr
 
 import (
 	"math/rand"
+	"reflect"
 	"sync/atomic"
 	"testing"
 )
 
+func TestReservoirBuffer_MarshalReproducible(t *testing.T) {
+	buf := NewReservoirBuffer(rand.New(rand.NewSource(123)), 10, 1)
+	unmarshaled := NewReservoirBuffer(rand.New(rand.NewSource(123)), 10, 1)
+	for i := 0; i < 100; i++ {
+		buf.AddSample(&RegretSample{Weight: float32(i)})
+		unmarshaled.AddSample(&RegretSample{Weight: float32(i)})
+	}
+
+	data, err := buf.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var restored ReservoirBuffer
+	if err := restored.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+
+	// The restored buffer keeps the same samples as the original, and
+	// marshaling does not change the samples kept by the original.
+	for i := 100; i < 200; i++ {
+		buf.AddSample(&RegretSample{Weight: float32(i)})
+		restored.AddSample(&RegretSample{Weight: float32(i)})
+		unmarshaled.AddSample(&RegretSample{Weight: float32(i)})
+	}
+
+	weights := func(b *ReservoirBuffer) []float32 {
+		var result []float32
+		for _, sample := range b.GetSamples() {
+			result = append(result, sample.(*RegretSample).Weight)
+		}
+
+		return result
+	}
+
+	if !reflect.DeepEqual(weights(&restored), weights(buf)) {
+		t.Errorf("expected samples %v, got %v", weights(buf), weights(&restored))
+	}
+
+	if !reflect.DeepEqual(weights(buf), weights(unmarshaled)) {
+		t.Errorf("expected samples %v, got %v", weights(unmarshaled), weights(buf))
+	}
+}
+
 // BenchmarkRandPool		30000000	        42.5 ns/op
 // BenchmarkRandPool-4   	30000000	        43.0 ns/op
 // BenchmarkRandPool-24    	20000000	        71.6 ns/op
 // BenchmarkRandPool-256    20000000	        89.8 ns/op
 func BenchmarkRandPool(b *testing.B) {
-	pool := newRandPool(128)
+	pool := newRandPool(rand.New(rand.NewSource(123)), 128)
 	var n int64
 	b.ResetTimer()
 	b.RunParallel(func(pb *testing.PB) {
@@ -0,0 +1,287 @@
+package deepcfr
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"math/rand"
+
+	"github.com/timpalpant/go-cfr"
+	"github.com/timpalpant/go-cfr/internal/f32"
+	"github.com/timpalpant/go-cfr/internal/randutil"
+)
+
+// ModelRetention bounds the set of TrainedModels that SingleDeepCFR keeps for
+// each player, which otherwise grows by one model every iteration.
+//
+// Each model is kept along with its weight in the average strategy.
+// A model trained on iteration t has weight t (Linear CFR); a model that
+// stands in for others that were dropped should have their combined weight.
+type ModelRetention interface {
+	// Retain is called after a new model is appended to the models of
+	// the given player, and returns the models (and weights) to keep.
+	// The last model is the current strategy and must be kept as is.
+	Retain(player int, models []TrainedModel, weights []float32) ([]TrainedModel, []float32)
+}
+
+// StrategySampler is implemented by ModelRetentions that need samples of the
+// current strategy. SingleDeepCFR adds a sample of the current strategy to
+// the player's buffer each time its policy's AddStrategyWeight is called,
+// weighted by the weight of the current model.
+type StrategySampler interface {
+	StrategyBuffer(player int) Buffer
+}
+
+// KeepLast keeps only the most recent MaxModels models of each player.
+// The average strategy is then the average over a sliding window of
+// recent iterations, rather than over all iterations.
+type KeepLast struct {
+	MaxModels int // Must be at least 1.
+}
+
+// Retain implements ModelRetention. It panics if MaxModels is less than 1.
+func (k KeepLast) Retain(player int, models []TrainedModel, weights []float32) ([]TrainedModel, []float32) {
+	checkMaxModels("KeepLast", k.MaxModels, 1)
+	if len(models) <= k.MaxModels {
+		return models, weights
+	}
+
+	n := len(models) - k.MaxModels
+	return models[n:], weights[n:]
+}
+
+// GeometricRetention keeps at most MaxModels models of each player. When there
+// are too many, the two adjacent models with the smallest combined weight are
+// merged: the older one is dropped, and its weight is added to the newer one.
+// Since weights grow linearly with iteration, older models are merged more
+// often, and the models kept are spaced roughly geometrically in time.
+//
+// This is an approximation: the strategy of the model that is kept stands in
+// for that of the model that is dropped, which is reasonable when strategies
+// change slowly between nearby iterations.
+type GeometricRetention struct {
+	MaxModels int // Must be at least 2.
+}
+
+// Retain implements ModelRetention. It panics if MaxModels is less than 2.
+func (g GeometricRetention) Retain(player int, models []TrainedModel, weights []float32) ([]TrainedModel, []float32) {
+	checkMaxModels("GeometricRetention", g.MaxModels, 2)
+	for len(models) > g.MaxModels {
+		// The current model (the last one) is never merged.
+		best := 0
+		for i := 1; i < len(models)-2; i++ {
+			if weights[i]+weights[i+1] < weights[best]+weights[best+1] {
+				best = i
+			}
+		}
+
+		weights[best+1] += weights[best]
+		models = append(models[:best], models[best+1:]...)
+		weights = append(weights[:best], weights[best+1:]...)
+	}
+
+	return models, weights
+}
+
+// ReservoirRetention keeps the current model of each player along with a
+// uniformly random sample of MaxModels-1 of the previous ones. Each retained
+// previous model is reweighted by the number of previous models it represents,
+// so that the average strategy is an unbiased estimate of the full average.
+//
+// If it is attached to a SingleDeepCFR that already has models, they are
+// taken as the previous models seen so far.
+type ReservoirRetention struct {
+	MaxModels int // Must be at least 2.
+
+	src  *randutil.Source
+	rng  *rand.Rand
+	seen []int // Number of previous models offered to each player's reservoir.
+}
+
+// NewReservoirRetention returns a new ReservoirRetention that keeps up to
+// maxModels models per player. Its random number generator is seeded from rng.
+// It panics if maxModels is less than 2.
+func NewReservoirRetention(rng *rand.Rand, maxModels int) *ReservoirRetention {
+	checkMaxModels("ReservoirRetention", maxModels, 2)
+	src := randutil.NewSource(rng.Int63())
+	return &ReservoirRetention{
+		MaxModels: maxModels,
+		src:       src,
+		rng:       rand.New(src),
+	}
+}
+
+// Retain implements ModelRetention. It panics if MaxModels is less than 2.
+func (r *ReservoirRetention) Retain(player int, models []TrainedModel, weights []float32) ([]TrainedModel, []float32) {
+	checkMaxModels("ReservoirRetention", r.MaxModels, 2)
+	if len(models) < 2 {
+		return models, weights
+	}
+
+	for len(r.seen) <= player {
+		r.seen = append(r.seen, 0)
+	}
+
+	// The previously current model is offered to the reservoir of older models,
+	// whose weights are first restored to their unscaled values.
+	n := len(models)
+	prevModels, prevWeights := models[:n-2], weights[:n-2]
+	candidate, candidateWeight := models[n-2], weights[n-2]
+	maxSize := r.MaxModels - 1
+	if r.seen[player] == 0 && len(prevModels) > 0 {
+		// The models were kept before this reservoir was used. Keep a
+		// uniform sample of them if there are too many, scaled as if
+		// they had been offered to the reservoir one at a time.
+		r.seen[player] = len(prevModels)
+		if len(prevModels) > maxSize {
+			r.rng.Shuffle(len(prevModels), func(i, j int) {
+				prevModels[i], prevModels[j] = prevModels[j], prevModels[i]
+				prevWeights[i], prevWeights[j] = prevWeights[j], prevWeights[i]
+			})
+
+			prevModels, prevWeights = prevModels[:maxSize], prevWeights[:maxSize]
+			f32.ScalUnitary(float32(r.seen[player])/float32(maxSize), prevWeights)
+		}
+	}
+
+	if len(prevModels) > 0 {
+		f32.ScalUnitary(float32(len(prevModels))/float32(r.seen[player]), prevWeights)
+	}
+
+	r.seen[player]++
+	if len(prevModels) < maxSize {
+		prevModels = append(prevModels, candidate)
+		prevWeights = append(prevWeights, candidateWeight)
+	} else if j := r.rng.Intn(r.seen[player]); j < maxSize {
+		prevModels[j] = candidate
+		prevWeights[j] = candidateWeight
+	}
+
+	f32.ScalUnitary(float32(r.seen[player])/float32(len(prevModels)), prevWeights)
+	resultModels := append(prevModels, models[n-1])
+	resultWeights := append(prevWeights, weights[n-1])
+	return resultModels, resultWeights
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+// The state of the random number generator is saved, so that
+// a restored policy retains the same models as this one from now on.
+func (r *ReservoirRetention) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+
+	if err := enc.Encode(r.MaxModels); err != nil {
+		return nil, err
+	}
+
+	if err := enc.Encode(r.seen); err != nil {
+		return nil, err
+	}
+
+	if err := enc.Encode(r.src.State()); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (r *ReservoirRetention) UnmarshalBinary(buf []byte) error {
+	dec := gob.NewDecoder(bytes.NewReader(buf))
+
+	if err := dec.Decode(&r.MaxModels); err != nil {
+		return err
+	}
+
+	if err := dec.Decode(&r.seen); err != nil {
+		return err
+	}
+
+	src, err := randutil.Decode(dec)
+	if err != nil {
+		return err
+	}
+
+	r.src = src
+	r.rng = rand.New(src)
+	return nil
+}
+
+// Distillation keeps at most MaxModels models of each player. When there are
+// too many, all but the current model are replaced by a single StrategyModel
+// trained with Model to predict their average strategy, with their combined
+// weight.
+//
+// The training samples are the current strategy of each iteration, collected
+// in the player's Buffer (see StrategySampler) as in Deep CFR, so the buffer
+// must hold samples from all iterations (e.g. a ReservoirBuffer). Since the
+// distilled model plays the average strategy, its probability of reaching each
+// InfoSet is the weighted average of that of the models it replaces, and so
+// the SD-CFR weighting of the average strategy remains correct.
+type Distillation struct {
+	Model     Model
+	Buffers   []Buffer
+	MaxModels int // Must be at least 2.
+}
+
+// StrategyBuffer implements StrategySampler.
+func (d *Distillation) StrategyBuffer(player int) Buffer {
+	return d.Buffers[player]
+}
+
+// Retain implements ModelRetention. It panics if MaxModels is less than 2.
+func (d *Distillation) Retain(player int, models []TrainedModel, weights []float32) ([]TrainedModel, []float32) {
+	checkMaxModels("Distillation", d.MaxModels, 2)
+	if len(models) <= d.MaxModels {
+		return models, weights
+	}
+
+	n := len(models)
+	distilled := &StrategyModel{d.Model.Train(d.Buffers[player])}
+	totalWeight := f32.Sum(weights[:n-1])
+	return []TrainedModel{distilled, models[n-1]}, []float32{totalWeight, weights[n-1]}
+}
+
+// checkMaxModels panics if a retention policy would have to drop models
+// that it cannot, such as the current model.
+func checkMaxModels(name string, maxModels, min int) {
+	if maxModels < min {
+		panic(fmt.Errorf("%s: MaxModels must be at least %d, got %d", name, min, maxModels))
+	}
+}
+
+// StrategyModel is a TrainedModel whose predictions are strategies, such as one
+// trained on samples of strategies. Predictions are clipped to be nonnegative
+// and normalized to sum to 1.
+type StrategyModel struct {
+	Model TrainedModel
+}
+
+// Predict implements TrainedModel.
+func (m *StrategyModel) Predict(infoSet cfr.InfoSet, nActions int) []float32 {
+	return normalizeStrategy(m.Model.Predict(infoSet, nActions))
+}
+
+// normalizeStrategy returns a copy of the prediction with negative entries
+// set to zero and normalized to sum to 1, or the uniform distribution if
+// all entries are nonpositive.
+func normalizeStrategy(prediction []float32) []float32 {
+	strategy := make([]float32, len(prediction))
+	copy(strategy, prediction)
+	makePositive(strategy)
+	if total := f32.Sum(strategy); total > 0 {
+		f32.ScalUnitary(1.0/total, strategy)
+	} else {
+		strategy = uniformDist(len(strategy))
+	}
+
+	return strategy
+}
+
+func init() {
+	gob.Register(KeepLast{})
+	gob.Register(GeometricRetention{})
+	gob.Register(&ReservoirRetention{})
+	gob.Register(&Distillation{})
+	gob.Register(&StrategyModel{})
+}
@@ -0,0 +1,145 @@
+package deepcfr
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/timpalpant/go-cfr"
+	"github.com/timpalpant/go-cfr/internal/f32"
+)
+
+// indexModel is a TrainedModel that identifies the iteration it was trained on.
+type indexModel int
+
+func (m indexModel) Predict(infoSet cfr.InfoSet, nActions int) []float32 {
+	return make([]float32, nActions)
+}
+
+func retainAll(t *testing.T, retention ModelRetention, n int) ([]TrainedModel, []float32) {
+	var models []TrainedModel
+	var weights []float32
+	for iter := 1; iter <= n; iter++ {
+		models = append(models, indexModel(iter))
+		weights = append(weights, float32(iter))
+		models, weights = retention.Retain(0, models, weights)
+		// The current model must always be kept as is.
+		if models[len(models)-1] != indexModel(iter) || weights[len(weights)-1] != float32(iter) {
+			t.Fatalf("iteration %d: current model was not kept: %v %v", iter, models, weights)
+		}
+	}
+
+	return models, weights
+}
+
+func TestKeepLast(t *testing.T) {
+	models, weights := retainAll(t, KeepLast{MaxModels: 3}, 10)
+	if len(models) != 3 || models[0] != indexModel(8) || weights[0] != 8 {
+		t.Errorf("expected last 3 models, got %v with weights %v", models, weights)
+	}
+}
+
+func TestGeometricRetention(t *testing.T) {
+	models, weights := retainAll(t, GeometricRetention{MaxModels: 8}, 100)
+	if len(models) != 8 {
+		t.Errorf("expected 8 models, got %d", len(models))
+	}
+
+	// The total weight of all iterations is conserved.
+	if total := f32.Sum(weights); total != 5050 {
+		t.Errorf("expected total weight 5050, got %v", total)
+	}
+
+	// Recent models are kept more densely than old ones.
+	gapOld := int(models[1].(indexModel) - models[0].(indexModel))
+	gapNew := int(models[7].(indexModel) - models[6].(indexModel))
+	if gapOld <= gapNew {
+		t.Errorf("expected older models to be sparser, got %v", models)
+	}
+}
+
+func TestReservoirRetention(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
+	// Each model is kept with equal probability, and the expected
+	// weight of each kept model is its original weight.
+	nTrials := 2000
+	expectedWeights := make([]float64, 50)
+	for trial := 0; trial < nTrials; trial++ {
+		models, weights := retainAll(t, NewReservoirRetention(rng, 5), 50)
+		if len(models) != 5 {
+			t.Fatalf("expected 5 models, got %d", len(models))
+		}
+
+		for i, model := range models {
+			expectedWeights[int(model.(indexModel))-1] += float64(weights[i]) / float64(nTrials)
+		}
+	}
+
+	for i, w := range expectedWeights {
+		if math.Abs(w-float64(i+1))/float64(i+1) > 0.3 {
+			t.Errorf("model %d: expected weight %d, got %v", i+1, i+1, w)
+		}
+	}
+}
+
+func TestReservoirRetention_AttachedLate(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
+	nTrials := 2000
+	expectedWeights := make([]float64, 30)
+	for trial := 0; trial < nTrials; trial++ {
+		// The first 20 models were kept without any retention policy.
+		var models []TrainedModel
+		var weights []float32
+		for iter := 1; iter <= 20; iter++ {
+			models = append(models, indexModel(iter))
+			weights = append(weights, float32(iter))
+		}
+
+		retention := NewReservoirRetention(rng, 5)
+		for iter := 21; iter <= 30; iter++ {
+			models = append(models, indexModel(iter))
+			weights = append(weights, float32(iter))
+			models, weights = retention.Retain(0, models, weights)
+		}
+
+		if len(models) != 5 || models[4] != indexModel(30) {
+			t.Fatalf("expected 5 models ending with the current one, got %v", models)
+		}
+
+		for i, model := range models {
+			w := float64(weights[i])
+			if math.IsNaN(w) || math.IsInf(w, 0) {
+				t.Fatalf("model %v has invalid weight %v", model, w)
+			}
+
+			expectedWeights[int(model.(indexModel))-1] += w / float64(nTrials)
+		}
+	}
+
+	for i, w := range expectedWeights {
+		if math.Abs(w-float64(i+1))/float64(i+1) > 0.3 {
+			t.Errorf("model %d: expected weight %d, got %v", i+1, i+1, w)
+		}
+	}
+}
+
+func TestRetention_MaxModels(t *testing.T) {
+	models := []TrainedModel{indexModel(1), indexModel(2), indexModel(3)}
+	weights := []float32{1, 2, 3}
+	for _, retention := range []ModelRetention{
+		KeepLast{MaxModels: 0},
+		GeometricRetention{MaxModels: 1},
+		&ReservoirRetention{MaxModels: 1},
+		&Distillation{MaxModels: 1},
+	} {
+		assertPanics(t, func() { retention.Retain(0, models, weights) })
+	}
+
+	assertPanics(t, func() { NewReservoirRetention(rand.New(rand.NewSource(123)), 1) })
+
+	// A single model is enough to keep the current one.
+	models, _ = KeepLast{MaxModels: 1}.Retain(0, models, weights)
+	if len(models) != 1 || models[0] != indexModel(3) {
+		t.Errorf("expected only the current model, got %v", models)
+	}
+}
@@ -0,0 +1,121 @@
+package deepcfr
+
+import (
+	"math/rand"
+)
+
+// SampleIterator iterates over the samples in a Buffer.
+//
+//	it := IterateSamples(buf)
+//	defer it.Close()
+//	for it.Next() {
+//		sample := it.Sample()
+//		...
+//	}
+//
+//	if err := it.Err(); err != nil { ... }
+type SampleIterator interface {
+	// Next advances the iterator to the next sample. It returns false
+	// when there are no more samples, or if an error occurred.
+	Next() bool
+	// Sample returns the current sample.
+	Sample() Sample
+	// Err returns the error, if any, that ended iteration.
+	Err() error
+	// Close releases any resources held by the iterator.
+	Close() error
+}
+
+// WeightedSampleIterator is a SampleIterator over a buffer whose samples are
+// not a uniform sample of those added. Weight returns the importance weight
+// of the current sample, which should be multiplied into its training weight.
+type WeightedSampleIterator interface {
+	SampleIterator
+	Weight() float32
+}
+
+// StreamingBuffer is an optional interface that a Buffer may implement
+// to iterate over its samples without holding them all in memory at once.
+type StreamingBuffer interface {
+	Buffer
+	// Iterate returns an iterator over all samples in the buffer.
+	Iterate() SampleIterator
+	// Shuffle returns an iterator over all samples in the buffer
+	// (one epoch), in an order chosen randomly with rng.
+	Shuffle(rng *rand.Rand) SampleIterator
+}
+
+// IterateSamples returns an iterator over all samples in the buffer.
+// If the buffer is not a StreamingBuffer, its samples are iterated from GetSamples.
+func IterateSamples(buf Buffer) SampleIterator {
+	if sb, ok := buf.(StreamingBuffer); ok {
+		return sb.Iterate()
+	}
+
+	return &sliceIterator{samples: buf.GetSamples()}
+}
+
+// ShuffleSamples returns an iterator over one epoch of all samples in the buffer,
+// in random order. If the buffer is not a StreamingBuffer, its samples are
+// shuffled from GetSamples.
+func ShuffleSamples(rng *rand.Rand, buf Buffer) SampleIterator {
+	if sb, ok := buf.(StreamingBuffer); ok {
+		return sb.Shuffle(rng)
+	}
+
+	samples := buf.GetSamples()
+	rng.Shuffle(len(samples), func(i, j int) {
+		samples[i], samples[j] = samples[j], samples[i]
+	})
+
+	return &sliceIterator{samples: samples}
+}
+
+// NextBatch reads the next mini-batch of up to len(batch) samples from
+// the iterator into batch, and returns the number of samples read.
+// It returns 0 once the iterator is exhausted. If weights is not nil,
+// the importance weight of each sample is read into it: this is 1 unless
+// the iterator is a WeightedSampleIterator.
+func NextBatch(it SampleIterator, batch []Sample, weights []float32) int {
+	wit, weighted := it.(WeightedSampleIterator)
+	n := 0
+	for n < len(batch) && it.Next() {
+		batch[n] = it.Sample()
+		if weights != nil {
+			weights[n] = 1.0
+			if weighted {
+				weights[n] = wit.Weight()
+			}
+		}
+
+		n++
+	}
+
+	return n
+}
+
+type sliceIterator struct {
+	samples []Sample
+	idx     int
+}
+
+func (it *sliceIterator) Next() bool {
+	if it.idx >= len(it.samples) {
+		return false
+	}
+
+	it.idx++
+	return true
+}
+
+func (it *sliceIterator) Sample() Sample {
+	return it.samples[it.idx-1]
+}
+
+func (it *sliceIterator) Err() error {
+	return nil
+}
+
+func (it *sliceIterator) Close() error {
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/gob"
 	"fmt"
+	"io"
 	"math"
 	"sync"
 
@@ -19,24 +20,43 @@ import (
 //
 // During CFR iterations, samples are added to the given buffer.
 // When Update is called, the model is retrained.
+//
+// By default, the model trained on every iteration is kept to compute the
+// average strategy. Use SetRetention to bound the number of models kept.
 type SingleDeepCFR struct {
 	model         Model
 	buffers       []Buffer
 	trainedModels [][]TrainedModel
+	modelWeights  [][]float32
+	numTrained    []int
+	retention     ModelRetention
 	iter          int
 }
 
 // New returns a new SingleDeepCFR policy with the given model and sample buffer.
 func NewSingleDeepCFR(model Model, buffers []Buffer) *SingleDeepCFR {
-	return &SingleDeepCFR{
-		model:   model,
-		buffers: buffers,
-		trainedModels: [][]TrainedModel{
-			[]TrainedModel{},
-			[]TrainedModel{},
-		},
-		iter: 1,
+	d := &SingleDeepCFR{
+		model:         model,
+		buffers:       buffers,
+		trainedModels: make([][]TrainedModel, len(buffers)),
+		modelWeights:  make([][]float32, len(buffers)),
+		numTrained:    make([]int, len(buffers)),
+		iter:          1,
 	}
+
+	setIter(d.iter, d.buffers)
+	return d
+}
+
+// SetRetention sets the policy used to bound the number of models kept
+// for each player. If nil (the default), all models are kept.
+func (d *SingleDeepCFR) SetRetention(retention ModelRetention) {
+	d.retention = retention
+}
+
+// NumModels returns the number of models currently kept for the given player.
+func (d *SingleDeepCFR) NumModels(player int) int {
+	return len(d.trainedModels[player])
 }
 
 func (d *SingleDeepCFR) GetBuffer(player int) Buffer {
@@ -44,15 +64,24 @@ func (d *SingleDeepCFR) GetBuffer(player int) Buffer {
 }
 
 func (d *SingleDeepCFR) currentPlayer() int {
-	return d.iter % 2
+	return d.iter % len(d.buffers)
 }
 
 func (d *SingleDeepCFR) GetPolicy(node cfr.GameTreeNode) cfr.NodePolicy {
+	player := node.Player()
+	var strategyBuf Buffer
+	if sampler, ok := d.retention.(StrategySampler); ok {
+		strategyBuf = sampler.StrategyBuffer(player)
+	}
+
 	return &dcfrPolicy{
-		node:   node,
-		buf:    d.buffers[node.Player()],
-		models: d.trainedModels[node.Player()],
-		iter:   d.iter,
+		node:        node,
+		buf:         d.buffers[player],
+		models:      d.trainedModels[player],
+		weights:     d.modelWeights[player],
+		strategyBuf: strategyBuf,
+		iter:        d.iter,
+		nPlayers:    len(d.buffers),
 	}
 }
 
@@ -64,15 +93,33 @@ func (m AdvantageModel) Predict(infoSet cfr.InfoSet, nActions int) []float32 {
 	return regretMatching(m.Model.Predict(infoSet, nActions))
 }
 
+// BatchPredict implements BatchPredictor.
+func (m AdvantageModel) BatchPredict(infoSets []cfr.InfoSet, nActions []int) [][]float32 {
+	result := BatchPredict(m.Model, infoSets, nActions)
+	for _, advantages := range result {
+		regretMatching(advantages)
+	}
+
+	return result
+}
+
 // Update implements cfr.StrategyProfile.
 func (d *SingleDeepCFR) Update() {
 	player := d.currentPlayer()
 	buf := d.buffers[player]
 	trained := d.model.Train(buf)
 	model := &AdvantageModel{trained}
-	d.trainedModels[player] = append(d.trainedModels[player], model)
+	d.numTrained[player]++
+	models := append(d.trainedModels[player], model)
+	weights := append(d.modelWeights[player], float32(d.numTrained[player]))
+	if d.retention != nil {
+		models, weights = d.retention.Retain(player, models, weights)
+	}
 
+	d.trainedModels[player] = models
+	d.modelWeights[player] = weights
 	d.iter++
+	setIter(d.iter, d.buffers)
 }
 
 // Iter implements cfr.StrategyProfile.
@@ -116,6 +163,25 @@ func (d *SingleDeepCFR) MarshalBinary() ([]byte, error) {
 		return nil, err
 	}
 
+	if err := enc.Encode(d.modelWeights); err != nil {
+		return nil, err
+	}
+
+	if err := enc.Encode(d.numTrained); err != nil {
+		return nil, err
+	}
+
+	hasRetention := d.retention != nil
+	if err := enc.Encode(hasRetention); err != nil {
+		return nil, err
+	}
+
+	if hasRetention {
+		if err := enc.Encode(&d.retention); err != nil {
+			return nil, err
+		}
+	}
+
 	return buf.Bytes(), nil
 }
 
@@ -140,15 +206,54 @@ func (d *SingleDeepCFR) UnmarshalBinary(buf []byte) error {
 		return err
 	}
 
+	if err := dec.Decode(&d.modelWeights); err == io.EOF {
+		// Saved before model weights were recorded, when all models were kept.
+		d.modelWeights = make([][]float32, len(d.trainedModels))
+		d.numTrained = make([]int, len(d.trainedModels))
+		for player, models := range d.trainedModels {
+			d.modelWeights[player] = linearWeights(len(models))
+			d.numTrained[player] = len(models)
+		}
+
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if err := dec.Decode(&d.numTrained); err != nil {
+		return err
+	}
+
+	var hasRetention bool
+	if err := dec.Decode(&hasRetention); err != nil {
+		return err
+	}
+
+	if hasRetention {
+		if err := dec.Decode(&d.retention); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// linearWeight returns the weight of samples added on the given iteration
+// with Linear CFR. Players are traversed in turn, so it is the number of
+// iterations in which the traversing player has been updated.
+func linearWeight(iter, nPlayers int) float32 {
+	return float32((iter + nPlayers - 1) / nPlayers)
+}
+
 type dcfrPolicy struct {
-	node     cfr.GameTreeNode
-	buf      Buffer
-	models   []TrainedModel
-	strategy []float32
-	iter     int
+	node        cfr.GameTreeNode
+	buf         Buffer
+	models      []TrainedModel
+	weights     []float32
+	strategyBuf Buffer
+	strategy    []float32
+	iter        int
+	nPlayers    int
 }
 
 func (d *dcfrPolicy) currentModel() TrainedModel {
@@ -160,7 +265,7 @@ func (d *dcfrPolicy) currentModel() TrainedModel {
 }
 
 func (d *dcfrPolicy) AddRegret(weight float32, samplingQ, instantaneousRegrets []float32) {
-	weight *= float32((d.iter + 1) / 2) // Linear CFR.
+	weight *= linearWeight(d.iter, d.nPlayers)
 	sample := NewRegretSample(d.node, instantaneousRegrets, weight)
 	d.buf.AddSample(sample)
 }
@@ -190,100 +295,88 @@ func (d *dcfrPolicy) GetBaseline() []float32 {
 func (d *dcfrPolicy) UpdateBaseline(w float32, action int, value float32) {}
 
 func (d *dcfrPolicy) AddStrategyWeight(w float32) {
-	// We perform SD-CFR, so don't save strategy weight samples.
+	// We perform SD-CFR, so strategy samples are only needed
+	// if the retention policy distills models from them.
+	if d.strategyBuf == nil || len(d.models) == 0 {
+		return
+	}
+
+	w *= d.weights[len(d.weights)-1]
+	sample := NewRegretSample(d.node, d.GetStrategy(), w)
+	d.strategyBuf.AddSample(sample)
 }
 
 func (d *dcfrPolicy) GetAverageStrategy() []float32 {
-	return getAverageStrategy(d.node, d.models)
+	return getAverageStrategy(d.node, d.models, d.weights)
 }
 
-func getAverageStrategy(node cfr.GameTreeNode, models []TrainedModel) []float32 {
+// getAverageStrategy returns the average of the strategies of the models
+// at the node, where each model is weighted by its given weight and the
+// probability that it plays to reach the node. If weights is nil,
+// the models are weighted linearly in the order they were trained.
+func getAverageStrategy(node cfr.GameTreeNode, models []TrainedModel, weights []float32) []float32 {
 	nChildren := node.NumChildren()
 	if nChildren == 1 {
 		return []float32{1.0}
 	}
 
-	var modelPredictions [][]float32
-	var wg sync.WaitGroup
-	wg.Add(1)
-	go func() {
-		modelPredictions = getModelPredictions(node, models)
-		wg.Done()
-	}()
-
-	var modelWeights []float32
-	wg.Add(1)
-	go func() {
-		modelWeights = getModelWeights(node, models)
-		wg.Done()
-	}()
-
-	wg.Wait()
+	// The strategy of each model at this node, and the probability with which
+	// each model plays to reach it, are predicted in one batch per model.
+	infoSets := []cfr.InfoSet{node.InfoSet(node.Player())}
+	nActions := []int{nChildren}
+	var childIdxs []int
+	lastChild := node
+	for ancestor := node.Parent(); ancestor != nil; ancestor = ancestor.Parent() {
+		if ancestor.Type() == cfr.PlayerNodeType && ancestor.Player() == node.Player() {
+			infoSets = append(infoSets, ancestor.InfoSet(ancestor.Player()))
+			nActions = append(nActions, ancestor.NumChildren())
+			childIdxs = append(childIdxs, childIndex(ancestor, lastChild))
+		}
 
-	result := make([]float32, nChildren)
-	for t, w := range modelWeights {
-		glog.V(3).Infof("[t=%d] Weight: %v, Strategy: %v", t, w, modelPredictions[t])
-		f32.AxpyUnitary(w, modelPredictions[t], result)
+		lastChild = ancestor
 	}
 
-	return result
-}
+	if weights == nil {
+		weights = linearWeights(len(models))
+	}
 
-func getModelPredictions(node cfr.GameTreeNode, models []TrainedModel) [][]float32 {
-	var mu sync.Mutex
-	var wg sync.WaitGroup
 	modelPredictions := make([][]float32, len(models))
-	infoSet := node.InfoSet(node.Player())
-	nChildren := node.NumChildren()
+	modelWeights := make([]float32, len(models))
+	var wg sync.WaitGroup
 	for i, model := range models {
 		wg.Add(1)
 		go func(i int, model TrainedModel) {
-			result := model.Predict(infoSet, nChildren)
-			mu.Lock()
-			modelPredictions[i] = result
-			mu.Unlock()
+			predictions := BatchPredict(model, infoSets, nActions)
+			modelPredictions[i] = predictions[0]
+			modelWeights[i] = weights[i]
+			for j, childIdx := range childIdxs {
+				modelWeights[i] *= predictions[j+1][childIdx]
+			}
+
 			wg.Done()
 		}(i, model)
 	}
 
 	wg.Wait()
-	return modelPredictions
-}
 
-func getModelWeights(node cfr.GameTreeNode, models []TrainedModel) []float32 {
-	var mu sync.Mutex
-	var wg sync.WaitGroup
-	modelWeights := make([]float32, len(models))
-	for i := range modelWeights {
-		modelWeights[i] = float32(i + 1)
+	normalization := f32.Sum(modelWeights)
+	f32.ScalUnitary(1.0/normalization, modelWeights)
+	result := make([]float32, nChildren)
+	for t, w := range modelWeights {
+		glog.V(3).Infof("[t=%d] Weight: %v, Strategy: %v", t, w, modelPredictions[t])
+		f32.AxpyUnitary(w, modelPredictions[t], result)
 	}
 
-	lastChild := node
-	for ancestor := node.Parent(); ancestor != nil; ancestor = ancestor.Parent() {
-		if ancestor.Type() == cfr.PlayerNodeType && ancestor.Player() == node.Player() {
-			nChildren := ancestor.NumChildren()
-			childIdx := childIndex(ancestor, lastChild)
-			infoSet := ancestor.InfoSet(ancestor.Player())
-			for i, model := range models {
-				wg.Add(1)
-				go func(i int, model TrainedModel) {
-					strategy := model.Predict(infoSet, nChildren)
-					mu.Lock()
-					modelWeights[i] *= strategy[childIdx]
-					mu.Unlock()
-					wg.Done()
-				}(i, model)
-			}
-		}
+	return result
+}
 
-		lastChild = ancestor
+func linearWeights(n int) []float32 {
+	weights := make([]float32, n)
+	for i := range weights {
+		weights[i] = float32(i + 1)
 	}
 
-	wg.Wait()
-
-	normalization := f32.Sum(modelWeights)
-	f32.ScalUnitary(1.0/normalization, modelWeights)
-	return modelWeights
+	return weights
 }
 
 func childIndex(parent, child cfr.GameTreeNode) int {
@@ -0,0 +1,562 @@
+package deepcfr
+
+import (
+	"bytes"
+	"container/heap"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+
+	"github.com/timpalpant/go-cfr/internal/randutil"
+)
+
+// StratumFunc assigns a sample, added during the given CFR iteration,
+// to a stratum. The strata of a StratifiedBuffer are the distinct
+// combinations of the values of its StratumFuncs.
+type StratumFunc func(iter int, sample Sample) int
+
+// ByIteration returns a StratumFunc that buckets samples by the iteration
+// in which they were added, with bucketSize iterations per bucket.
+func ByIteration(bucketSize int) StratumFunc {
+	return func(iter int, sample Sample) int {
+		return iter / bucketSize
+	}
+}
+
+// ByInfoSet returns a StratumFunc that assigns samples to strata by
+// their serialized InfoSet, for example to stratify by player or by
+// depth in the game tree if those can be decoded from the InfoSet.
+func ByInfoSet(f func(infoSet []byte) int) StratumFunc {
+	return func(iter int, sample Sample) int {
+		switch s := sample.(type) {
+		case *RegretSample:
+			return f(s.InfoSet)
+		case *ExperienceTuple:
+			return f(s.InfoSet)
+		default:
+			return 0
+		}
+	}
+}
+
+// PriorityFunc returns the priority of a sample in a prioritized buffer.
+// Samples with higher priority are more likely to be kept.
+type PriorityFunc func(sample Sample) float32
+
+// AdvantagePriority returns a PriorityFunc that prioritizes samples by their
+// largest absolute advantage (or absolute value, for ExperienceTuples).
+// Priorities are at least minPriority, which must be positive, so that
+// every sample has a chance to be kept.
+func AdvantagePriority(minPriority float32) PriorityFunc {
+	return func(sample Sample) float32 {
+		priority := minPriority
+		switch s := sample.(type) {
+		case *RegretSample:
+			for _, x := range s.Advantages {
+				if abs := float32(math.Abs(float64(x))); abs > priority {
+					priority = abs
+				}
+			}
+		case *ExperienceTuple:
+			if abs := float32(math.Abs(float64(s.Value))); abs > priority {
+				priority = abs
+			}
+		}
+
+		return priority
+	}
+}
+
+// StratifiedBufferParams configure a StratifiedBuffer.
+type StratifiedBufferParams struct {
+	// Maximum number of samples to keep in each stratum.
+	MaxSizePerStratum int
+	// Functions that assign samples to strata. If empty,
+	// all samples are in a single stratum.
+	Strata []StratumFunc
+	// If not nil, samples within each stratum are kept by priority sampling
+	// with this priority. Otherwise they are kept by uniform reservoir sampling.
+	Priority PriorityFunc
+}
+
+// StratifiedBuffer is a Buffer that keeps a separate reservoir of samples
+// for each stratum, so that, for example, samples from early iterations
+// cannot crowd out those from later iterations.
+//
+// Since the samples kept are not a uniform sample of all samples added,
+// each is given a weight that corrects for the bias: the number of added
+// samples it represents (relative to the mean). For uniform reservoirs this
+// is the number of samples added to the stratum divided by the number kept.
+// For prioritized reservoirs, it is the priority sampling estimate (Duffield,
+// Lund and Thorup, "Priority sampling for estimation of arbitrary subset
+// sums", JACM 2007).
+//
+// GetSample and GetSamples return copies of the samples with this weight
+// multiplied into their Weight. StratifiedBuffer's iterators instead return
+// the samples as they were added, and are WeightedSampleIterators: the weight
+// of each sample should be multiplied into its training weight.
+//
+// If the StratumFuncs depend on the iteration, the buffer must be told the
+// current iteration with SetIter. The deep CFR strategy profiles in this
+// package do this automatically.
+//
+// The StratumFuncs and PriorityFunc cannot be saved when the buffer is
+// marshaled. An unmarshaled buffer can be read, but AddSample panics until
+// they are restored with SetParams.
+//
+// It is safe to call AddSample concurrently from multiple goroutines.
+type StratifiedBuffer struct {
+	params StratifiedBufferParams
+	// If not nil, the buffer was unmarshaled and SetParams must be called
+	// with params of this shape before samples can be added.
+	restored *paramsShape
+
+	mx     sync.Mutex
+	src    *randutil.Source
+	rng    *rand.Rand
+	iter   int
+	strata map[string]*stratum
+	// The reweighted samples returned by GetSample(s), which are
+	// cached until the next sample is added.
+	reweighted []Sample
+}
+
+// NewStratifiedBuffer returns a new, empty StratifiedBuffer. The random number
+// generator used to sample within each stratum is seeded from rng.
+func NewStratifiedBuffer(rng *rand.Rand, params StratifiedBufferParams) *StratifiedBuffer {
+	src := randutil.NewSource(rng.Int63())
+	return &StratifiedBuffer{
+		params: params,
+		src:    src,
+		rng:    rand.New(src),
+		strata: make(map[string]*stratum),
+	}
+}
+
+// NewPrioritizedBuffer returns a StratifiedBuffer with a single stratum of up to
+// maxSize samples, in which samples are kept by priority sampling.
+func NewPrioritizedBuffer(rng *rand.Rand, maxSize int, priority PriorityFunc) *StratifiedBuffer {
+	return NewStratifiedBuffer(rng, StratifiedBufferParams{
+		MaxSizePerStratum: maxSize,
+		Priority:          priority,
+	})
+}
+
+// SetIter sets the current CFR iteration, which is passed to the StratumFuncs.
+func (b *StratifiedBuffer) SetIter(iter int) {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	b.iter = iter
+}
+
+func (b *StratifiedBuffer) stratumKey(sample Sample) string {
+	var key []byte
+	var buf [binary.MaxVarintLen64]byte
+	for _, f := range b.params.Strata {
+		n := binary.PutVarint(buf[:], int64(f(b.iter, sample)))
+		key = append(key, buf[:n]...)
+	}
+
+	return string(key)
+}
+
+// AddSample implements Buffer. It panics if the buffer was unmarshaled
+// and SetParams has not yet been called.
+func (b *StratifiedBuffer) AddSample(sample Sample) {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	if b.restored != nil {
+		panic(fmt.Errorf("StratifiedBuffer: SetParams must be called after unmarshaling"))
+	}
+
+	b.reweighted = nil
+
+	key := b.stratumKey(sample)
+	s, ok := b.strata[key]
+	if !ok {
+		s = &stratum{MaxSize: b.params.MaxSizePerStratum}
+		b.strata[key] = s
+	}
+
+	if b.params.Priority != nil {
+		s.addPrioritized(b.rng, sample, b.params.Priority(sample))
+	} else {
+		s.addUniform(b.rng, sample)
+	}
+}
+
+// GetSample implements Buffer. The sample's Weight is multiplied
+// by the weight that corrects for the bias of the buffer.
+func (b *StratifiedBuffer) GetSample(idx int) Sample {
+	return b.getReweightedSamples()[idx]
+}
+
+// GetSamples implements Buffer. The samples' Weights are multiplied
+// by the weights that correct for the bias of the buffer.
+func (b *StratifiedBuffer) GetSamples() []Sample {
+	samples := b.getReweightedSamples()
+	return append([]Sample(nil), samples...)
+}
+
+// getReweightedSamples returns the (cached) samples of GetSamples,
+// which must not be modified.
+func (b *StratifiedBuffer) getReweightedSamples() []Sample {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	if b.reweighted == nil {
+		samples, weights := b.getWeightedSamples()
+		for i, sample := range samples {
+			samples[i] = reweight(sample, weights[i])
+		}
+
+		b.reweighted = samples
+	}
+
+	return b.reweighted
+}
+
+// reweight returns a copy of the sample with its weight multiplied by w.
+func reweight(sample Sample, w float32) Sample {
+	switch s := sample.(type) {
+	case *RegretSample:
+		result := *s
+		result.Weight *= w
+		return &result
+	case *ExperienceTuple:
+		result := *s
+		result.Weight *= w
+		return &result
+	default:
+		return sample
+	}
+}
+
+// GetWeightedSamples returns all samples in the buffer, along with
+// the weight of each that corrects for the bias of stratification
+// and prioritization. The weights have mean 1.
+func (b *StratifiedBuffer) GetWeightedSamples() ([]Sample, []float32) {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	return b.getWeightedSamples()
+}
+
+func (b *StratifiedBuffer) getWeightedSamples() ([]Sample, []float32) {
+	// Iterate over strata in a deterministic order.
+	keys := make([]string, 0, len(b.strata))
+	for key := range b.strata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var samples []Sample
+	var weights []float32
+	var total float64
+	for _, key := range keys {
+		s := b.strata[key]
+		n := len(samples)
+		samples = s.appendSamples(samples)
+		weights = s.appendWeights(weights)
+		for _, w := range weights[n:] {
+			total += float64(w)
+		}
+	}
+
+	if total > 0 {
+		scale := float32(float64(len(weights)) / total)
+		for i := range weights {
+			weights[i] *= scale
+		}
+	}
+
+	return samples, weights
+}
+
+// Len implements Buffer. It returns the number of samples kept in the buffer.
+func (b *StratifiedBuffer) Len() int {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	n := 0
+	for _, s := range b.strata {
+		n += s.len()
+	}
+
+	return n
+}
+
+// NumStrata returns the number of strata to which samples have been added.
+func (b *StratifiedBuffer) NumStrata() int {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	return len(b.strata)
+}
+
+// Iterate implements StreamingBuffer.
+func (b *StratifiedBuffer) Iterate() SampleIterator {
+	samples, weights := b.GetWeightedSamples()
+	return &weightedSliceIterator{sliceIterator{samples: samples}, weights}
+}
+
+// Shuffle implements StreamingBuffer.
+func (b *StratifiedBuffer) Shuffle(rng *rand.Rand) SampleIterator {
+	samples, weights := b.GetWeightedSamples()
+	rng.Shuffle(len(samples), func(i, j int) {
+		samples[i], samples[j] = samples[j], samples[i]
+		weights[i], weights[j] = weights[j], weights[i]
+	})
+
+	return &weightedSliceIterator{sliceIterator{samples: samples}, weights}
+}
+
+// Close implements io.Closer.
+func (b *StratifiedBuffer) Close() error {
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+// The StratumFuncs and PriorityFunc are not saved, and must be
+// restored with SetParams after unmarshaling. Only the number of
+// StratumFuncs, and whether there is a PriorityFunc, are saved.
+// The state of the random number generator is saved,
+// so that a restored buffer samples the same as this one from now on.
+func (b *StratifiedBuffer) MarshalBinary() ([]byte, error) {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+
+	if err := enc.Encode(b.params.MaxSizePerStratum); err != nil {
+		return nil, err
+	}
+
+	shape := b.restored
+	if shape == nil {
+		shape = newParamsShape(b.params)
+	}
+
+	if err := enc.Encode(shape); err != nil {
+		return nil, err
+	}
+
+	if err := enc.Encode(b.iter); err != nil {
+		return nil, err
+	}
+
+	if err := enc.Encode(b.strata); err != nil {
+		return nil, err
+	}
+
+	if err := enc.Encode(b.src.State()); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (b *StratifiedBuffer) UnmarshalBinary(buf []byte) error {
+	r := bytes.NewReader(buf)
+	dec := gob.NewDecoder(r)
+
+	if err := dec.Decode(&b.params.MaxSizePerStratum); err != nil {
+		return err
+	}
+
+	b.restored = &paramsShape{}
+	if err := dec.Decode(b.restored); err != nil {
+		return err
+	}
+
+	if err := dec.Decode(&b.iter); err != nil {
+		return err
+	}
+
+	if err := dec.Decode(&b.strata); err != nil {
+		return err
+	}
+
+	src, err := randutil.Decode(dec)
+	if err != nil {
+		return err
+	}
+
+	b.src = src
+	b.rng = rand.New(src)
+	b.reweighted = nil
+	return nil
+}
+
+// SetParams sets the StratumFuncs and PriorityFunc of the buffer,
+// which are not saved when the buffer is marshaled. It panics if the
+// params have a different number of StratumFuncs than those the buffer
+// was saved with, or if only one of them has a PriorityFunc, and the
+// buffer is not empty, since the samples already kept could not be
+// combined with those added later.
+func (b *StratifiedBuffer) SetParams(params StratifiedBufferParams) {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	shape := newParamsShape(params)
+	if b.restored == nil {
+		b.restored = newParamsShape(b.params)
+	}
+
+	if *shape != *b.restored && len(b.strata) > 0 {
+		panic(fmt.Errorf("StratifiedBuffer: params have %d strata (prioritized: %v), but buffer has %d (prioritized: %v)",
+			shape.NumStrata, shape.Prioritized, b.restored.NumStrata, b.restored.Prioritized))
+	}
+
+	b.params = params
+	b.restored = nil
+}
+
+// paramsShape is the part of StratifiedBufferParams that is saved
+// when the buffer is marshaled.
+type paramsShape struct {
+	NumStrata   int
+	Prioritized bool
+}
+
+func newParamsShape(params StratifiedBufferParams) *paramsShape {
+	return &paramsShape{
+		NumStrata:   len(params.Strata),
+		Prioritized: params.Priority != nil,
+	}
+}
+
+// stratum is a reservoir of samples. In uniform mode, Samples is a uniform
+// sample of the N samples added. In prioritized mode, Entries is a min-heap
+// (by key) of the highest-keyed samples, with one more entry than is kept so
+// that the smallest key is the priority sampling threshold.
+type stratum struct {
+	MaxSize int
+	N       int
+	Samples []Sample
+	Entries priorityEntries
+}
+
+type priorityEntry struct {
+	Sample   Sample
+	Priority float32
+	Key      float64
+}
+
+func (s *stratum) addUniform(rng *rand.Rand, sample Sample) {
+	s.N++
+	if len(s.Samples) < s.MaxSize {
+		s.Samples = append(s.Samples, sample)
+	} else if m := rng.Intn(s.N); m < s.MaxSize {
+		s.Samples[m] = sample
+	}
+}
+
+func (s *stratum) addPrioritized(rng *rand.Rand, sample Sample, priority float32) {
+	s.N++
+	// Priority sampling: the key is the priority divided by a uniform random
+	// number in (0, 1], and the samples with the largest keys are kept.
+	key := float64(priority) / (1.0 - rng.Float64())
+	entry := priorityEntry{sample, priority, key}
+	if len(s.Entries) <= s.MaxSize {
+		heap.Push(&s.Entries, entry)
+	} else if key > s.Entries[0].Key {
+		s.Entries[0] = entry
+		heap.Fix(&s.Entries, 0)
+	}
+}
+
+func (s *stratum) len() int {
+	if len(s.Entries) > 0 {
+		entries, _ := s.kept()
+		return len(entries)
+	}
+
+	return len(s.Samples)
+}
+
+// kept returns the entries that are kept in a prioritized reservoir,
+// and the priority sampling threshold.
+func (s *stratum) kept() (priorityEntries, float64) {
+	if len(s.Entries) <= s.MaxSize {
+		// No samples have been dropped yet.
+		return s.Entries, 0
+	}
+
+	return s.Entries[1:], s.Entries[0].Key
+}
+
+func (s *stratum) appendSamples(samples []Sample) []Sample {
+	if len(s.Entries) > 0 {
+		entries, _ := s.kept()
+		for _, entry := range entries {
+			samples = append(samples, entry.Sample)
+		}
+
+		return samples
+	}
+
+	return append(samples, s.Samples...)
+}
+
+func (s *stratum) appendWeights(weights []float32) []float32 {
+	if len(s.Entries) > 0 {
+		entries, threshold := s.kept()
+		for _, entry := range entries {
+			w := math.Max(float64(entry.Priority), threshold) / float64(entry.Priority)
+			weights = append(weights, float32(w))
+		}
+
+		return weights
+	}
+
+	w := float32(s.N) / float32(len(s.Samples))
+	for range s.Samples {
+		weights = append(weights, w)
+	}
+
+	return weights
+}
+
+// priorityEntries implements heap.Interface as a min-heap by key.
+type priorityEntries []priorityEntry
+
+func (h priorityEntries) Len() int            { return len(h) }
+func (h priorityEntries) Less(i, j int) bool  { return h[i].Key < h[j].Key }
+func (h priorityEntries) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *priorityEntries) Push(x interface{}) { *h = append(*h, x.(priorityEntry)) }
+func (h *priorityEntries) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
+
+type weightedSliceIterator struct {
+	sliceIterator
+	weights []float32
+}
+
+func (it *weightedSliceIterator) Weight() float32 {
+	return it.weights[it.idx-1]
+}
+
+// iterBuffer is implemented by buffers that need to know the current iteration.
+type iterBuffer interface {
+	SetIter(iter int)
+}
+
+// setIter informs any of the buffers that need to know of the current iteration.
+func setIter(iter int, buffers []Buffer) {
+	for _, buf := range buffers {
+		if ib, ok := buf.(iterBuffer); ok {
+			ib.SetIter(iter)
+		}
+	}
+}
+
+func init() {
+	gob.Register(&StratifiedBuffer{})
+}
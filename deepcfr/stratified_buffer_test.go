@@ -0,0 +1,255 @@
+package deepcfr
+
+import (
+	"bytes"
+	"encoding/gob"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/timpalpant/go-cfr"
+)
+
+func TestStratifiedBuffer_ByIteration(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
+	buf := NewStratifiedBuffer(rng, StratifiedBufferParams{
+		MaxSizePerStratum: 10,
+		Strata:            []StratumFunc{ByIteration(2)},
+	})
+
+	// Early iterations add many more samples than later ones.
+	nSamples := []int{100, 100, 40, 40, 10, 10}
+	for iter, n := range nSamples {
+		buf.SetIter(iter)
+		for i := 0; i < n; i++ {
+			buf.AddSample(newTestSample(iter))
+		}
+	}
+
+	if buf.NumStrata() != 3 {
+		t.Errorf("expected 3 strata, got %d", buf.NumStrata())
+	}
+
+	if buf.Len() != 30 {
+		t.Errorf("expected 30 samples, got %d", buf.Len())
+	}
+
+	// Each sample is weighted by the number of samples it represents.
+	samples, weights := buf.GetWeightedSamples()
+	expected := map[int]float32{0: 200, 1: 200, 2: 80, 3: 80, 4: 20, 5: 20}
+	scale := float32(30.0 / 300.0)
+	for i, sample := range samples {
+		w := expected[sampleWeight(sample)] / 10 * scale
+		if math.Abs(float64(weights[i]-w)) > 1e-5 {
+			t.Errorf("sample %v: expected weight %v, got %v", sample, w, weights[i])
+		}
+	}
+}
+
+func TestStratifiedBuffer_GetSamples(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
+	buf := NewStratifiedBuffer(rng, StratifiedBufferParams{
+		MaxSizePerStratum: 10,
+		Strata:            []StratumFunc{ByIteration(1)},
+	})
+
+	for iter := 1; iter <= 3; iter++ {
+		buf.SetIter(iter)
+		for i := 0; i < 10*iter; i++ {
+			buf.AddSample(newTestSample(iter))
+		}
+	}
+
+	// The weights of GetSamples include the correction for the bias of the buffer.
+	expected, weights := buf.GetWeightedSamples()
+	samples := buf.GetSamples()
+	if len(samples) != len(expected) {
+		t.Fatalf("expected %d samples, got %d", len(expected), len(samples))
+	}
+
+	for i, sample := range samples {
+		w := getWeight(expected[i]) * weights[i]
+		for _, s := range []Sample{sample, buf.GetSample(i)} {
+			if actual := getWeight(s); math.Abs(float64(actual-w)) > 1e-5 {
+				t.Errorf("sample %d: expected weight %v, got %v", i, w, actual)
+			}
+		}
+	}
+
+	// The samples in the buffer are not modified.
+	for i, sample := range expected {
+		if w := getWeight(sample); w != float32(getIter(sample)) {
+			t.Errorf("sample %d: weight was modified to %v", i, w)
+		}
+	}
+}
+
+func getWeight(s Sample) float32 {
+	switch s := s.(type) {
+	case *RegretSample:
+		return s.Weight
+	case *ExperienceTuple:
+		return s.Weight
+	}
+
+	panic("unknown sample type")
+}
+
+// getIter returns the iteration that a sample from newTestSample was created with.
+func getIter(s Sample) int {
+	switch s := s.(type) {
+	case *RegretSample:
+		return int(s.InfoSet[0])
+	case *ExperienceTuple:
+		return int(s.InfoSet[0])
+	}
+
+	panic("unknown sample type")
+}
+
+func TestStratifiedBuffer_SetIter(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
+	buf := NewStratifiedBuffer(rng, StratifiedBufferParams{
+		MaxSizePerStratum: 10,
+		Strata:            []StratumFunc{ByIteration(1)},
+	})
+
+	profile := NewSingleDeepCFR(&constantModel{}, []Buffer{buf, buf})
+	for i := 0; i < 3; i++ {
+		buf.AddSample(newTestSample(0))
+		profile.Update()
+	}
+
+	if buf.NumStrata() != 3 {
+		t.Errorf("expected 3 strata, got %d", buf.NumStrata())
+	}
+}
+
+func TestPrioritizedBuffer(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
+	var lowFraction, highFraction float64
+	nTrials := 100
+	for trial := 0; trial < nTrials; trial++ {
+		buf := NewPrioritizedBuffer(rng, 50, AdvantagePriority(0.01))
+		for i := 0; i < 1000; i++ {
+			advantage := float32(0.0)
+			if i%10 == 0 {
+				advantage = 1.0
+			}
+
+			buf.AddSample(&RegretSample{
+				Weight:     1.0,
+				InfoSet:    []byte{byte(i)},
+				Advantages: []float32{advantage, -advantage},
+			})
+		}
+
+		if buf.Len() != 50 {
+			t.Fatalf("expected 50 samples, got %d", buf.Len())
+		}
+
+		samples, weights := buf.GetWeightedSamples()
+		var nHigh int
+		var lowWeight, totalWeight float64
+		for i, sample := range samples {
+			if sample.(*RegretSample).Advantages[0] > 0 {
+				nHigh++
+			} else {
+				lowWeight += float64(weights[i])
+			}
+
+			totalWeight += float64(weights[i])
+		}
+
+		highFraction += float64(nHigh) / 50 / float64(nTrials)
+		lowFraction += lowWeight / totalWeight / float64(nTrials)
+	}
+
+	// Most of the samples kept have high priority...
+	if highFraction < 0.8 {
+		t.Errorf("expected mostly high-priority samples, got fraction %v", highFraction)
+	}
+
+	// ...but the weights correct for it.
+	if math.Abs(lowFraction-0.9) > 0.1 {
+		t.Errorf("expected weighted low-priority fraction ~0.9, got %v", lowFraction)
+	}
+}
+
+func TestStratifiedBuffer_Marshal(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
+	params := StratifiedBufferParams{
+		MaxSizePerStratum: 5,
+		Strata:            []StratumFunc{ByIteration(1)},
+		Priority:          AdvantagePriority(0.1),
+	}
+	buf := NewStratifiedBuffer(rng, params)
+	for i := 0; i < 20; i++ {
+		buf.SetIter(i % 2)
+		buf.AddSample(newTestSample(2 * i))
+	}
+
+	var b bytes.Buffer
+	var buffer Buffer = buf
+	if err := gob.NewEncoder(&b).Encode(&buffer); err != nil {
+		t.Fatal(err)
+	}
+
+	var reloaded Buffer
+	if err := gob.NewDecoder(&b).Decode(&reloaded); err != nil {
+		t.Fatal(err)
+	}
+
+	// Samples cannot be added until the funcs are restored,
+	// and then only with params of the same shape.
+	assertPanics(t, func() { reloaded.AddSample(newTestSample(100)) })
+	assertPanics(t, func() {
+		reloaded.(*StratifiedBuffer).SetParams(StratifiedBufferParams{
+			MaxSizePerStratum: 5,
+			Strata:            []StratumFunc{ByIteration(1)},
+		})
+	})
+
+	reloaded.(*StratifiedBuffer).SetParams(params)
+	expected, expectedWeights := buf.GetWeightedSamples()
+	actual, actualWeights := reloaded.(*StratifiedBuffer).GetWeightedSamples()
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %d samples, got %d", len(expected), len(actual))
+	}
+
+	for i := range expected {
+		if sampleWeight(actual[i]) != sampleWeight(expected[i]) || actualWeights[i] != expectedWeights[i] {
+			t.Errorf("sample %d: expected %v (%v), got %v (%v)",
+				i, expected[i], expectedWeights[i], actual[i], actualWeights[i])
+		}
+	}
+
+	n := reloaded.Len()
+	reloaded.(*StratifiedBuffer).SetIter(2)
+	reloaded.AddSample(newTestSample(100))
+	if reloaded.Len() != n+1 {
+		t.Errorf("expected %d samples after adding one, got %d", n+1, reloaded.Len())
+	}
+}
+
+func assertPanics(t *testing.T, f func()) {
+	t.Helper()
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("expected panic")
+		}
+	}()
+
+	f()
+}
+
+// constantModel predicts zero advantages for every InfoSet.
+type constantModel struct{}
+
+func (m *constantModel) Train(buf Buffer) TrainedModel {
+	return m
+}
+
+func (m *constantModel) Predict(infoSet cfr.InfoSet, nActions int) []float32 {
+	return make([]float32, nActions)
+}
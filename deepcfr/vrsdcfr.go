@@ -25,20 +25,18 @@ type VRSingleDeepCFR struct {
 
 // New returns a new VRSingleDeepCFR policy with the given model and sample buffer.
 func NewVRSingleDeepCFR(model Model, buffers, baselineBuffers []Buffer) *VRSingleDeepCFR {
-	return &VRSingleDeepCFR{
+	d := &VRSingleDeepCFR{
 		model:           model,
 		buffers:         buffers,
 		baselineBuffers: baselineBuffers,
-		trainedModels: [][]TrainedModel{
-			[]TrainedModel{},
-			[]TrainedModel{},
-		},
-		baselineModels: []TrainedModel{
-			nil,
-			nil,
-		},
-		iter: 1,
+		trainedModels:   make([][]TrainedModel, len(buffers)),
+		baselineModels:  make([]TrainedModel, len(buffers)),
+		iter:            1,
 	}
+
+	setIter(d.iter, d.buffers)
+	setIter(d.iter, d.baselineBuffers)
+	return d
 }
 
 func (d *VRSingleDeepCFR) GetBuffer(player int) Buffer {
@@ -46,7 +44,7 @@ func (d *VRSingleDeepCFR) GetBuffer(player int) Buffer {
 }
 
 func (d *VRSingleDeepCFR) currentPlayer() int {
-	return d.iter % 2
+	return d.iter % len(d.buffers)
 }
 
 func (d *VRSingleDeepCFR) GetPolicy(node cfr.GameTreeNode) cfr.NodePolicy {
@@ -57,6 +55,7 @@ func (d *VRSingleDeepCFR) GetPolicy(node cfr.GameTreeNode) cfr.NodePolicy {
 		models:        d.trainedModels[node.Player()],
 		baselineModel: d.baselineModels[node.Player()],
 		iter:          d.iter,
+		nPlayers:      len(d.buffers),
 	}
 }
 
@@ -72,6 +71,8 @@ func (d *VRSingleDeepCFR) Update() {
 	d.baselineModels[player] = d.model.Train(baselineBuf)
 
 	d.iter++
+	setIter(d.iter, d.buffers)
+	setIter(d.iter, d.baselineBuffers)
 }
 
 // Iter implements cfr.StrategyProfile.
@@ -171,6 +172,7 @@ type vrdcfrPolicy struct {
 	models        []TrainedModel
 	baselineModel TrainedModel
 	iter          int
+	nPlayers      int
 
 	infoSet  cfr.InfoSet
 	strategy []float32
@@ -198,7 +200,7 @@ func (d *vrdcfrPolicy) IsEmpty() bool {
 }
 
 func (d *vrdcfrPolicy) AddRegret(weight float32, samplingQ, instantaneousRegrets []float32) {
-	weight *= float32((d.iter + 1) / 2) // Linear CFR.
+	weight *= linearWeight(d.iter, d.nPlayers)
 	for i, r := range instantaneousRegrets {
 		// We only save regret samples that were actually traversed.
 		if samplingQ[i] > 0 {
@@ -234,7 +236,7 @@ func (d *vrdcfrPolicy) GetBaseline() []float32 {
 }
 
 func (d *vrdcfrPolicy) UpdateBaseline(w float32, action int, value float32) {
-	w *= float32((d.iter + 1) / 2) // Linear CFR.
+	w *= linearWeight(d.iter, d.nPlayers)
 	sample := NewExperienceTuple(d.node, w, action, value)
 	d.baselineBuf.AddSample(sample)
 }
@@ -244,7 +246,7 @@ func (d *vrdcfrPolicy) AddStrategyWeight(w float32) {
 }
 
 func (d *vrdcfrPolicy) GetAverageStrategy() []float32 {
-	return getAverageStrategy(d.node, d.models)
+	return getAverageStrategy(d.node, d.models, nil)
 }
 
 func init() {
@@ -0,0 +1,221 @@
+package exploitability
+
+import (
+	"github.com/timpalpant/go-cfr"
+)
+
+// BestResponse is a pure best response for one player against fixed
+// policies for all other players.
+//
+// BestResponse implements mcts.Policy for the responding player's nodes,
+// and can be converted to a cfr.StrategyProfile with StrategyProfile().
+type BestResponse struct {
+	player     int
+	actions    map[string]int
+	numActions map[string]int
+	value      float64
+}
+
+// NewBestResponse computes a best response for the given player to the
+// opponent policy. The opponent policy is queried at every node of the
+// game tree that belongs to a player other than the responding player.
+func NewBestResponse(root cfr.GameTreeNode, player int, opponent Policy) *BestResponse {
+	r := newResponder(player, newCachedPolicy(opponent))
+	value := r.compute(root)
+	return &BestResponse{
+		player:     player,
+		actions:    r.actions,
+		numActions: r.numActions,
+		value:      value,
+	}
+}
+
+// Player returns the responding player.
+func (br *BestResponse) Player() int {
+	return br.player
+}
+
+// Value returns the expected value to the responding player of playing
+// the best response.
+func (br *BestResponse) Value() float64 {
+	return br.value
+}
+
+// GetAction returns the best response action at the given node.
+// InfoSets that cannot be reached by the opponent policy, and nodes
+// of other players, may select any action.
+func (br *BestResponse) GetAction(node cfr.GameTreeNode) int {
+	if node.Player() != br.player {
+		return 0
+	}
+
+	key := node.InfoSetKey(br.player)
+	return br.actions[string(key)]
+}
+
+// GetPolicy implements mcts.Policy. Nodes of other players play
+// uniformly at random, as in StrategyProfile().
+func (br *BestResponse) GetPolicy(node cfr.GameTreeNode) []float32 {
+	p := make([]float32, node.NumChildren())
+	if node.Player() != br.player {
+		for i := range p {
+			p[i] = 1.0 / float32(len(p))
+		}
+
+		return p
+	}
+
+	p[br.GetAction(node)] = 1.0
+	return p
+}
+
+// StrategyProfile returns a cfr.StrategyProfile in which the responding
+// player plays the best response. Nodes of all other players play
+// uniformly at random.
+func (br *BestResponse) StrategyProfile() *cfr.FixedStrategyProfile {
+	profile := cfr.NewFixedStrategyProfile()
+	for key, action := range br.actions {
+		p := make([]float32, br.numActions[key])
+		p[action] = 1.0
+		profile.SetStrategy([]byte(key), p)
+	}
+
+	return profile
+}
+
+// responder computes a pure best response for one player against
+// fixed policies for all other players.
+//
+// Because the same InfoSet is reached by many histories, the best action
+// at an InfoSet depends on the values of all of its histories, which in turn
+// depend on the best responses at all later InfoSets. Assuming perfect recall,
+// every history in an InfoSet has the same number of prior decisions by the
+// responding player (its depth), so the best response can be computed by
+// walking the tree once for each depth, starting from the deepest InfoSets.
+type responder struct {
+	player   int
+	opponent Policy
+
+	// Depth of the InfoSets whose action values are being accumulated
+	// by the current walk. InfoSets deeper than this already have their
+	// best response selected.
+	targetDepth  int
+	actionValues map[string][]float64
+	actions      map[string]int
+	numActions   map[string]int
+}
+
+func newResponder(player int, opponent Policy) *responder {
+	return &responder{
+		player:     player,
+		opponent:   opponent,
+		actions:    make(map[string]int),
+		numActions: make(map[string]int),
+	}
+}
+
+// compute selects the best response action at every InfoSet of the
+// responding player and returns the value of the best response.
+func (r *responder) compute(root cfr.GameTreeNode) float64 {
+	maxDepth := r.maxDepth(root, 0)
+	for depth := maxDepth; depth >= 0; depth-- {
+		r.targetDepth = depth
+		r.actionValues = make(map[string][]float64)
+		r.walk(root, 1.0, 0)
+		for key, q := range r.actionValues {
+			r.actions[key] = argMax(q)
+			r.numActions[key] = len(q)
+		}
+	}
+
+	r.targetDepth = -1
+	r.actionValues = nil
+	return r.walk(root, 1.0, 0)
+}
+
+// maxDepth returns the maximum number of decisions made by the responding
+// player along any path from node to a terminal node.
+func (r *responder) maxDepth(node cfr.GameTreeNode, depth int) int {
+	if node.Type() == cfr.PlayerNodeType && node.Player() == r.player {
+		depth++
+	}
+
+	result := depth - 1
+	for i := 0; i < node.NumChildren(); i++ {
+		if d := r.maxDepth(node.GetChild(i), depth); d > result {
+			result = d
+		}
+	}
+
+	node.Close()
+	return result
+}
+
+// walk returns the value of the given node to the responding player, where
+// reach is the probability of reaching node due to chance and the other players.
+//
+// The returned value is only meaningful for nodes below the target depth.
+func (r *responder) walk(node cfr.GameTreeNode, reach float64, depth int) float64 {
+	var ev float64
+	switch node.Type() {
+	case cfr.TerminalNodeType:
+		ev = node.Utility(r.player)
+	case cfr.ChanceNodeType:
+		for i := 0; i < node.NumChildren(); i++ {
+			p := node.GetChildProbability(i)
+			ev += p * r.walk(node.GetChild(i), p*reach, depth)
+		}
+	default:
+		if node.Player() == r.player {
+			ev = r.handleResponderNode(node, reach, depth)
+		} else {
+			pv := r.opponent.GetPolicy(node)
+			for i := 0; i < node.NumChildren(); i++ {
+				p := float64(pv[i])
+				ev += p * r.walk(node.GetChild(i), p*reach, depth)
+			}
+		}
+	}
+
+	node.Close()
+	return ev
+}
+
+func (r *responder) handleResponderNode(node cfr.GameTreeNode, reach float64, depth int) float64 {
+	key := node.InfoSetKey(r.player)
+	nChildren := node.NumChildren()
+	switch {
+	case depth > r.targetDepth:
+		// Best response has already been selected.
+		action := r.actions[string(key)]
+		return r.walk(node.GetChild(action), reach, depth+1)
+	case depth == r.targetDepth:
+		q, ok := r.actionValues[string(key)]
+		if !ok {
+			q = make([]float64, nChildren)
+			r.actionValues[string(key)] = q
+		}
+
+		for i := 0; i < nChildren; i++ {
+			q[i] += reach * r.walk(node.GetChild(i), reach, depth+1)
+		}
+	default:
+		// Visit all children to reach the InfoSets at the target depth.
+		for i := 0; i < nChildren; i++ {
+			r.walk(node.GetChild(i), reach, depth+1)
+		}
+	}
+
+	return 0
+}
+
+func argMax(v []float64) int {
+	best := 0
+	for i, x := range v {
+		if x > v[best] {
+			best = i
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,67 @@
+package exploitability
+
+import (
+	"math"
+	"testing"
+
+	"github.com/timpalpant/go-cfr"
+	"github.com/timpalpant/go-cfr/kuhn"
+	"github.com/timpalpant/go-cfr/mcts"
+	"github.com/timpalpant/go-cfr/tree"
+)
+
+var _ mcts.Policy = &BestResponse{}
+
+func TestBestResponse_Kuhn(t *testing.T) {
+	root := kuhn.NewGame()
+	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
+	opt := cfr.New(policy)
+	for i := 0; i < 10; i++ {
+		opt.Run(root)
+		policy.Update()
+	}
+
+	opponent := AverageStrategy(policy)
+	for player := 0; player < cfr.NumPlayers(root); player++ {
+		br := NewBestResponse(root, player, opponent)
+		brProfile := br.StrategyProfile()
+
+		// Playing the best response against the opponent must
+		// achieve the best response value.
+		headToHead := policyFunc(func(node cfr.GameTreeNode) []float32 {
+			if node.Player() == player {
+				return brProfile.GetPolicy(node).GetAverageStrategy()
+			}
+
+			return opponent.GetPolicy(node)
+		})
+
+		ev := expectedValues(root, cfr.NumPlayers(root), headToHead)[player]
+		t.Logf("player %d: best response value: %v, head-to-head: %v", player, br.Value(), ev)
+		if math.Abs(ev-br.Value()) > tol {
+			t.Errorf("player %d: expected value %v, got %v", player, br.Value(), ev)
+		}
+
+		tree.Visit(root, func(node cfr.GameTreeNode) {
+			if node.Type() != cfr.PlayerNodeType {
+				return
+			}
+
+			p := br.GetPolicy(node)
+			if node.Player() != player {
+				// Nodes of other players are played uniformly.
+				for _, x := range p {
+					if x != 1.0/float32(len(p)) {
+						t.Errorf("expected uniform strategy for player %d, got %v", node.Player(), p)
+					}
+				}
+
+				return
+			}
+
+			if p[br.GetAction(node)] != 1.0 {
+				t.Errorf("expected pure strategy, got %v", p)
+			}
+		})
+	}
+}
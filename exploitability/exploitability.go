@@ -0,0 +1,149 @@
+// Package exploitability implements exact best response and exploitability
+// computations for strategy profiles in extensive-form games.
+//
+// All computations walk the full game tree and are therefore only
+// tractable for games that are small enough to enumerate.
+package exploitability
+
+import (
+	"github.com/timpalpant/go-cfr"
+)
+
+// Policy is a fixed strategy that returns the vector of action probabilities
+// to play at a given node. It is equivalent to mcts.Policy.
+type Policy interface {
+	GetPolicy(node cfr.GameTreeNode) []float32
+}
+
+// Result summarizes the exploitability of a strategy profile.
+type Result struct {
+	// BestResponseValues is the value to each player of playing a best response
+	// against the average strategies of the other players.
+	BestResponseValues []float64
+	// Values is the expected value to each player when all players follow
+	// the average strategy profile.
+	Values []float64
+	// NashConv is the sum over players of the gain from deviating to a best response.
+	NashConv float64
+	// Exploitability is the average gain from deviating to a best response.
+	// In two-player zero-sum games this is the mean best response value.
+	Exploitability float64
+}
+
+// Compute calculates the best response values, NashConv and exploitability
+// of the average strategy of the given StrategyProfile.
+func Compute(root cfr.GameTreeNode, profile cfr.StrategyProfile) Result {
+	return ComputePolicy(root, AverageStrategy(profile))
+}
+
+// ComputePolicy calculates the best response values, NashConv and exploitability
+// of the given Policy, which is used for all players.
+func ComputePolicy(root cfr.GameTreeNode, policy Policy) Result {
+	policy = newCachedPolicy(policy)
+	numPlayers := cfr.NumPlayers(root)
+	result := Result{
+		BestResponseValues: make([]float64, numPlayers),
+		Values:             expectedValues(root, numPlayers, policy),
+	}
+
+	for player := 0; player < numPlayers; player++ {
+		r := newResponder(player, policy)
+		result.BestResponseValues[player] = r.compute(root)
+		result.NashConv += result.BestResponseValues[player] - result.Values[player]
+	}
+
+	result.Exploitability = result.NashConv / float64(numPlayers)
+	return result
+}
+
+// NashConv returns the sum over players of the gain from deviating to a
+// best response against the average strategy of the given StrategyProfile.
+func NashConv(root cfr.GameTreeNode, profile cfr.StrategyProfile) float64 {
+	return Compute(root, profile).NashConv
+}
+
+// Exploitability returns the average gain from deviating to a best response
+// against the average strategy of the given StrategyProfile.
+func Exploitability(root cfr.GameTreeNode, profile cfr.StrategyProfile) float64 {
+	return Compute(root, profile).Exploitability
+}
+
+// BestResponseValue returns the value to the given player of playing a best
+// response against the average strategies of the other players.
+func BestResponseValue(root cfr.GameTreeNode, player int, profile cfr.StrategyProfile) float64 {
+	return NewBestResponse(root, player, AverageStrategy(profile)).Value()
+}
+
+// AverageStrategy returns a Policy that plays the average strategy
+// of the given StrategyProfile.
+func AverageStrategy(profile cfr.StrategyProfile) Policy {
+	return averageStrategy{profile}
+}
+
+type averageStrategy struct {
+	profile cfr.StrategyProfile
+}
+
+func (s averageStrategy) GetPolicy(node cfr.GameTreeNode) []float32 {
+	return s.profile.GetPolicy(node).GetAverageStrategy()
+}
+
+// cachedPolicy caches the strategy of each InfoSet of a Policy so that
+// it is only computed once, since the tree walks visit every InfoSet many times.
+type cachedPolicy struct {
+	policy Policy
+	cache  map[string][]float32
+}
+
+func newCachedPolicy(policy Policy) *cachedPolicy {
+	if p, ok := policy.(*cachedPolicy); ok {
+		return p
+	}
+
+	return &cachedPolicy{
+		policy: policy,
+		cache:  make(map[string][]float32),
+	}
+}
+
+func (c *cachedPolicy) GetPolicy(node cfr.GameTreeNode) []float32 {
+	key := node.InfoSetKey(node.Player())
+	p, ok := c.cache[string(key)]
+	if !ok {
+		p = c.policy.GetPolicy(node)
+		c.cache[string(key)] = p
+	}
+
+	return p
+}
+
+// expectedValues returns the expected utility of each player when all
+// players act according to the given strategy.
+func expectedValues(node cfr.GameTreeNode, numPlayers int, policy Policy) []float64 {
+	ev := make([]float64, numPlayers)
+	switch node.Type() {
+	case cfr.TerminalNodeType:
+		for player := range ev {
+			ev[player] = node.Utility(player)
+		}
+	case cfr.ChanceNodeType:
+		for i := 0; i < node.NumChildren(); i++ {
+			p := node.GetChildProbability(i)
+			addScaled(ev, p, expectedValues(node.GetChild(i), numPlayers, policy))
+		}
+	default:
+		pv := policy.GetPolicy(node)
+		for i := 0; i < node.NumChildren(); i++ {
+			addScaled(ev, float64(pv[i]), expectedValues(node.GetChild(i), numPlayers, policy))
+		}
+	}
+
+	node.Close()
+	return ev
+}
+
+func addScaled(dst []float64, alpha float64, x []float64) {
+	for i, v := range x {
+		dst[i] += alpha * v
+	}
+}
@@ -0,0 +1,124 @@
+package exploitability
+
+import (
+	"math"
+	"testing"
+
+	"github.com/timpalpant/go-cfr"
+	"github.com/timpalpant/go-cfr/kuhn"
+	"github.com/timpalpant/go-cfr/tree"
+)
+
+const tol = 1e-6
+
+func TestKuhn_UniformRandom(t *testing.T) {
+	root := kuhn.NewGame()
+	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
+	result := Compute(root, policy)
+	t.Logf("%+v", result)
+
+	// Known values for uniform random play in Kuhn poker.
+	if math.Abs(result.NashConv-11.0/12) > tol {
+		t.Errorf("expected NashConv %v, got %v", 11.0/12, result.NashConv)
+	}
+
+	if math.Abs(result.Exploitability-11.0/24) > tol {
+		t.Errorf("expected exploitability %v, got %v", 11.0/24, result.Exploitability)
+	}
+}
+
+func TestKuhn_BestResponseIsOptimal(t *testing.T) {
+	root := kuhn.NewGame()
+	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
+	opt := cfr.New(policy)
+	for i := 0; i < 10; i++ {
+		opt.Run(root)
+		policy.Update()
+	}
+
+	for player := 0; player < cfr.NumPlayers(root); player++ {
+		brValue := BestResponseValue(root, player, policy)
+		expected := bruteForceBestResponseValue(root, player, policy)
+		if math.Abs(brValue-expected) > tol {
+			t.Errorf("player %d: expected best response value %v, got %v",
+				player, expected, brValue)
+		}
+	}
+}
+
+func TestKuhn_VanillaCFR(t *testing.T) {
+	root := kuhn.NewGame()
+	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
+	opt := cfr.New(policy)
+	for i := 0; i < 1000; i++ {
+		opt.Run(root)
+		policy.Update()
+	}
+
+	result := Compute(root, policy)
+	t.Logf("%+v", result)
+	if result.Exploitability > 0.01 {
+		t.Errorf("expected exploitability < 0.01, got %v", result.Exploitability)
+	}
+
+	// Game value of Kuhn poker is -1/18 for the first player.
+	if math.Abs(result.Values[0]+1.0/18) > 0.01 {
+		t.Errorf("expected game value %v, got %v", -1.0/18, result.Values[0])
+	}
+}
+
+// bruteForceBestResponseValue enumerates all pure strategies for the
+// given player and returns the value of the best one.
+func bruteForceBestResponseValue(root cfr.GameTreeNode, player int, profile cfr.StrategyProfile) float64 {
+	var keys []string
+	numActions := make(map[string]int)
+	tree.Visit(root, func(node cfr.GameTreeNode) {
+		if node.Type() != cfr.PlayerNodeType || node.Player() != player {
+			return
+		}
+
+		key := string(node.InfoSetKey(player))
+		if _, ok := numActions[key]; !ok {
+			keys = append(keys, key)
+			numActions[key] = node.NumChildren()
+		}
+	})
+
+	average := newCachedPolicy(AverageStrategy(profile))
+	actions := make(map[string]int)
+	strategy := policyFunc(func(node cfr.GameTreeNode) []float32 {
+		if node.Player() != player {
+			return average.GetPolicy(node)
+		}
+
+		pv := make([]float32, node.NumChildren())
+		pv[actions[string(node.InfoSetKey(player))]] = 1.0
+		return pv
+	})
+
+	best := math.Inf(-1)
+	var enumerate func(i int)
+	enumerate = func(i int) {
+		if i == len(keys) {
+			if ev := expectedValues(root, cfr.NumPlayers(root), strategy)[player]; ev > best {
+				best = ev
+			}
+
+			return
+		}
+
+		for a := 0; a < numActions[keys[i]]; a++ {
+			actions[keys[i]] = a
+			enumerate(i + 1)
+		}
+	}
+
+	enumerate(0)
+	return best
+}
+
+type policyFunc func(node cfr.GameTreeNode) []float32
+
+func (f policyFunc) GetPolicy(node cfr.GameTreeNode) []float32 {
+	return f(node)
+}
@@ -0,0 +1,112 @@
+package cfr
+
+import (
+	"bytes"
+	"encoding/gob"
+)
+
+func init() {
+	gob.Register(&FixedStrategyProfile{})
+}
+
+// FixedStrategyProfile implements StrategyProfile for a fixed tabular strategy,
+// such as a best response or an equilibrium computed by some other method.
+// Strategies are looked up by InfoSet Key(). InfoSets without a strategy are
+// played uniformly at random.
+//
+// Regret and strategy updates are ignored, so a FixedStrategyProfile can be
+// used to hold one player's strategy fixed during training or evaluation.
+type FixedStrategyProfile struct {
+	strategies map[string][]float32
+}
+
+// NewFixedStrategyProfile returns a new, empty FixedStrategyProfile.
+func NewFixedStrategyProfile() *FixedStrategyProfile {
+	return &FixedStrategyProfile{
+		strategies: make(map[string][]float32),
+	}
+}
+
+// SetStrategy sets the strategy to play at the InfoSet with the given key.
+func (p *FixedStrategyProfile) SetStrategy(key []byte, strategy []float32) {
+	p.strategies[string(key)] = strategy
+}
+
+// GetPolicy implements StrategyProfile.
+func (p *FixedStrategyProfile) GetPolicy(node GameTreeNode) NodePolicy {
+	key := node.InfoSetKey(node.Player())
+	strategy, ok := p.strategies[string(key)]
+	if !ok {
+		strategy = uniformDist(node.NumChildren())
+	}
+
+	return fixedPolicy(strategy)
+}
+
+// Update implements StrategyProfile.
+func (p *FixedStrategyProfile) Update() {}
+
+// Iter implements StrategyProfile.
+func (p *FixedStrategyProfile) Iter() int {
+	return 1
+}
+
+// Close implements io.Closer.
+func (p *FixedStrategyProfile) Close() error {
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (p *FixedStrategyProfile) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+	if err := enc.Encode(p.strategies); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (p *FixedStrategyProfile) UnmarshalBinary(buf []byte) error {
+	r := bytes.NewReader(buf)
+	dec := gob.NewDecoder(r)
+	return dec.Decode(&p.strategies)
+}
+
+// fixedPolicy implements NodePolicy for a strategy that never changes.
+type fixedPolicy []float32
+
+func (p fixedPolicy) AddRegret(w float32, samplingQ, instantaneousRegrets []float32) {}
+
+func (p fixedPolicy) GetStrategy() []float32 {
+	result := make([]float32, len(p))
+	copy(result, p)
+	return result
+}
+
+func (p fixedPolicy) GetBaseline() []float32 {
+	return make([]float32, len(p))
+}
+
+func (p fixedPolicy) UpdateBaseline(w float32, action int, value float32) {}
+
+func (p fixedPolicy) AddStrategyWeight(w float32) {}
+
+func (p fixedPolicy) GetAverageStrategy() []float32 {
+	result := make([]float32, len(p))
+	copy(result, p)
+	return result
+}
+
+func (p fixedPolicy) IsEmpty() bool {
+	return false
+}
+
+func uniformDist(n int) []float32 {
+	result := make([]float32, n)
+	for i := range result {
+		result[i] = 1.0 / float32(n)
+	}
+	return result
+}
@@ -0,0 +1,161 @@
+package cfr_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/timpalpant/go-cfr"
+	"github.com/timpalpant/go-cfr/sampling"
+)
+
+// sequentialGame is a small general-sum game of perfect information.
+// Player 0 chooses A or B, then player 1 observes the choice and chooses x or y.
+//
+// In the unique subgame-perfect equilibrium player 1 chooses x after A
+// (where the players' interests are aligned), and so player 0 chooses A.
+// A solver that assumes the game is zero-sum would instead have player 1
+// choose y after A, and player 0 choose B.
+var sequentialGamePayoffs = map[string][2]float64{
+	"Ax": {3, 3},
+	"Ay": {0, 0},
+	"Bx": {1, 2},
+	"By": {2, 1},
+}
+
+var sequentialGameActions = []string{"AB", "xy"}
+
+type sequentialGameNode struct {
+	parent  *sequentialGameNode
+	history string
+	// Payoffs of each terminal history. If nil, sequentialGamePayoffs are used.
+	payoffs map[string][2]float64
+}
+
+func (n *sequentialGameNode) Type() cfr.NodeType {
+	if len(n.history) == len(sequentialGameActions) {
+		return cfr.TerminalNodeType
+	}
+
+	return cfr.PlayerNodeType
+}
+
+func (n *sequentialGameNode) Close() {}
+
+func (n *sequentialGameNode) NumChildren() int {
+	if n.Type() == cfr.TerminalNodeType {
+		return 0
+	}
+
+	return len(sequentialGameActions[n.Player()])
+}
+
+func (n *sequentialGameNode) GetChild(i int) cfr.GameTreeNode {
+	action := sequentialGameActions[n.Player()][i]
+	return &sequentialGameNode{parent: n, history: n.history + string(action), payoffs: n.payoffs}
+}
+
+func (n *sequentialGameNode) Parent() cfr.GameTreeNode {
+	return n.parent
+}
+
+func (n *sequentialGameNode) GetChildProbability(i int) float64 {
+	panic("sequential game has no chance nodes")
+}
+
+func (n *sequentialGameNode) SampleChild() (cfr.GameTreeNode, float64) {
+	panic("sequential game has no chance nodes")
+}
+
+func (n *sequentialGameNode) Player() int {
+	return len(n.history)
+}
+
+func (n *sequentialGameNode) InfoSet(player int) cfr.InfoSet {
+	is := historyInfoSet(n.history)
+	return &is
+}
+
+func (n *sequentialGameNode) InfoSetKey(player int) []byte {
+	return []byte(n.history)
+}
+
+func (n *sequentialGameNode) Utility(player int) float64 {
+	if n.payoffs != nil {
+		return n.payoffs[n.history][player]
+	}
+
+	return sequentialGamePayoffs[n.history][player]
+}
+
+type historyInfoSet string
+
+func (is historyInfoSet) Key() []byte {
+	return []byte(is)
+}
+
+func (is historyInfoSet) MarshalBinary() ([]byte, error) {
+	return is.Key(), nil
+}
+
+func (is *historyInfoSet) UnmarshalBinary(buf []byte) error {
+	*is = historyInfoSet(buf)
+	return nil
+}
+
+func TestGeneralSum_VanillaCFR(t *testing.T) {
+	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
+	testGeneralSum(t, cfr.New(policy), policy, 1000)
+}
+
+func TestGeneralSum_ChanceSamplingCFR(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
+	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
+	testGeneralSum(t, cfr.NewChanceSampling(rng, policy), policy, 1000)
+}
+
+func TestGeneralSum_ExternalSamplingCFR(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
+	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
+	opt := cfr.NewMCCFR(rng, policy, sampling.NewExternalSampler())
+	testGeneralSum(t, opt, policy, 1000)
+}
+
+func TestGeneralSum_OutcomeSamplingCFR(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
+	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
+	opt := cfr.NewMCCFR(rng, policy, sampling.NewOutcomeSampler(rng, 0.1))
+	testGeneralSum(t, opt, policy, 10000)
+}
+
+func TestGeneralSum_GeneralizedSamplingCFR(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
+	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
+	opt := cfr.NewGeneralizedSampling(rng, policy, sampling.NewRobustSampler(rng, 1))
+	testGeneralSum(t, opt, policy, 10000)
+}
+
+func TestGeneralSum_VRMCCFR(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
+	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
+	opt := cfr.NewGeneralSumVRMCCFR(rng, policy, sampling.NewRobustSampler(rng, 1), sampling.NewRobustSampler(rng, 1))
+	testGeneralSum(t, opt, policy, 10000)
+}
+
+func testGeneralSum(t *testing.T, opt cfr.Solver, policy cfr.StrategyProfile, nIter int) {
+	root := &sequentialGameNode{}
+	for i := 0; i < nIter; i++ {
+		opt.Run(root)
+		policy.Update()
+	}
+
+	p0 := policy.GetPolicy(root).GetAverageStrategy()
+	p1 := policy.GetPolicy(root.GetChild(0)).GetAverageStrategy()
+	t.Logf("player 0: %v, player 1 after A: %v", p0, p1)
+	if p0[0] < 0.95 {
+		t.Errorf("expected player 0 to choose A, got strategy %v", p0)
+	}
+
+	if p1[0] < 0.95 {
+		t.Errorf("expected player 1 to choose x after A, got strategy %v", p1)
+	}
+}
@@ -13,52 +13,72 @@ type GeneralizedSamplingCFR struct {
 	slicePool *floatSlicePool
 	mapPool   *keyIntMapPool
 	rng       *rand.Rand
+	randState
 
+	nPlayers         int
 	traversingPlayer int
 	sampledActions   map[string]int
 }
 
-func NewGeneralizedSampling(strategyProfile StrategyProfile, sampler Sampler) *GeneralizedSamplingCFR {
+func NewGeneralizedSampling(rng *rand.Rand, strategyProfile StrategyProfile, sampler Sampler) *GeneralizedSamplingCFR {
 	return &GeneralizedSamplingCFR{
 		strategyProfile: strategyProfile,
 		sampler:         sampler,
 		slicePool:       &floatSlicePool{},
 		mapPool:         &keyIntMapPool{},
-		rng:             rand.New(rand.NewSource(rand.Int63())),
+		rng:             rng,
 	}
 }
 
+// StrategyProfile implements Solver.
+func (c *GeneralizedSamplingCFR) StrategyProfile() StrategyProfile {
+	return c.strategyProfile
+}
+
+// Seed implements Solver.
+func (c *GeneralizedSamplingCFR) Seed(seed int64) {
+	c.rng.Seed(seed)
+}
+
+// Run performs one iteration of generalized sampling CFR, updating the regrets of
+// one player (in turn), and returns the sampled value of the game for the
+// first player.
 func (c *GeneralizedSamplingCFR) Run(node GameTreeNode) float32 {
 	iter := c.strategyProfile.Iter()
-	c.traversingPlayer = int(iter % 2)
+	c.nPlayers = NumPlayers(node)
+	c.traversingPlayer = iter % c.nPlayers
 	c.sampledActions = c.mapPool.alloc()
 	defer c.mapPool.free(c.sampledActions)
-	return c.runHelper(node, node.Player(), 1.0)
+	ev := c.runHelper(node, 1.0)
+	defer c.slicePool.free(ev)
+	return ev[0]
 }
 
-func (c *GeneralizedSamplingCFR) runHelper(node GameTreeNode, lastPlayer int, sampleProb float32) float32 {
-	var ev float32
+// runHelper returns the sampled utility of each player at the
+// given node. The returned slice must be freed by the caller.
+func (c *GeneralizedSamplingCFR) runHelper(node GameTreeNode, sampleProb float32) []float32 {
+	var ev []float32
 	switch node.Type() {
 	case TerminalNodeType:
-		ev = float32(node.Utility(lastPlayer))
+		ev = c.slicePool.alloc(c.nPlayers)
+		getUtilities(node, ev)
 	case ChanceNodeType:
-		ev = c.handleChanceNode(node, lastPlayer, sampleProb)
+		ev = c.handleChanceNode(node, sampleProb)
 	default:
-		sgn := getSign(lastPlayer, node.Player())
-		ev = sgn * c.handlePlayerNode(node, sampleProb)
+		ev = c.handlePlayerNode(node, sampleProb)
 	}
 
 	node.Close()
 	return ev
 }
 
-func (c *GeneralizedSamplingCFR) handleChanceNode(node GameTreeNode, lastPlayer int, sampleProb float32) float32 {
-	child, _ := node.SampleChild()
+func (c *GeneralizedSamplingCFR) handleChanceNode(node GameTreeNode, sampleProb float32) []float32 {
+	child, _ := SampleChanceNode(c.rng, node)
 	// Sampling probabilities cancel out in the calculation of counterfactual value.
-	return c.runHelper(child, lastPlayer, sampleProb)
+	return c.runHelper(child, sampleProb)
 }
 
-func (c *GeneralizedSamplingCFR) handlePlayerNode(node GameTreeNode, sampleProb float32) float32 {
+func (c *GeneralizedSamplingCFR) handlePlayerNode(node GameTreeNode, sampleProb float32) []float32 {
 	if node.Player() == c.traversingPlayer {
 		return c.handleTraversingPlayerNode(node, sampleProb)
 	} else {
@@ -66,13 +86,13 @@ func (c *GeneralizedSamplingCFR) handlePlayerNode(node GameTreeNode, sampleProb
 	}
 }
 
-func (c *GeneralizedSamplingCFR) handleTraversingPlayerNode(node GameTreeNode, sampleProb float32) float32 {
+func (c *GeneralizedSamplingCFR) handleTraversingPlayerNode(node GameTreeNode, sampleProb float32) []float32 {
 	player := node.Player()
 	nChildren := node.NumChildren()
 	if nChildren == 1 {
 		// Optimization to skip trivial nodes with no real choice.
 		child := node.GetChild(0)
-		return c.runHelper(child, player, sampleProb)
+		return c.runHelper(child, sampleProb)
 	}
 
 	policy := c.strategyProfile.GetPolicy(node)
@@ -82,19 +102,23 @@ func (c *GeneralizedSamplingCFR) handleTraversingPlayerNode(node GameTreeNode, s
 	oldSampledActions := c.sampledActions
 	c.sampledActions = c.mapPool.alloc()
 
+	strategy := policy.GetStrategy()
+	ev := c.slicePool.alloc(c.nPlayers)
 	for i, q := range qs {
 		child := node.GetChild(i)
-		var util float32
+		var childEV []float32
 		if q > 0 {
-			util = c.runHelper(child, player, q*sampleProb)
+			childEV = c.runHelper(child, q*sampleProb)
 		} else {
-			util = c.probe(child, player)
+			childEV = c.probe(child)
 		}
 
-		regrets[i] = util
+		regrets[i] = childEV[player]
+		f32.AxpyUnitary(strategy[i], childEV, ev)
+		c.slicePool.free(childEV)
 	}
 
-	cfValue := f32.DotUnitary(policy.GetStrategy(), regrets)
+	cfValue := ev[player]
 	f32.AddConst(-cfValue, regrets)
 	policy.AddRegret(1.0/sampleProb, qs, regrets)
 
@@ -102,12 +126,12 @@ func (c *GeneralizedSamplingCFR) handleTraversingPlayerNode(node GameTreeNode, s
 	c.slicePool.free(regrets)
 	c.mapPool.free(c.sampledActions)
 	c.sampledActions = oldSampledActions
-	return cfValue
+	return ev
 }
 
 // Sample player action according to strategy, do not update policy.
 // Save selected action so that they are reused if this infoset is hit again.
-func (c *GeneralizedSamplingCFR) handleSampledPlayerNode(node GameTreeNode, sampleProb float32) float32 {
+func (c *GeneralizedSamplingCFR) handleSampledPlayerNode(node GameTreeNode, sampleProb float32) []float32 {
 	policy := c.strategyProfile.GetPolicy(node)
 
 	// Update average strategy for this node.
@@ -119,24 +143,28 @@ func (c *GeneralizedSamplingCFR) handleSampledPlayerNode(node GameTreeNode, samp
 	// Sampling probabilities cancel out in the calculation of counterfactual value,
 	// so we don't include them here.
 	child := node.GetChild(getOrSample(c.sampledActions, node, policy, c.rng))
-	return c.runHelper(child, node.Player(), sampleProb)
+	return c.runHelper(child, sampleProb)
 }
 
-func (c *GeneralizedSamplingCFR) probe(node GameTreeNode, player int) float32 {
-	var ev float32
+// probe returns the utility of each player at a terminal node reached by
+// sampling actions according to the current strategy. The returned slice
+// must be freed by the caller.
+func (c *GeneralizedSamplingCFR) probe(node GameTreeNode) []float32 {
+	var ev []float32
 	switch node.Type() {
 	case TerminalNodeType:
-		ev = float32(node.Utility(player))
+		ev = c.slicePool.alloc(c.nPlayers)
+		getUtilities(node, ev)
 	case ChanceNodeType:
-		child, _ := node.SampleChild()
-		ev = c.probe(child, player)
+		child, _ := SampleChanceNode(c.rng, node)
+		ev = c.probe(child)
 	default:
 		policy := c.strategyProfile.GetPolicy(node)
 		strategy := policy.GetStrategy()
 		x := c.rng.Float32()
 		selected := sampleOne(strategy, x)
 		child := node.GetChild(selected)
-		ev = c.probe(child, player)
+		ev = c.probe(child)
 	}
 
 	node.Close()
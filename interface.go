@@ -3,6 +3,7 @@ package cfr
 import (
 	"encoding"
 	"io"
+	"math/rand"
 )
 
 // NodeType is the type of node in an extensive-form game tree.
@@ -39,9 +40,20 @@ type ChanceNode interface {
 	// Implementations may reuse sampling.SampleChanceNode to sample from the CDF,
 	// (by scanning over GetChildProbability) or implement their own more efficient
 	// sampling.
+	//
+	// Solvers do not call SampleChild directly; see RandChanceNode.
 	SampleChild() (child GameTreeNode, p float64)
 }
 
+// RandChanceNode may optionally be implemented by GameTreeNodes to sample
+// a child of a Chance node using a caller-supplied random number generator.
+//
+// Solvers sample chance nodes with SampleChanceNode, which uses SampleChildRand
+// if it is implemented and otherwise scans over GetChildProbability.
+type RandChanceNode interface {
+	SampleChildRand(rng *rand.Rand) (child GameTreeNode, p float64)
+}
+
 // PlayerNode is a node in which one of the player's acts.
 type PlayerNode interface {
 	// Player returns this current node's acting player.
@@ -79,6 +91,23 @@ type GameTreeNode interface {
 	PlayerNode
 }
 
+// MultiPlayerNode may optionally be implemented by GameTreeNodes
+// to specify the number of players in the game.
+type MultiPlayerNode interface {
+	// NumPlayers returns the number of (non-chance) players in the game.
+	NumPlayers() int
+}
+
+// NumPlayers returns the number of players in the game of the given node.
+// Games that do not implement MultiPlayerNode are assumed to have two players.
+func NumPlayers(node GameTreeNode) int {
+	if mp, ok := node.(MultiPlayerNode); ok {
+		return mp.NumPlayers()
+	}
+
+	return 2
+}
+
 // StrategyProfile maintains a collection of regret-matching policies for each
 // player node in the game tree.
 //
@@ -2,6 +2,7 @@ package policy
 
 import (
 	"encoding/binary"
+	"fmt"
 	"math"
 
 	"github.com/timpalpant/go-cfr/internal/f32"
@@ -20,6 +21,17 @@ type Policy struct {
 
 	regretSum   []float32
 	strategySum []float32
+
+	// Instantaneous regret accumulated in the most recent iteration in which
+	// any regret was added, used as the prediction in predictive regret
+	// matching. With alternating updates a node only receives regret on
+	// every other iteration, so the prediction is kept until it is replaced.
+	lastRegret []float32
+	// Whether regret has been added since the last call to NextStrategy.
+	regretAdded bool
+	// Scratch space for the predicted regrets in NextStrategy, which is
+	// only allocated in predictive mode and is not saved.
+	predictedRegret []float32
 }
 
 // NewPolicy returns a new Policy for a game node with the given number of actions.
@@ -30,6 +42,7 @@ func New(nActions int) *Policy {
 		baseline:              make([]float32, nActions),
 		regretSum:             make([]float32, nActions),
 		strategySum:           make([]float32, nActions),
+		lastRegret:            make([]float32, nActions),
 	}
 }
 
@@ -48,35 +61,95 @@ func (p *Policy) IsEmpty() bool {
 	return true
 }
 
-func (p *Policy) NextStrategy(discountPositiveRegret, discountNegativeRegret, discountstrategySum float32) {
-	if discountstrategySum != 1.0 {
-		f32.ScalUnitary(discountstrategySum, p.strategySum)
+// RegretMinimizer computes the strategy at an information set from its
+// accumulated regrets. It is implemented by cfr.RegretMinimizer.
+type RegretMinimizer interface {
+	UpdateRegrets(iter int, regretSum []float32)
+	Strategy(iter int, regrets, strategy []float32)
+}
+
+// UpdateParams are the parameters used by NextStrategy to compute the next
+// strategy at the end of an iteration.
+type UpdateParams struct {
+	Iter int
+
+	DiscountPositiveRegret float32
+	DiscountNegativeRegret float32
+	DiscountStrategySum    float32
+
+	// If non-zero, the instantaneous regret of the last iteration is added
+	// to the accumulated regret with this weight as a prediction of the
+	// next iteration's regret.
+	PredictionWeight float32
+
+	// RegretMinimizer used to compute the next strategy.
+	// If nil, regret matching is used.
+	RegretMinimizer RegretMinimizer
+}
+
+// NextStrategy discounts the accumulated regrets and strategy sums and
+// computes the next strategy according to the given parameters.
+func (p *Policy) NextStrategy(params UpdateParams) {
+	if params.DiscountStrategySum != 1.0 {
+		f32.ScalUnitary(params.DiscountStrategySum, p.strategySum)
 	}
 
 	f32.AxpyUnitary(p.currentStrategyWeight, p.currentStrategy, p.strategySum)
 
-	if discountPositiveRegret != 1.0 {
+	if params.DiscountPositiveRegret != 1.0 {
 		for i, x := range p.regretSum {
 			if x > 0 {
-				p.regretSum[i] *= discountPositiveRegret
+				p.regretSum[i] *= params.DiscountPositiveRegret
 			}
 		}
 	}
 
-	if discountNegativeRegret != 1.0 {
+	if params.DiscountNegativeRegret != 1.0 {
 		for i, x := range p.regretSum {
 			if x < 0 {
-				p.regretSum[i] *= discountNegativeRegret
+				p.regretSum[i] *= params.DiscountNegativeRegret
 			}
 		}
 	}
 
-	p.regretMatching()
+	rm := params.RegretMinimizer
+	if rm != nil {
+		rm.UpdateRegrets(params.Iter, p.regretSum)
+	}
+
+	regrets := p.regretSum
+	if params.PredictionWeight != 0 {
+		if len(p.predictedRegret) != len(p.regretSum) {
+			p.predictedRegret = make([]float32, len(p.regretSum))
+		}
+
+		regrets = p.predictedRegret
+		copy(regrets, p.regretSum)
+		f32.AxpyUnitary(params.PredictionWeight, p.lastRegret, regrets)
+	}
+
+	if rm != nil {
+		rm.Strategy(params.Iter, regrets, p.currentStrategy)
+	} else {
+		RegretMatching(regrets, p.currentStrategy)
+	}
+
 	p.currentStrategyWeight = 0.0
+	p.regretAdded = false
 }
 
 func (p *Policy) AddRegret(w float32, samplingQ, instantaneousRegrets []float32) {
+	if !p.regretAdded {
+		// Replace the prediction from the last iteration that added regret.
+		for i := range p.lastRegret {
+			p.lastRegret[i] = 0.0
+		}
+
+		p.regretAdded = true
+	}
+
 	f32.AxpyUnitary(w, instantaneousRegrets, p.regretSum)
+	f32.AxpyUnitary(w, instantaneousRegrets, p.lastRegret)
 }
 
 func (p *Policy) AddStrategyWeight(w float32) {
@@ -98,6 +171,10 @@ func (p *Policy) GetAverageStrategy() []float32 {
 	return avgStrat
 }
 
+func (p *Policy) GetRegretSum() []float32 {
+	return p.regretSum
+}
+
 func (p *Policy) GetStrategySum() []float32 {
 	return p.strategySum
 }
@@ -116,23 +193,59 @@ func (p *Policy) NumActions() int {
 	return len(p.regretSum)
 }
 
-func (p *Policy) regretMatching() {
-	copy(p.currentStrategy, p.regretSum)
-	makePositive(p.currentStrategy)
-	total := f32.Sum(p.currentStrategy)
+// RegretMatching sets strategy proportional to the positive part of the given regrets,
+// or to the uniform distribution if no regret is positive.
+func RegretMatching(regrets, strategy []float32) {
+	copy(strategy, regrets)
+	makePositive(strategy)
+	total := f32.Sum(strategy)
 	if total > 0 {
-		f32.ScalUnitary(1.0/total, p.currentStrategy)
+		f32.ScalUnitary(1.0/total, strategy)
 	} else {
-		for i := range p.currentStrategy {
-			p.currentStrategy[i] = 1.0 / float32(len(p.currentStrategy))
+		for i := range strategy {
+			strategy[i] = 1.0 / float32(len(strategy))
 		}
 	}
 }
 
+// The serialized Policy is prefixed with a version byte and a flags byte.
+// Policies serialized before the header was added (which have no lastRegret)
+// are distinguished by their length, which is a multiple of 4.
+const (
+	// currentStrategyWeight, currentStrategy, regretSum, strategySum, baseline.
+	formatV1 = 1
+	// Header, then formatV1 + lastRegret.
+	formatV2 = 2
+
+	headerSize      = 2
+	flagRegretAdded = 1
+)
+
 // UnmarshalBinary implements encoding.BinaryUnmarshaler.
 func (p *Policy) UnmarshalBinary(buf []byte) error {
+	version := byte(formatV1)
+	var flags byte
+	if len(buf)%4 == headerSize {
+		version, flags = buf[0], buf[1]
+		buf = buf[headerSize:]
+	}
+
+	var nArrays int
+	switch version {
+	case formatV1:
+		nArrays = 4
+	case formatV2:
+		nArrays = 5
+	default:
+		return fmt.Errorf("unknown policy format version: %d", version)
+	}
+
 	nFloats := len(buf) / 4
-	nActions := (nFloats - 1) / 4
+	if nFloats == 0 || len(buf)%4 != 0 || (nFloats-1)%nArrays != 0 {
+		return fmt.Errorf("invalid policy of %d bytes for format version %d", len(buf), version)
+	}
+
+	nActions := (nFloats - 1) / nArrays
 
 	p.currentStrategyWeight = decodeF32(buf[:4])
 	buf = buf[4:]
@@ -147,18 +260,32 @@ func (p *Policy) UnmarshalBinary(buf []byte) error {
 	buf = buf[4*nActions:]
 
 	p.baseline = decodeF32s(buf[:4*nActions])
+	buf = buf[4*nActions:]
+
+	if version >= formatV2 {
+		p.lastRegret = decodeF32s(buf[:4*nActions])
+	} else {
+		p.lastRegret = make([]float32, nActions)
+	}
 
+	p.regretAdded = flags&flagRegretAdded != 0
 	return nil
 }
 
 // MarshalBinary implements encoding.BinaryMarshaler.
 func (p *Policy) MarshalBinary() ([]byte, error) {
 	nActions := len(p.regretSum)
-	nBytes := 4 * (4*nActions + 1)
+	nBytes := headerSize + 4*(5*nActions+1)
 	result := make([]byte, nBytes)
 
-	putF32(result, p.currentStrategyWeight)
-	buf := result[4:]
+	result[0] = formatV2
+	if p.regretAdded {
+		result[1] |= flagRegretAdded
+	}
+
+	buf := result[headerSize:]
+	putF32(buf, p.currentStrategyWeight)
+	buf = buf[4:]
 
 	putF32s(buf, p.currentStrategy)
 	buf = buf[4*nActions:]
@@ -170,6 +297,9 @@ func (p *Policy) MarshalBinary() ([]byte, error) {
 	buf = buf[4*nActions:]
 
 	putF32s(buf, p.baseline)
+	buf = buf[4*nActions:]
+
+	putF32s(buf, p.lastRegret)
 
 	return result, nil
 }
@@ -0,0 +1,114 @@
+package policy
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPolicy_MarshalBinary(t *testing.T) {
+	p := New(3)
+	p.AddRegret(1.0, nil, []float32{1, -2, 3})
+	p.AddStrategyWeight(0.5)
+	p.UpdateBaseline(1.0, 1, 2.0)
+
+	buf, err := p.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var reloaded Policy
+	if err := reloaded.UnmarshalBinary(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(&reloaded, p) {
+		t.Errorf("expected %+v, got %+v", p, &reloaded)
+	}
+}
+
+func TestPolicy_UnmarshalBinary_V1(t *testing.T) {
+	// A 2-action policy serialized before lastRegret was added:
+	// currentStrategyWeight, currentStrategy, regretSum, strategySum, baseline.
+	floats := []float32{0.5, 0.25, 0.75, 1, -1, 2, 3, 0.1, 0.2}
+	buf := make([]byte, 4*len(floats))
+	putF32s(buf, floats)
+
+	var p Policy
+	if err := p.UnmarshalBinary(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &Policy{
+		currentStrategyWeight: 0.5,
+		currentStrategy:       []float32{0.25, 0.75},
+		regretSum:             []float32{1, -1},
+		strategySum:           []float32{2, 3},
+		baseline:              []float32{0.1, 0.2},
+		lastRegret:            []float32{0, 0},
+	}
+
+	if !reflect.DeepEqual(&p, expected) {
+		t.Errorf("expected %+v, got %+v", expected, &p)
+	}
+
+	// It is rewritten in the current format.
+	buf, err := p.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var reloaded Policy
+	if err := reloaded.UnmarshalBinary(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(&reloaded, expected) {
+		t.Errorf("expected %+v, got %+v", expected, &reloaded)
+	}
+}
+
+func TestPolicy_UnmarshalBinary_Invalid(t *testing.T) {
+	p := New(2)
+	buf, err := p.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var reloaded Policy
+	if err := reloaded.UnmarshalBinary(buf[:len(buf)-4]); err == nil {
+		t.Error("expected error decoding truncated policy")
+	}
+
+	buf[0] = 99
+	if err := reloaded.UnmarshalBinary(buf); err == nil {
+		t.Error("expected error decoding unknown version")
+	}
+}
+
+func TestPolicy_PredictionKeptWithoutRegret(t *testing.T) {
+	params := UpdateParams{PredictionWeight: 1.0}
+	p := New(2)
+	p.AddRegret(1.0, nil, []float32{2, -2})
+	p.NextStrategy(params)
+	p.AddRegret(1.0, nil, []float32{-1.5, 1.5})
+	p.NextStrategy(params)
+
+	// The regret sum [0.5, -0.5] plus the prediction [-1.5, 1.5].
+	if !reflect.DeepEqual(p.GetStrategy(), []float32{0, 1}) {
+		t.Errorf("expected strategy [0 1], got %v", p.GetStrategy())
+	}
+
+	// No regret is added on the other player's iteration,
+	// so the last prediction is still used.
+	p.NextStrategy(params)
+	if !reflect.DeepEqual(p.GetStrategy(), []float32{0, 1}) {
+		t.Errorf("expected strategy [0 1], got %v", p.GetStrategy())
+	}
+
+	// The next regret replaces the prediction.
+	p.AddRegret(1.0, nil, []float32{1, -1})
+	p.NextStrategy(params)
+	if !reflect.DeepEqual(p.lastRegret, []float32{1, -1}) {
+		t.Errorf("expected prediction [1 -1], got %v", p.lastRegret)
+	}
+}
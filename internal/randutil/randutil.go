@@ -0,0 +1,96 @@
+// Package randutil saves and restores the state of random number generators,
+// so that a run that is resumed from a checkpoint draws the same random
+// numbers as one that was not interrupted.
+//
+// The state of a math/rand source cannot be saved directly. Instead, a Source
+// counts the number of values drawn since it was seeded. Saving its State has
+// no effect on the numbers it generates, and restoring a State reseeds a new
+// source and replays the same number of draws.
+package randutil
+
+import (
+	"encoding/gob"
+	"io"
+	"math/rand"
+)
+
+// State is the state of a Source: the seed it was last seeded with,
+// and the number of values that have been drawn from it since.
+type State struct {
+	Seed  int64
+	Draws uint64
+}
+
+// Source implements rand.Source64, and counts the values drawn from it
+// so that its State can be saved. Like the sources returned by
+// rand.NewSource, it is not safe for concurrent use.
+type Source struct {
+	src   rand.Source64
+	state State
+}
+
+// NewSource returns a new Source seeded with the given value.
+func NewSource(seed int64) *Source {
+	return &Source{
+		src:   rand.NewSource(seed).(rand.Source64),
+		state: State{Seed: seed},
+	}
+}
+
+// Restore returns a new Source in the given State, which will generate the
+// same values as the Source from which the State was saved.
+// It takes time proportional to the number of values already drawn.
+func Restore(state State) *Source {
+	s := NewSource(state.Seed)
+	for i := uint64(0); i < state.Draws; i++ {
+		s.src.Int63()
+	}
+
+	s.state.Draws = state.Draws
+	return s
+}
+
+// Decode decodes a State that was encoded with enc.Encode(src.State()),
+// and returns a Source restored from it. Data saved before the State of the
+// random number generator was recorded does not contain one, and the Source
+// is then seeded from the global source.
+func Decode(dec *gob.Decoder) (*Source, error) {
+	var state State
+	if err := dec.Decode(&state); err == io.EOF {
+		return NewSource(rand.Int63()), nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return Restore(state), nil
+}
+
+// Int63 implements rand.Source.
+func (s *Source) Int63() int64 {
+	s.state.Draws++
+	return s.src.Int63()
+}
+
+// Uint64 implements rand.Source64.
+func (s *Source) Uint64() uint64 {
+	s.state.Draws++
+	return s.src.Uint64()
+}
+
+// Seed implements rand.Source.
+func (s *Source) Seed(seed int64) {
+	s.src.Seed(seed)
+	s.state = State{Seed: seed}
+}
+
+// State returns the current state of the Source. It does not change
+// the values that are generated.
+func (s *Source) State() State {
+	return s.state
+}
+
+// SetState restores the Source to the given State in place, so that
+// a rand.Rand (and anything else sharing it) continues from that State.
+func (s *Source) SetState(state State) {
+	*s = *Restore(state)
+}
@@ -0,0 +1,64 @@
+package randutil
+
+import (
+	"bytes"
+	"encoding/gob"
+	"math/rand"
+	"testing"
+)
+
+func TestSource_RestoreState(t *testing.T) {
+	src := NewSource(123)
+	rng := rand.New(src)
+	expected := rand.New(rand.NewSource(123))
+	for i := 0; i < 100; i++ {
+		if x, y := rng.Float64(), expected.Float64(); x != y {
+			t.Fatalf("draw %d: expected %v, got %v", i, y, x)
+		}
+	}
+
+	restored := rand.New(Restore(src.State()))
+	for i := 0; i < 100; i++ {
+		x, y, z := rng.Intn(1000), restored.Intn(1000), expected.Intn(1000)
+		if x != z || y != z {
+			t.Fatalf("draw %d: expected %v, got %v (original) and %v (restored)", i, z, x, y)
+		}
+	}
+}
+
+func TestSource_SetState(t *testing.T) {
+	src := NewSource(123)
+	rng := rand.New(src)
+	state := src.State()
+	x := rng.Int63()
+	rng.Int63()
+
+	src.SetState(state)
+	if y := rng.Int63(); y != x {
+		t.Errorf("expected %v after SetState, got %v", x, y)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	src := NewSource(123)
+	rand.New(src).Uint64()
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(src.State()); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := Decode(gob.NewDecoder(&buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if restored.State() != src.State() {
+		t.Errorf("expected state %+v, got %+v", src.State(), restored.State())
+	}
+
+	// Data without a State is seeded randomly.
+	if _, err := Decode(gob.NewDecoder(&buf)); err != nil {
+		t.Errorf("expected no error at EOF, got %v", err)
+	}
+}
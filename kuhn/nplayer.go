@@ -0,0 +1,250 @@
+package kuhn
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/timpalpant/go-cfr"
+)
+
+// NPlayerPokerNode implements cfr.GameTreeNode for the N-player
+// generalization of Kuhn Poker.
+//
+// Each player antes one chip and is dealt a single card from a deck of
+// N+1 cards. Players act in turn and may check or bet one chip. Once a
+// player has bet, each of the other players acts exactly once more and
+// may either call (Bet) or fold (Check). The highest card among the
+// players that did not fold wins the pot.
+//
+// With two players the game tree (and InfoSet keys) are identical to
+// those of PokerNode.
+type NPlayerPokerNode struct {
+	parent        *NPlayerPokerNode
+	nPlayers      int
+	player        int
+	children      []NPlayerPokerNode
+	probabilities []float64
+	history       string
+
+	// Private cards held by each player, in order of dealing.
+	cards []Card
+}
+
+// NewNPlayerGame returns the root node of N-player Kuhn poker.
+func NewNPlayerGame(nPlayers int) *NPlayerPokerNode {
+	if nPlayers < 2 {
+		panic(fmt.Errorf("kuhn poker requires at least 2 players, got %d", nPlayers))
+	}
+
+	return &NPlayerPokerNode{nPlayers: nPlayers, player: chance}
+}
+
+// String implements fmt.Stringer.
+func (k NPlayerPokerNode) String() string {
+	return fmt.Sprintf("Player %v's turn. History: %5s [Cards: %v]",
+		k.player, k.history, k.cards)
+}
+
+// NumPlayers implements cfr.MultiPlayerNode.
+func (k *NPlayerPokerNode) NumPlayers() int {
+	return k.nPlayers
+}
+
+// Close implements cfr.GameTreeNode.
+func (k *NPlayerPokerNode) Close() {
+	k.children = nil
+	k.probabilities = nil
+}
+
+// NumChildren implements cfr.GameTreeNode.
+func (k *NPlayerPokerNode) NumChildren() int {
+	if k.children == nil {
+		k.buildChildren()
+	}
+
+	return len(k.children)
+}
+
+// GetChild implements cfr.GameTreeNode.
+func (k *NPlayerPokerNode) GetChild(i int) cfr.GameTreeNode {
+	if k.children == nil {
+		k.buildChildren()
+	}
+
+	return &k.children[i]
+}
+
+// Parent implements cfr.GameTreeNode.
+func (k *NPlayerPokerNode) Parent() cfr.GameTreeNode {
+	if k.parent == nil {
+		// Avoid returning a non-nil interface holding a nil pointer.
+		return nil
+	}
+
+	return k.parent
+}
+
+// GetChildProbability implements cfr.GameTreeNode.
+func (k *NPlayerPokerNode) GetChildProbability(i int) float64 {
+	if k.children == nil {
+		k.buildChildren()
+	}
+
+	return k.probabilities[i]
+}
+
+// SampleChild implements cfr.GameTreeNode.
+func (k *NPlayerPokerNode) SampleChild() (cfr.GameTreeNode, float64) {
+	i := rand.Intn(k.NumChildren())
+	return k.GetChild(i), k.GetChildProbability(i)
+}
+
+// SampleChildRand implements cfr.RandChanceNode.
+func (k *NPlayerPokerNode) SampleChildRand(rng *rand.Rand) (cfr.GameTreeNode, float64) {
+	i := rng.Intn(k.NumChildren())
+	return k.GetChild(i), k.GetChildProbability(i)
+}
+
+// Type implements cfr.GameTreeNode.
+func (k *NPlayerPokerNode) Type() cfr.NodeType {
+	if k.IsTerminal() {
+		return cfr.TerminalNodeType
+	} else if k.player == chance {
+		return cfr.ChanceNodeType
+	}
+
+	return cfr.PlayerNodeType
+}
+
+func (k *NPlayerPokerNode) IsTerminal() bool {
+	if len(k.history) < k.nPlayers {
+		return false
+	}
+
+	actions := k.actions()
+	firstBet := strings.IndexByte(actions, Bet)
+	if firstBet == -1 {
+		return len(actions) == k.nPlayers
+	}
+
+	// Every other player has responded to the first bet.
+	return len(actions) == firstBet+k.nPlayers
+}
+
+// Player implements cfr.GameTreeNode.
+func (k *NPlayerPokerNode) Player() int {
+	return k.player
+}
+
+// Utility implements cfr.GameTreeNode.
+func (k *NPlayerPokerNode) Utility(player int) float64 {
+	actions := k.actions()
+	hasBet := strings.IndexByte(actions, Bet) != -1
+	pot := 0
+	winner := -1
+	for p := 0; p < k.nPlayers; p++ {
+		contribution := k.contribution(p)
+		pot += contribution
+		if hasBet && contribution == 1 {
+			continue // Folded.
+		}
+
+		if winner == -1 || k.cards[p] > k.cards[winner] {
+			winner = p
+		}
+	}
+
+	if player == winner {
+		return float64(pot - k.contribution(player))
+	}
+
+	return -float64(k.contribution(player))
+}
+
+// contribution returns the number of chips put in the pot by the given player.
+func (k *NPlayerPokerNode) contribution(player int) int {
+	actions := k.actions()
+	result := 1 // Ante.
+	for i := player; i < len(actions); i += k.nPlayers {
+		if actions[i] == Bet {
+			result++
+		}
+	}
+
+	return result
+}
+
+// actions returns the betting history (excluding chance deals).
+func (k *NPlayerPokerNode) actions() string {
+	return k.history[k.nPlayers:]
+}
+
+// InfoSet implements cfr.GameTreeNode.
+func (k *NPlayerPokerNode) InfoSet(player int) cfr.InfoSet {
+	return &pokerInfoSet{
+		history: k.history,
+		card:    k.cards[player].String(),
+	}
+}
+
+func (k *NPlayerPokerNode) InfoSetKey(player int) []byte {
+	return k.InfoSet(player).Key()
+}
+
+func (k *NPlayerPokerNode) buildChildren() {
+	if k.IsTerminal() {
+		return
+	}
+
+	if len(k.history) < k.nPlayers {
+		k.children = k.buildDeals()
+		k.probabilities = uniformDist(len(k.children))
+	} else {
+		k.children = k.buildActions()
+	}
+}
+
+func (k *NPlayerPokerNode) buildDeals() []NPlayerPokerNode {
+	var result []NPlayerPokerNode
+	for card := Card(0); int(card) <= k.nPlayers; card++ {
+		if k.isDealt(card) {
+			continue // Players can't be dealt the same card.
+		}
+
+		child := *k
+		child.parent = k
+		child.cards = append(append([]Card(nil), k.cards...), card)
+		child.history += string([]byte{Random})
+		if len(child.history) == k.nPlayers {
+			child.player = 0
+		}
+
+		result = append(result, child)
+	}
+
+	return result
+}
+
+func (k *NPlayerPokerNode) isDealt(card Card) bool {
+	for _, c := range k.cards {
+		if c == card {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (k *NPlayerPokerNode) buildActions() []NPlayerPokerNode {
+	var result []NPlayerPokerNode
+	for _, choice := range []byte{Check, Bet} {
+		child := *k
+		child.parent = k
+		child.player = (k.player + 1) % k.nPlayers
+		child.history += string([]byte{choice})
+		result = append(result, child)
+	}
+
+	return result
+}
@@ -0,0 +1,130 @@
+package kuhn
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/timpalpant/go-cfr"
+	"github.com/timpalpant/go-cfr/exploitability"
+	"github.com/timpalpant/go-cfr/sampling"
+	"github.com/timpalpant/go-cfr/tree"
+)
+
+func TestNPlayerPoker_TwoPlayerGameTree(t *testing.T) {
+	root := NewNPlayerGame(2)
+
+	nNodes := tree.CountNodes(root)
+	if nNodes != 58 {
+		t.Errorf("expected %d nodes, got %d", 58, nNodes)
+	}
+
+	nTerminal := tree.CountTerminalNodes(root)
+	if nTerminal != 30 {
+		t.Errorf("expected %d terminal nodes, got %d", 30, nTerminal)
+	}
+
+	// Terminal utilities must match those of two-player Kuhn poker.
+	expected := make(map[string][]float64)
+	tree.Visit(NewGame(), func(node cfr.GameTreeNode) {
+		if node.Type() == cfr.TerminalNodeType {
+			key := string(node.InfoSetKey(0)) + string(node.InfoSetKey(1))
+			expected[key] = []float64{node.Utility(0), node.Utility(1)}
+		}
+	})
+
+	tree.Visit(root, func(node cfr.GameTreeNode) {
+		if node.Type() == cfr.TerminalNodeType {
+			key := string(node.InfoSetKey(0)) + string(node.InfoSetKey(1))
+			u := []float64{node.Utility(0), node.Utility(1)}
+			if !reflect.DeepEqual(u, expected[key]) {
+				t.Errorf("%v: expected utilities %v, got %v", node, expected[key], u)
+			}
+		}
+	})
+}
+
+func TestNPlayerPoker_ThreePlayerGameTree(t *testing.T) {
+	root := NewNPlayerGame(3)
+	nInfoSets := tree.CountInfoSets(root)
+	if nInfoSets != 48 {
+		t.Errorf("expected %d infosets, got %d", 48, nInfoSets)
+	}
+
+	tree.Visit(root, func(node cfr.GameTreeNode) {
+		if node.Type() != cfr.TerminalNodeType {
+			return
+		}
+
+		var total float64
+		for player := 0; player < 3; player++ {
+			total += node.Utility(player)
+		}
+
+		if total != 0 {
+			t.Errorf("%v: utilities do not sum to zero: %v", node, total)
+		}
+	})
+}
+
+func TestNPlayerPoker_VanillaCFR(t *testing.T) {
+	root := NewNPlayerGame(3)
+	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
+	opt := cfr.New(policy)
+	result := testNPlayerCFR(t, root, opt, policy, 1000)
+	if result.NashConv > 0.05 {
+		t.Errorf("expected NashConv < 0.05, got %v", result.NashConv)
+	}
+}
+
+func TestNPlayerPoker_ChanceSamplingCFR(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
+	root := NewNPlayerGame(3)
+	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
+	opt := cfr.NewChanceSampling(rng, policy)
+	testNPlayerCFR(t, root, opt, policy, 100000)
+}
+
+func TestNPlayerPoker_ExternalSamplingCFR(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
+	root := NewNPlayerGame(3)
+	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
+	opt := cfr.NewMCCFR(rng, policy, sampling.NewExternalSampler())
+	testNPlayerCFR(t, root, opt, policy, 100000)
+}
+
+func TestNPlayerPoker_OutcomeSamplingCFR(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
+	root := NewNPlayerGame(3)
+	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
+	opt := cfr.NewMCCFR(rng, policy, sampling.NewOutcomeSampler(rng, 0.1))
+	testNPlayerCFR(t, root, opt, policy, 100000)
+}
+
+func TestNPlayerPoker_VRMCCFR(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
+	root := NewNPlayerGame(3)
+	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
+	rs1 := sampling.NewRobustSampler(rng, 2)
+	rs2 := sampling.NewRobustSampler(rng, 1)
+	opt := cfr.NewGeneralSumVRMCCFR(rng, policy, rs1, rs2)
+	testNPlayerCFR(t, root, opt, policy, 100000)
+}
+
+func testNPlayerCFR(t *testing.T, root cfr.GameTreeNode, opt cfr.Solver, policy cfr.StrategyProfile, nIter int) exploitability.Result {
+	initial := exploitability.Compute(root, policy)
+	for i := 1; i <= nIter; i++ {
+		opt.Run(root)
+		policy.Update()
+	}
+
+	result := exploitability.Compute(root, policy)
+	t.Logf("NashConv: %.4f (initially %.4f), best response values: %v, values: %v",
+		result.NashConv, initial.NashConv, result.BestResponseValues, result.Values)
+	if !(result.NashConv < initial.NashConv) || math.IsNaN(result.NashConv) {
+		t.Errorf("NashConv did not decrease: %v -> %v", initial.NashConv, result.NashConv)
+	}
+
+	return result
+}
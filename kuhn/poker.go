@@ -6,6 +6,7 @@ import (
 	"encoding/gob"
 	"fmt"
 	"math/rand"
+	"strconv"
 	"strings"
 
 	"github.com/timpalpant/go-cfr"
@@ -40,7 +41,11 @@ var cardStr = [...]string{
 }
 
 func (c Card) String() string {
-	return cardStr[c]
+	if int(c) < len(cardStr) {
+		return cardStr[c]
+	}
+
+	return strconv.Itoa(int(c))
 }
 
 // PokerNode implements cfr.GameTreeNode for Kuhn Poker.
@@ -91,6 +96,11 @@ func (k *PokerNode) GetChild(i int) cfr.GameTreeNode {
 
 // Parent implements cfr.GameTreeNode.
 func (k *PokerNode) Parent() cfr.GameTreeNode {
+	if k.parent == nil {
+		// Avoid returning a non-nil interface holding a nil pointer.
+		return nil
+	}
+
 	return k.parent
 }
 
@@ -109,6 +119,12 @@ func (k *PokerNode) SampleChild() (cfr.GameTreeNode, float64) {
 	return k.GetChild(i), k.GetChildProbability(i)
 }
 
+// SampleChildRand implements cfr.RandChanceNode.
+func (k *PokerNode) SampleChildRand(rng *rand.Rand) (cfr.GameTreeNode, float64) {
+	i := rng.Intn(k.NumChildren())
+	return k.GetChild(i), k.GetChildProbability(i)
+}
+
 // Type implements cfr.GameTreeNode.
 func (k *PokerNode) Type() cfr.NodeType {
 	if k.IsTerminal() {
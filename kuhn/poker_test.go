@@ -3,11 +3,14 @@ package kuhn
 import (
 	"bytes"
 	"encoding/gob"
+	"math/rand"
 	"reflect"
 	"testing"
 
 	"github.com/timpalpant/go-cfr"
 	"github.com/timpalpant/go-cfr/deepcfr"
+	"github.com/timpalpant/go-cfr/deepcfr/mlp"
+	"github.com/timpalpant/go-cfr/exploitability"
 	"github.com/timpalpant/go-cfr/mcts"
 	"github.com/timpalpant/go-cfr/sampling"
 	"github.com/timpalpant/go-cfr/tree"
@@ -42,79 +45,243 @@ func TestPoker_VanillaCFR(t *testing.T) {
 }
 
 func TestPoker_ChanceSamplingCFR(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
 	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
-	opt := cfr.NewChanceSampling(policy)
+	opt := cfr.NewChanceSampling(rng, policy)
 	testCFR(t, opt, policy, 200000)
 }
 
 func TestPoker_ExternalSamplingCFR(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
 	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
 	es := sampling.NewExternalSampler()
-	opt := cfr.NewMCCFR(policy, es)
+	opt := cfr.NewMCCFR(rng, policy, es)
 	testCFR(t, opt, policy, 200000)
 }
 
+func TestPoker_ParallelExternalSamplingCFR(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
+	policy := cfr.NewConcurrentPolicyTable(cfr.DiscountParams{})
+	newSampler := func(rng *rand.Rand) cfr.Sampler { return sampling.NewExternalSampler() }
+	opt := cfr.NewParallelMCCFR(rng, policy, newSampler, 4)
+	newGame := func() cfr.GameTreeNode { return NewGame() }
+	nIter := 20000
+	var expectedValue float32
+	for i := 1; i <= nIter; i++ {
+		expectedValue += opt.Run(newGame)
+		if i%(nIter/10) == 0 {
+			t.Logf("[iter=%d] Expected game value: %.4f", i, expectedValue/float32(i))
+		}
+
+		policy.Update()
+	}
+
+	result := exploitability.Compute(NewGame(), policy)
+	t.Logf("Exploitability: %.4f, NashConv: %.4f, best response values: %v",
+		result.Exploitability, result.NashConv, result.BestResponseValues)
+	if result.Exploitability > 0.02 {
+		t.Errorf("expected exploitability < 0.02, got %v", result.Exploitability)
+	}
+}
+
 func TestPoker_OutcomeSamplingCFR(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
 	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
-	os := sampling.NewOutcomeSampler(0.3)
-	opt := cfr.NewMCCFR(policy, os)
+	os := sampling.NewOutcomeSampler(rng, 0.3)
+	opt := cfr.NewMCCFR(rng, policy, os)
 	testCFR(t, opt, policy, 200000)
 }
 
 func TestPoker_OnlineOutcomeSamplingCFR(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
 	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
-	os := sampling.NewOutcomeSampler(0.3)
-	opt := cfr.NewOnlineOutcomeSamplingCFR(policy, os)
+	os := sampling.NewOutcomeSampler(rng, 0.3)
+	opt := cfr.NewOnlineOutcomeSamplingCFR(rng, policy, os)
 	testCFR(t, opt, policy, 200000)
 }
 
 func TestPoker_VRMCCFR(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
 	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
-	rs1 := sampling.NewRobustSampler(2)
-	rs2 := sampling.NewRobustSampler(1)
-	opt := cfr.NewVRMCCFR(policy, rs1, rs2)
+	rs1 := sampling.NewRobustSampler(rng, 2)
+	rs2 := sampling.NewRobustSampler(rng, 1)
+	opt := cfr.NewVRMCCFR(rng, policy, rs1, rs2)
 	testCFR(t, opt, policy, 200000)
 }
 
 func TestPoker_AverageStrategySamplingCFR(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
 	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
 	params := sampling.AverageStrategyParams{
 		Epsilon: 0.05,
 		Beta:    1000000,
 		Tau:     1000,
 	}
-	as := sampling.NewAverageStrategySampler(params)
-	opt := cfr.NewMCCFR(policy, as)
+	as := sampling.NewAverageStrategySampler(rng, params)
+	opt := cfr.NewMCCFR(rng, policy, as)
 	testCFR(t, opt, policy, 200000)
 }
 
 func TestPoker_RobustSamplingCFR(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
 	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
-	rs := sampling.NewRobustSampler(1)
-	opt := cfr.NewGeneralizedSampling(policy, rs)
+	rs := sampling.NewRobustSampler(rng, 1)
+	opt := cfr.NewGeneralizedSampling(rng, policy, rs)
 	testCFR(t, opt, policy, 200000)
 }
 
+func TestPoker_Reproducible(t *testing.T) {
+	train := func(seed int64) *cfr.PolicyTable {
+		rng := rand.New(rand.NewSource(seed))
+		policy := cfr.NewPolicyTable(cfr.DiscountParams{})
+		rs1 := sampling.NewRobustSampler(rng, 2)
+		rs2 := sampling.NewOutcomeSampler(rng, 0.1)
+		opt := cfr.NewVRMCCFR(rng, policy, rs1, rs2)
+		for i := 0; i < 1000; i++ {
+			opt.Run(NewGame())
+			policy.Update()
+		}
+
+		return policy
+	}
+
+	p1, p2 := train(42), train(42)
+	tree.Visit(NewGame(), func(node cfr.GameTreeNode) {
+		if node.Type() != cfr.PlayerNodeType {
+			return
+		}
+
+		s1 := p1.GetPolicy(node).GetAverageStrategy()
+		s2 := p2.GetPolicy(node).GetAverageStrategy()
+		if !reflect.DeepEqual(s1, s2) {
+			t.Errorf("%v: strategies differ for the same seed: %v != %v", node, s1, s2)
+		}
+	})
+}
+
+// scanOnly hides the cfr.RandChanceNode implementation of a node so that
+// cfr.SampleChanceNode falls back to scanning over GetChildProbability.
+type scanOnly struct {
+	cfr.GameTreeNode
+}
+
+func TestPoker_SampleChanceNode(t *testing.T) {
+	root := NewGame()
+	n := root.NumChildren()
+	sample := func(node cfr.GameTreeNode, seed int64) []string {
+		rng := rand.New(rand.NewSource(seed))
+		var result []string
+		for i := 0; i < 1000; i++ {
+			child, p := cfr.SampleChanceNode(rng, node)
+			if p != 1.0/float64(n) {
+				t.Errorf("expected p = %v, got %v", 1.0/float64(n), p)
+			}
+
+			result = append(result, child.(*PokerNode).String())
+		}
+
+		return result
+	}
+
+	for _, node := range []cfr.GameTreeNode{root, scanOnly{root}} {
+		s1, s2 := sample(node, 42), sample(node, 42)
+		if !reflect.DeepEqual(s1, s2) {
+			t.Errorf("%T: samples differ for the same seed", node)
+		}
+
+		counts := make(map[string]int)
+		for _, s := range s1 {
+			counts[s]++
+		}
+
+		if len(counts) != n {
+			t.Errorf("%T: expected %d distinct deals, got %d", node, n, len(counts))
+		}
+
+		for deal, count := range counts {
+			if count < 500/n || count > 1500/n {
+				t.Errorf("%T: deal %v sampled %d/1000 times", node, deal, count)
+			}
+		}
+	}
+}
+
 func TestPoker_MultiOutcomeSamplingCFR(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
 	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
-	mos := sampling.NewMultiOutcomeSampler(1, 0.1)
-	opt := cfr.NewGeneralizedSampling(policy, mos)
+	mos := sampling.NewMultiOutcomeSampler(rng, 1, 0.1)
+	opt := cfr.NewGeneralizedSampling(rng, policy, mos)
 	testCFR(t, opt, policy, 200000)
 }
 
 func TestPoker_CFRPlus(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
 	plus := cfr.DiscountParams{UseRegretMatchingPlus: true}
 	policy := cfr.NewPolicyTable(plus)
 	es := sampling.NewExternalSampler()
-	opt := cfr.NewGeneralizedSampling(policy, es)
+	opt := cfr.NewGeneralizedSampling(rng, policy, es)
 	testCFR(t, opt, policy, 200000)
 }
 
+func TestPoker_PredictiveCFRPlus(t *testing.T) {
+	pcfrPlus := cfr.DiscountParams{UseRegretMatchingPlus: true, Predictive: true}
+	policy := cfr.NewPolicyTable(pcfrPlus)
+	opt := cfr.New(policy)
+	root := runCFR(t, opt, policy, 10000)
+	result := exploitability.Compute(root, policy)
+	t.Logf("exploitability: %.4f", result.Exploitability)
+	if result.Exploitability > 0.001 {
+		t.Errorf("expected exploitability < 0.001, got %v", result.Exploitability)
+	}
+}
+
+func TestPoker_PredictiveCFRPlus_ExternalSampling(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
+	pcfrPlus := cfr.DiscountParams{UseRegretMatchingPlus: true, Predictive: true}
+	policy := cfr.NewPolicyTable(pcfrPlus)
+	opt := cfr.NewMCCFR(rng, policy, sampling.NewExternalSampler())
+	root := runCFR(t, opt, policy, 100000)
+	result := exploitability.Compute(root, policy)
+	t.Logf("exploitability: %.4f", result.Exploitability)
+	if result.Exploitability > 0.01 {
+		t.Errorf("expected exploitability < 0.01, got %v", result.Exploitability)
+	}
+}
+
+func TestPoker_RegretMinimizers(t *testing.T) {
+	minimizers := map[string]struct {
+		rm                cfr.RegretMinimizer
+		maxExploitability float64
+	}{
+		"RM":    {cfr.RegretMatching{}, 0.005},
+		"RM+":   {cfr.RegretMatching{Plus: true}, 0.005},
+		"DRM":   {cfr.DiscountedRegretMatching{Alpha: 1.5, Beta: 0.0}, 0.005},
+		"Hedge": {cfr.Hedge{LearningRate: 1.0, Decay: 0.5}, 0.01},
+		// The default learning rate is used if LearningRate is not set.
+		"Hedge (default)": {cfr.Hedge{Decay: 0.5}, 0.01},
+	}
+
+	for name, tc := range minimizers {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			policy := cfr.NewPolicyTableWithRegretMinimizer(cfr.DiscountParams{}, tc.rm)
+			opt := cfr.New(policy)
+			root := runCFR(t, opt, policy, 10000)
+			result := exploitability.Compute(root, policy)
+			t.Logf("exploitability: %.4f", result.Exploitability)
+			if result.Exploitability > tc.maxExploitability {
+				t.Errorf("expected exploitability < %v, got %v", tc.maxExploitability, result.Exploitability)
+			}
+		})
+	}
+}
+
 func TestPoker_LinearCFR(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
 	linear := cfr.DiscountParams{LinearWeighting: true}
 	policy := cfr.NewPolicyTable(linear)
 	es := sampling.NewExternalSampler()
-	opt := cfr.NewGeneralizedSampling(policy, es)
+	opt := cfr.NewGeneralizedSampling(rng, policy, es)
 	testCFR(t, opt, policy, 200000)
 }
 
@@ -141,46 +308,46 @@ func BenchmarkPoker_VanillaCFR(b *testing.B) {
 }
 
 func BenchmarkPoker_ChanceSamplingCFR(b *testing.B) {
+	rng := rand.New(rand.NewSource(123))
 	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
-	opt := cfr.NewChanceSampling(policy)
+	opt := cfr.NewChanceSampling(rng, policy)
 	b.ResetTimer()
 	runCFR(b, opt, policy, b.N)
 }
 
 func BenchmarkPoker_ExternalSamplingCFR(b *testing.B) {
+	rng := rand.New(rand.NewSource(123))
 	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
 	es := sampling.NewExternalSampler()
-	opt := cfr.NewGeneralizedSampling(policy, es)
+	opt := cfr.NewGeneralizedSampling(rng, policy, es)
 	b.ResetTimer()
 	runCFR(b, opt, policy, b.N)
 }
 
 func BenchmarkPoker_AverageStrategySamplingCFR(b *testing.B) {
+	rng := rand.New(rand.NewSource(123))
 	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
 	params := sampling.AverageStrategyParams{
 		Epsilon: 0.05,
 		Beta:    1000000,
 		Tau:     1000,
 	}
-	as := sampling.NewAverageStrategySampler(params)
-	opt := cfr.NewGeneralizedSampling(policy, as)
+	as := sampling.NewAverageStrategySampler(rng, params)
+	opt := cfr.NewGeneralizedSampling(rng, policy, as)
 	b.ResetTimer()
 	runCFR(b, opt, policy, b.N)
 }
 
 func BenchmarkPoker_OutcomeSamplingCFR(b *testing.B) {
+	rng := rand.New(rand.NewSource(123))
 	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
-	os := sampling.NewOutcomeSampler(0.05)
-	opt := cfr.NewGeneralizedSampling(policy, os)
+	os := sampling.NewOutcomeSampler(rng, 0.05)
+	opt := cfr.NewGeneralizedSampling(rng, policy, os)
 	b.ResetTimer()
 	runCFR(b, opt, policy, b.N)
 }
 
-type cfrImpl interface {
-	Run(cfr.GameTreeNode) float32
-}
-
-func testCFR(t *testing.T, opt cfrImpl, policy cfr.StrategyProfile, nIter int) {
+func testCFR(t *testing.T, opt cfr.Solver, policy cfr.StrategyProfile, nIter int) {
 	root := runCFR(t, opt, policy, nIter)
 	seen := make(map[string]struct{})
 	tree.Visit(root, func(node cfr.GameTreeNode) {
@@ -189,7 +356,7 @@ func testCFR(t *testing.T, opt cfrImpl, policy cfr.StrategyProfile, nIter int) {
 		}
 
 		key := node.InfoSet(node.Player()).Key()
-		if _, ok := seen[key]; ok {
+		if _, ok := seen[string(key)]; ok {
 			return
 		}
 
@@ -198,15 +365,22 @@ func testCFR(t *testing.T, opt cfrImpl, policy cfr.StrategyProfile, nIter int) {
 			t.Logf("%6s: check=%.2f bet=%.2f", node, actionProbs[0], actionProbs[1])
 		}
 
-		seen[key] = struct{}{}
+		seen[string(key)] = struct{}{}
 	})
+
+	result := exploitability.Compute(root, policy)
+	t.Logf("Exploitability: %.4f, NashConv: %.4f, best response values: %v",
+		result.Exploitability, result.NashConv, result.BestResponseValues)
+	if result.Exploitability > 0.01 {
+		t.Errorf("expected exploitability < 0.01, got %v", result.Exploitability)
+	}
 }
 
 type logger interface {
 	Logf(string, ...interface{})
 }
 
-func runCFR(log logger, opt cfrImpl, policy cfr.StrategyProfile, nIter int) cfr.GameTreeNode {
+func runCFR(log logger, opt cfr.Solver, policy cfr.StrategyProfile, nIter int) cfr.GameTreeNode {
 	root := NewGame()
 	var expectedValue float32
 	for i := 1; i <= nIter; i++ {
@@ -237,14 +411,15 @@ func (m randomGuessModel) Predict(infoSet cfr.InfoSet, nActions int) []float32 {
 }
 
 func TestPoker_SingleDeepCFR(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
 	model := &randomGuessModel{}
 	gob.Register(model)
-	buf0 := deepcfr.NewReservoirBuffer(10, 1)
-	buf1 := deepcfr.NewReservoirBuffer(10, 1)
+	buf0 := deepcfr.NewReservoirBuffer(rng, 10, 1)
+	buf1 := deepcfr.NewReservoirBuffer(rng, 10, 1)
 	deepCFR := deepcfr.NewSingleDeepCFR(model, []deepcfr.Buffer{buf0, buf1})
 	root := NewGame()
 	es := sampling.NewExternalSampler()
-	opt := cfr.NewGeneralizedSampling(deepCFR, es)
+	opt := cfr.NewGeneralizedSampling(rng, deepCFR, es)
 	for i := 1; i <= 1000; i++ {
 		opt.Run(root)
 	}
@@ -289,17 +464,18 @@ func TestPoker_SingleDeepCFR(t *testing.T) {
 }
 
 func TestPoker_VRSingleDeepCFR(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
 	model := &randomGuessModel{}
 	gob.Register(model)
-	buf0 := deepcfr.NewReservoirBuffer(10, 1)
-	buf1 := deepcfr.NewReservoirBuffer(10, 1)
-	buf2 := deepcfr.NewReservoirBuffer(10, 1)
-	buf3 := deepcfr.NewReservoirBuffer(10, 1)
+	buf0 := deepcfr.NewReservoirBuffer(rng, 10, 1)
+	buf1 := deepcfr.NewReservoirBuffer(rng, 10, 1)
+	buf2 := deepcfr.NewReservoirBuffer(rng, 10, 1)
+	buf3 := deepcfr.NewReservoirBuffer(rng, 10, 1)
 	deepCFR := deepcfr.NewVRSingleDeepCFR(model, []deepcfr.Buffer{buf0, buf1}, []deepcfr.Buffer{buf2, buf3})
 	root := NewGame()
-	rs1 := sampling.NewRobustSampler(2)
-	rs2 := sampling.NewRobustSampler(1)
-	opt := cfr.NewVRMCCFR(deepCFR, rs1, rs2)
+	rs1 := sampling.NewRobustSampler(rng, 2)
+	rs2 := sampling.NewRobustSampler(rng, 1)
+	opt := cfr.NewVRMCCFR(rng, deepCFR, rs1, rs2)
 	for i := 1; i <= 1000; i++ {
 		opt.Run(root)
 	}
@@ -343,6 +519,305 @@ func TestPoker_VRSingleDeepCFR(t *testing.T) {
 	}
 }
 
+// tabularModel fits each InfoSet (and action, for ExperienceTuples)
+// to the weighted mean of its samples.
+type tabularModel struct {
+	Means map[string][]float32
+}
+
+func (m tabularModel) Train(samples deepcfr.Buffer) deepcfr.TrainedModel {
+	sums := make(map[string][]float32)
+	weights := make(map[string][]float32)
+	add := func(key string, i int, w, x float32) {
+		for len(sums[key]) <= i {
+			sums[key] = append(sums[key], 0)
+			weights[key] = append(weights[key], 0)
+		}
+
+		sums[key][i] += w * x
+		weights[key][i] += w
+	}
+
+	for _, s := range samples.GetSamples() {
+		switch sample := s.(type) {
+		case *deepcfr.RegretSample:
+			for i, x := range sample.Advantages {
+				add(string(sample.InfoSet), i, sample.Weight, x)
+			}
+		case *deepcfr.ExperienceTuple:
+			add(string(sample.InfoSet), int(sample.Action), sample.Weight, sample.Value)
+		}
+	}
+
+	means := make(map[string][]float32, len(sums))
+	for key, sum := range sums {
+		for i, w := range weights[key] {
+			if w > 0 {
+				sum[i] /= w
+			}
+		}
+
+		means[key] = sum
+	}
+
+	return tabularModel{means}
+}
+
+func (m tabularModel) Predict(infoSet cfr.InfoSet, nActions int) []float32 {
+	result := make([]float32, nActions)
+	copy(result, m.Means[string(infoSet.Key())])
+	return result
+}
+
+func TestPoker_DeepCFR(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
+	model := tabularModel{}
+	gob.Register(model)
+	buffers := []deepcfr.Buffer{
+		deepcfr.NewReservoirBuffer(rng, 100000, 1),
+		deepcfr.NewReservoirBuffer(rng, 100000, 1),
+	}
+	strategyBuffers := []deepcfr.Buffer{
+		deepcfr.NewReservoirBuffer(rng, 100000, 1),
+		deepcfr.NewReservoirBuffer(rng, 100000, 1),
+	}
+	deepCFR := deepcfr.NewDeepCFR(model, buffers, model, strategyBuffers)
+	root := NewGame()
+	es := sampling.NewExternalSampler()
+	opt := cfr.NewGeneralizedSampling(rng, deepCFR, es)
+	for i := 1; i <= 2000; i++ {
+		opt.Run(root)
+		deepCFR.Update()
+	}
+
+	if deepCFR.GetStrategyBuffer(0).Len() == 0 || deepCFR.GetStrategyBuffer(1).Len() == 0 {
+		t.Fatal("expected strategy samples to be collected")
+	}
+
+	deepCFR.TrainAverageStrategy()
+	result := exploitability.Compute(root, deepCFR)
+	t.Logf("exploitability: %.4f, value: %.4f", result.Exploitability, result.Values[0])
+	if result.Exploitability > 0.05 {
+		t.Errorf("expected exploitability < 0.05, got %v", result.Exploitability)
+	}
+
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+	if err := enc.Encode(deepCFR); err != nil {
+		t.Fatal(err)
+	}
+
+	dec := gob.NewDecoder(&buf)
+	var reloaded deepcfr.DeepCFR
+	if err := dec.Decode(&reloaded); err != nil {
+		t.Fatal(err)
+	}
+
+	tree.Visit(root, func(node cfr.GameTreeNode) {
+		if node.Type() != cfr.PlayerNodeType {
+			return
+		}
+
+		p1 := deepCFR.GetPolicy(node).GetAverageStrategy()
+		p2 := reloaded.GetPolicy(node).GetAverageStrategy()
+		if !reflect.DeepEqual(p1, p2) {
+			t.Errorf("expected %v, got %v", p1, p2)
+		}
+	})
+}
+
+func TestPoker_DREAM(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
+	model := tabularModel{}
+	gob.Register(model)
+	buffers := []deepcfr.Buffer{
+		deepcfr.NewReservoirBuffer(rng, 100000, 1),
+		deepcfr.NewReservoirBuffer(rng, 100000, 1),
+	}
+	baselineBuffers := []deepcfr.Buffer{
+		deepcfr.NewReservoirBuffer(rng, 100000, 1),
+		deepcfr.NewReservoirBuffer(rng, 100000, 1),
+	}
+	dream := deepcfr.NewDREAM(model, buffers, model, baselineBuffers)
+	root := NewGame()
+	os1 := sampling.NewOutcomeSampler(rng, 0.5)
+	os2 := sampling.NewOutcomeSampler(rng, 0)
+	opt := cfr.NewVRMCCFR(rng, dream, os1, os2)
+	for i := 1; i <= 2000; i++ {
+		opt.Run(root)
+		dream.Update()
+	}
+
+	result := exploitability.Compute(root, dream)
+	t.Logf("exploitability: %.4f, value: %.4f", result.Exploitability, result.Values[0])
+	if result.Exploitability > 0.1 {
+		t.Errorf("expected exploitability < 0.1, got %v", result.Exploitability)
+	}
+
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+	if err := enc.Encode(dream); err != nil {
+		t.Fatal(err)
+	}
+
+	dec := gob.NewDecoder(&buf)
+	var reloaded deepcfr.DREAM
+	if err := dec.Decode(&reloaded); err != nil {
+		t.Fatal(err)
+	}
+
+	if reloaded.GetBaselineBuffer(0).Len() != dream.GetBaselineBuffer(0).Len() {
+		t.Errorf("expected %d baseline samples, got %d",
+			dream.GetBaselineBuffer(0).Len(), reloaded.GetBaselineBuffer(0).Len())
+	}
+}
+
+func TestPoker_SingleDeepCFR_MLP(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
+	model := mlp.NewModel(rng, mlp.HashingFeaturizer{Size: 64}, mlp.Params{
+		HiddenLayers: []int{32},
+		MaxActions:   2,
+		BatchSize:    64,
+		NumBatches:   200,
+		LearningRate: 0.01,
+	})
+	buffers := []deepcfr.Buffer{
+		deepcfr.NewReservoirBuffer(rng, 10000, 1),
+		deepcfr.NewReservoirBuffer(rng, 10000, 1),
+	}
+	deepCFR := deepcfr.NewSingleDeepCFR(model, buffers)
+	root := NewGame()
+	opt := cfr.NewGeneralizedSampling(rng, deepCFR, sampling.NewExternalSampler())
+	for i := 1; i <= 100; i++ {
+		for k := 0; k < 20; k++ {
+			opt.Run(root)
+		}
+
+		deepCFR.Update()
+	}
+
+	result := exploitability.Compute(root, deepCFR)
+	t.Logf("exploitability: %.4f, value: %.4f", result.Exploitability, result.Values[0])
+	if result.Exploitability > 0.1 {
+		t.Errorf("expected exploitability < 0.1, got %v", result.Exploitability)
+	}
+}
+
+func TestPoker_SingleDeepCFR_BatchedCachedPredictions(t *testing.T) {
+	run := func(wrap func(deepcfr.Model) deepcfr.Model) *deepcfr.SingleDeepCFR {
+		rng := rand.New(rand.NewSource(123))
+		buffers := []deepcfr.Buffer{
+			deepcfr.NewReservoirBuffer(rng, 10000, 1),
+			deepcfr.NewReservoirBuffer(rng, 10000, 1),
+		}
+		deepCFR := deepcfr.NewSingleDeepCFR(wrap(tabularModel{}), buffers)
+		opt := cfr.NewGeneralizedSampling(rng, deepCFR, sampling.NewExternalSampler())
+		for i := 1; i <= 20; i++ {
+			for k := 0; k < 10; k++ {
+				opt.Run(NewGame())
+			}
+
+			deepCFR.Update()
+		}
+
+		return deepCFR
+	}
+
+	expected := run(func(m deepcfr.Model) deepcfr.Model { return m })
+	cache := deepcfr.NewPredictionCache(1000)
+	actual := run(func(m deepcfr.Model) deepcfr.Model {
+		return deepcfr.NewCachingModel(deepcfr.NewBatchingModel(m, deepcfr.BatchParams{}), cache)
+	})
+
+	root := NewGame()
+	tree.Visit(root, func(node cfr.GameTreeNode) {
+		if node.Type() != cfr.PlayerNodeType {
+			return
+		}
+
+		p1 := expected.GetPolicy(node).GetAverageStrategy()
+		p2 := actual.GetPolicy(node).GetAverageStrategy()
+		if !reflect.DeepEqual(p1, p2) {
+			t.Errorf("expected %v, got %v", p1, p2)
+		}
+	})
+
+	if hits, misses := cache.Stats(); hits == 0 {
+		t.Errorf("expected cache hits, got %d hits and %d misses", hits, misses)
+	}
+}
+
+func TestPoker_SingleDeepCFR_Retention(t *testing.T) {
+	gob.Register(tabularModel{})
+	testCases := []struct {
+		name              string
+		newRetention      func(rng *rand.Rand) deepcfr.ModelRetention
+		maxExploitability float64
+	}{
+		{"all", func(rng *rand.Rand) deepcfr.ModelRetention { return nil }, 0.1},
+		// Only averages over recent iterations, so converges less well.
+		{"keep last", func(rng *rand.Rand) deepcfr.ModelRetention { return deepcfr.KeepLast{MaxModels: 20} }, 0.3},
+		{"geometric", func(rng *rand.Rand) deepcfr.ModelRetention { return deepcfr.GeometricRetention{MaxModels: 20} }, 0.1},
+		{"reservoir", func(rng *rand.Rand) deepcfr.ModelRetention { return deepcfr.NewReservoirRetention(rng, 20) }, 0.1},
+		{"distillation", func(rng *rand.Rand) deepcfr.ModelRetention {
+			return &deepcfr.Distillation{
+				Model: tabularModel{},
+				Buffers: []deepcfr.Buffer{
+					deepcfr.NewReservoirBuffer(rng, 100000, 1),
+					deepcfr.NewReservoirBuffer(rng, 100000, 1),
+				},
+				MaxModels: 20,
+			}
+		}, 0.2},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rng := rand.New(rand.NewSource(123))
+			buffers := []deepcfr.Buffer{
+				deepcfr.NewReservoirBuffer(rng, 100000, 1),
+				deepcfr.NewReservoirBuffer(rng, 100000, 1),
+			}
+			deepCFR := deepcfr.NewSingleDeepCFR(tabularModel{}, buffers)
+			retention := tc.newRetention(rng)
+			deepCFR.SetRetention(retention)
+			root := NewGame()
+			opt := cfr.NewGeneralizedSampling(rng, deepCFR, sampling.NewExternalSampler())
+			for i := 1; i <= 400; i++ {
+				opt.Run(root)
+				deepCFR.Update()
+			}
+
+			result := exploitability.Compute(root, deepCFR)
+			t.Logf("%d models, exploitability: %.4f, value: %.4f",
+				deepCFR.NumModels(0), result.Exploitability, result.Values[0])
+			if result.Exploitability > tc.maxExploitability {
+				t.Errorf("expected exploitability < %v, got %v", tc.maxExploitability, result.Exploitability)
+			}
+
+			if retention != nil && deepCFR.NumModels(0) > 20 {
+				t.Errorf("expected at most 20 models, got %d", deepCFR.NumModels(0))
+			}
+
+			// The retention policy is saved with the profile.
+			var buf bytes.Buffer
+			if err := gob.NewEncoder(&buf).Encode(deepCFR); err != nil {
+				t.Fatal(err)
+			}
+
+			var reloaded deepcfr.SingleDeepCFR
+			if err := gob.NewDecoder(&buf).Decode(&reloaded); err != nil {
+				t.Fatal(err)
+			}
+
+			reloaded.Update()
+			if retention != nil && reloaded.NumModels(1) > 20 {
+				t.Errorf("expected at most 20 models after reloading, got %d", reloaded.NumModels(1))
+			}
+		})
+	}
+}
+
 func TestMarshalStrategy(t *testing.T) {
 	root := NewGame()
 	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
@@ -390,10 +865,89 @@ func TestMarshalStrategy(t *testing.T) {
 	})
 }
 
+func TestMarshalStrategy_Predictive(t *testing.T) {
+	root := NewGame()
+	params := cfr.DiscountParams{UseRegretMatchingPlus: true, Predictive: true}
+	policy := cfr.NewPolicyTable(params)
+	opt := cfr.New(policy)
+	for i := 0; i < 10; i++ {
+		opt.Run(root)
+		policy.Update()
+	}
+
+	// Marshal in the middle of an iteration, so that the reloaded policy
+	// must keep the pending instantaneous regrets used for prediction.
+	opt.Run(root)
+	buf, err := policy.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded := cfr.NewPolicyTable(cfr.DiscountParams{})
+	if err := reloaded.UnmarshalBinary(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []cfr.StrategyProfile{policy, reloaded} {
+		// Touch all nodes so that they are updated.
+		cfr.New(p).Run(root)
+		p.Update()
+	}
+
+	tree.Visit(root, func(node cfr.GameTreeNode) {
+		if node.Type() != cfr.PlayerNodeType {
+			return
+		}
+
+		s1 := policy.GetPolicy(node).GetStrategy()
+		s2 := reloaded.GetPolicy(node).GetStrategy()
+		if !reflect.DeepEqual(s1, s2) {
+			t.Errorf("expected %v, got %v", s1, s2)
+		}
+	})
+}
+
+func TestMarshalStrategy_RegretMinimizer(t *testing.T) {
+	root := NewGame()
+	rm := cfr.Hedge{LearningRate: 1.0, Decay: 0.5}
+	policy := cfr.NewPolicyTableWithRegretMinimizer(cfr.DiscountParams{}, rm)
+	opt := cfr.New(policy)
+	opt.Run(root)
+	policy.Update()
+
+	buf, err := policy.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded := cfr.NewPolicyTable(cfr.DiscountParams{})
+	if err := reloaded.UnmarshalBinary(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []cfr.StrategyProfile{policy, reloaded} {
+		cfr.New(p).Run(root)
+		p.Update()
+	}
+
+	tree.Visit(root, func(node cfr.GameTreeNode) {
+		if node.Type() != cfr.PlayerNodeType {
+			return
+		}
+
+		s1 := policy.GetPolicy(node).GetStrategy()
+		s2 := reloaded.GetPolicy(node).GetStrategy()
+		if !reflect.DeepEqual(s1, s2) {
+			t.Errorf("expected %v, got %v", s1, s2)
+		}
+	})
+}
+
 func TestPoker_SmoothUCT(t *testing.T) {
 	root := NewGame()
 	opt := mcts.NewSmoothUCT(100000, 1.75, 0.1, 0.9, 0.001)
-	ev := opt.Run(root)
+	rng := rand.New(rand.NewSource(rand.Int63()))
+	ev := opt.Run(rng, root)
 	t.Logf("EV = %.4f", ev)
 	seen := make(map[string]struct{})
 	tree.Visit(root, func(node cfr.GameTreeNode) {
@@ -402,7 +956,7 @@ func TestPoker_SmoothUCT(t *testing.T) {
 		}
 
 		key := node.InfoSet(node.Player()).Key()
-		if _, ok := seen[key]; ok {
+		if _, ok := seen[string(key)]; ok {
 			return
 		}
 
@@ -411,6 +965,6 @@ func TestPoker_SmoothUCT(t *testing.T) {
 			t.Logf("%6s: check=%.2f bet=%.2f", node, actionProbs[0], actionProbs[1])
 		}
 
-		seen[key] = struct{}{}
+		seen[string(key)] = struct{}{}
 	})
 }
@@ -0,0 +1,412 @@
+// Package leduc implements an extensive-form game tree for Leduc Hold'em,
+// as described in: http://poker.cs.ualberta.ca/publications/UAI05.pdf.
+//
+// The deck consists of two suits of three ranks (Jack, Queen, King).
+// Each player antes 1 chip and is dealt one private card, followed by a round
+// of betting. A public board card is then dealt, followed by a second round
+// of betting. Bets are 2 chips in the first round and 4 chips in the second,
+// with at most one bet and one raise per round. At showdown, a player who
+// pairs the board wins, otherwise the highest card wins.
+//
+// Since suits do not matter in Leduc Hold'em, cards are dealt by rank
+// and InfoSets do not distinguish suits.
+package leduc
+
+import (
+	"encoding/gob"
+	"fmt"
+	"strings"
+
+	"github.com/timpalpant/go-cfr"
+	"github.com/timpalpant/go-cfr/sampling"
+)
+
+const (
+	chance  = -1
+	player0 = 0
+	player1 = 1
+
+	numRounds    = 2
+	maxRaises    = 2
+	numSuits     = 2
+	roundDivider = '/'
+)
+
+var betSizes = [numRounds]int{2, 4}
+
+type Action byte
+
+const (
+	Fold  Action = 'f'
+	Call  Action = 'c' // Check if there is no outstanding bet.
+	Raise Action = 'r' // Bet if there is no outstanding bet.
+)
+
+type Card int
+
+const (
+	NoCard Card = iota - 1
+	Jack
+	Queen
+	King
+)
+
+var cardStr = [...]string{
+	"J",
+	"Q",
+	"K",
+}
+
+func (c Card) String() string {
+	if c == NoCard {
+		return "?"
+	}
+
+	return cardStr[c]
+}
+
+func parseCard(s string) (Card, error) {
+	if s == NoCard.String() {
+		return NoCard, nil
+	}
+
+	for i, c := range cardStr {
+		if s == c {
+			return Card(i), nil
+		}
+	}
+
+	return NoCard, fmt.Errorf("invalid card: %q", s)
+}
+
+// PokerNode implements cfr.GameTreeNode for Leduc Hold'em.
+type PokerNode struct {
+	parent        *PokerNode
+	player        int
+	children      []PokerNode
+	probabilities []float64
+
+	// Betting history, with rounds separated by roundDivider.
+	history  string
+	round    int
+	nRaises  int
+	terminal bool
+
+	// Number of chips each player has put in the pot.
+	contributions [2]int
+
+	// Private card held by either player, and the public board card.
+	p0Card, p1Card Card
+	board          Card
+}
+
+// NewGame returns the root node of a new game of Leduc Hold'em.
+func NewGame() *PokerNode {
+	return &PokerNode{
+		player:        chance,
+		contributions: [2]int{1, 1}, // Antes.
+		p0Card:        NoCard,
+		p1Card:        NoCard,
+		board:         NoCard,
+	}
+}
+
+// String implements fmt.Stringer.
+func (k PokerNode) String() string {
+	return fmt.Sprintf("Player %v's turn. History: %9s [Cards: P0 - %s, P1 - %s, Board - %s]",
+		k.player, k.history, k.p0Card, k.p1Card, k.board)
+}
+
+// Close implements cfr.GameTreeNode.
+func (k *PokerNode) Close() {
+	k.children = nil
+	k.probabilities = nil
+}
+
+// NumChildren implements cfr.GameTreeNode.
+func (k *PokerNode) NumChildren() int {
+	if k.children == nil {
+		k.buildChildren()
+	}
+
+	return len(k.children)
+}
+
+// GetChild implements cfr.GameTreeNode.
+func (k *PokerNode) GetChild(i int) cfr.GameTreeNode {
+	if k.children == nil {
+		k.buildChildren()
+	}
+
+	return &k.children[i]
+}
+
+// Parent implements cfr.GameTreeNode.
+func (k *PokerNode) Parent() cfr.GameTreeNode {
+	if k.parent == nil {
+		// Avoid returning a non-nil interface holding a nil pointer.
+		return nil
+	}
+
+	return k.parent
+}
+
+// GetChildProbability implements cfr.GameTreeNode.
+func (k *PokerNode) GetChildProbability(i int) float64 {
+	if k.children == nil {
+		k.buildChildren()
+	}
+
+	return k.probabilities[i]
+}
+
+// SampleChild implements cfr.GameTreeNode.
+func (k *PokerNode) SampleChild() (cfr.GameTreeNode, float64) {
+	return sampling.SampleChanceNode(k)
+}
+
+// Type implements cfr.GameTreeNode.
+func (k *PokerNode) Type() cfr.NodeType {
+	if k.terminal {
+		return cfr.TerminalNodeType
+	} else if k.player == chance {
+		return cfr.ChanceNodeType
+	}
+
+	return cfr.PlayerNodeType
+}
+
+// Player implements cfr.GameTreeNode.
+func (k *PokerNode) Player() int {
+	return k.player
+}
+
+// Utility implements cfr.GameTreeNode.
+func (k *PokerNode) Utility(player int) float64 {
+	if !k.terminal {
+		panic("utility is only defined for terminal nodes: " + k.history)
+	}
+
+	opponent := 1 - player
+	won := float64(k.contributions[opponent])
+	lost := -float64(k.contributions[player])
+
+	// By convention, terminal nodes are labeled with the player whose
+	// turn it would be (i.e. not the last acting player).
+	if k.history[len(k.history)-1] == byte(Fold) {
+		// Last player folded. The current player wins.
+		if k.player == player {
+			return won
+		}
+
+		return lost
+	}
+
+	playerRank := handRank(k.playerCard(player), k.board)
+	opponentRank := handRank(k.playerCard(opponent), k.board)
+	if playerRank > opponentRank {
+		return won
+	} else if playerRank < opponentRank {
+		return lost
+	}
+
+	return 0.0 // Split pot.
+}
+
+// handRank returns a value that is higher for stronger hands.
+func handRank(card, board Card) int {
+	if card == board {
+		return int(King) + 1 + int(card) // Pairs beat all high cards.
+	}
+
+	return int(card)
+}
+
+type pokerInfoSet struct {
+	card    Card
+	board   Card
+	history string
+}
+
+func (p pokerInfoSet) Key() []byte {
+	return []byte(p.card.String() + p.board.String() + "-" + p.history)
+}
+
+func (p pokerInfoSet) MarshalBinary() ([]byte, error) {
+	return p.Key(), nil
+}
+
+func (p *pokerInfoSet) UnmarshalBinary(buf []byte) error {
+	parts := strings.SplitN(string(buf), "-", 2)
+	if len(parts) != 2 || len(parts[0]) != 2 {
+		return fmt.Errorf("invalid binary poker info set: %q", buf)
+	}
+
+	card, err := parseCard(parts[0][:1])
+	if err != nil {
+		return err
+	}
+
+	board, err := parseCard(parts[0][1:])
+	if err != nil {
+		return err
+	}
+
+	p.card = card
+	p.board = board
+	p.history = parts[1]
+	return nil
+}
+
+// InfoSet implements cfr.GameTreeNode.
+func (k *PokerNode) InfoSet(player int) cfr.InfoSet {
+	return &pokerInfoSet{
+		card:    k.playerCard(player),
+		board:   k.board,
+		history: k.history,
+	}
+}
+
+// InfoSetKey implements cfr.GameTreeNode.
+func (k *PokerNode) InfoSetKey(player int) []byte {
+	return k.InfoSet(player).Key()
+}
+
+func (k *PokerNode) playerCard(player int) Card {
+	if player == player0 {
+		return k.p0Card
+	}
+
+	return k.p1Card
+}
+
+// outstandingBet returns true if the current player must call
+// a bet to remain in the hand.
+func (k *PokerNode) outstandingBet() bool {
+	return k.contributions[0] != k.contributions[1]
+}
+
+func (k *PokerNode) buildChildren() {
+	if k.terminal {
+		return
+	}
+
+	switch {
+	case k.p0Card == NoCard:
+		k.children, k.probabilities = buildDeals(k, func(child *PokerNode, card Card) {
+			child.p0Card = card
+		})
+	case k.p1Card == NoCard:
+		k.children, k.probabilities = buildDeals(k, func(child *PokerNode, card Card) {
+			child.p1Card = card
+			child.player = player0
+		})
+	case k.player == chance:
+		k.children, k.probabilities = buildDeals(k, func(child *PokerNode, card Card) {
+			child.board = card
+			child.player = player0
+		})
+	default:
+		k.children = buildBettingChildren(k)
+	}
+}
+
+// buildDeals builds a child for each card remaining in the deck,
+// with probability proportional to the number of remaining cards of that rank.
+func buildDeals(parent *PokerNode, deal func(child *PokerNode, card Card)) ([]PokerNode, []float64) {
+	var remaining [len(cardStr)]int
+	nRemaining := 0
+	for card := range remaining {
+		remaining[card] = numSuits
+		nRemaining += numSuits
+	}
+
+	for _, card := range []Card{parent.p0Card, parent.p1Card, parent.board} {
+		if card != NoCard {
+			remaining[card]--
+			nRemaining--
+		}
+	}
+
+	var children []PokerNode
+	var probabilities []float64
+	for card, n := range remaining {
+		if n == 0 {
+			continue
+		}
+
+		child := *parent
+		child.parent = parent
+		child.children = nil
+		child.probabilities = nil
+		deal(&child, Card(card))
+		children = append(children, child)
+		probabilities = append(probabilities, float64(n)/float64(nRemaining))
+	}
+
+	return children, probabilities
+}
+
+func buildBettingChildren(parent *PokerNode) []PokerNode {
+	var actions []Action
+	if parent.outstandingBet() {
+		actions = append(actions, Fold)
+	}
+
+	actions = append(actions, Call)
+	if parent.nRaises < maxRaises {
+		actions = append(actions, Raise)
+	}
+
+	result := make([]PokerNode, len(actions))
+	for i, action := range actions {
+		result[i] = buildBettingChild(parent, action)
+	}
+
+	return result
+}
+
+func buildBettingChild(parent *PokerNode, action Action) PokerNode {
+	child := *parent
+	child.parent = parent
+	child.children = nil
+	child.probabilities = nil
+	child.player = 1 - parent.player
+	child.history += string([]byte{byte(action)})
+
+	player := parent.player
+	opponent := 1 - player
+	roundHistory := parent.history[strings.LastIndexByte(parent.history, roundDivider)+1:]
+	switch action {
+	case Fold:
+		child.terminal = true
+	case Call:
+		facingBet := parent.outstandingBet()
+		child.contributions[player] = parent.contributions[opponent]
+		if facingBet || len(roundHistory) > 0 {
+			// Either a bet was called, or both players checked.
+			endRound(&child)
+		}
+	case Raise:
+		child.contributions[player] = parent.contributions[opponent] + betSizes[parent.round]
+		child.nRaises++
+	}
+
+	return child
+}
+
+func endRound(node *PokerNode) {
+	if node.round == numRounds-1 {
+		node.terminal = true
+		return
+	}
+
+	node.round++
+	node.nRaises = 0
+	node.player = chance
+	node.history += string([]byte{roundDivider})
+}
+
+func init() {
+	gob.Register(&pokerInfoSet{})
+}
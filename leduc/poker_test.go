@@ -0,0 +1,449 @@
+package leduc
+
+import (
+	"bytes"
+	"encoding/gob"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/timpalpant/go-cfr"
+	"github.com/timpalpant/go-cfr/deepcfr"
+	"github.com/timpalpant/go-cfr/exploitability"
+	"github.com/timpalpant/go-cfr/sampling"
+	"github.com/timpalpant/go-cfr/tree"
+)
+
+func TestPoker_GameTree(t *testing.T) {
+	root := NewGame()
+
+	nNodes := tree.CountNodes(root)
+	if nNodes != 1939 {
+		t.Errorf("expected %d nodes, got %d", 1939, nNodes)
+	}
+
+	nTerminal := tree.CountTerminalNodes(root)
+	if nTerminal != 1116 {
+		t.Errorf("expected %d terminal nodes, got %d", 1116, nTerminal)
+	}
+}
+
+func TestPoker_InfoSets(t *testing.T) {
+	root := NewGame()
+	nInfoSets := tree.CountInfoSets(root)
+	if nInfoSets != 288 {
+		t.Errorf("expected %d nodes, got %d", 288, nInfoSets)
+	}
+}
+
+func TestPoker_ChanceProbabilities(t *testing.T) {
+	root := NewGame()
+	tree.Visit(root, func(node cfr.GameTreeNode) {
+		if node.Type() != cfr.ChanceNodeType {
+			return
+		}
+
+		var total float64
+		for i := 0; i < node.NumChildren(); i++ {
+			total += node.GetChildProbability(i)
+		}
+
+		if total < 1.0-1e-9 || total > 1.0+1e-9 {
+			t.Errorf("chance probabilities sum to %v: %v", total, node)
+		}
+	})
+}
+
+func TestPoker_InfoSetMarshalRoundTrip(t *testing.T) {
+	root := NewGame()
+	tree.VisitInfoSets(root, func(player int, infoSet cfr.InfoSet) {
+		buf, err := infoSet.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var reloaded pokerInfoSet
+		if err := reloaded.UnmarshalBinary(buf); err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(infoSet, &reloaded) {
+			t.Errorf("expected %v, got %v", infoSet, &reloaded)
+		}
+
+		if !bytes.Equal(infoSet.Key(), reloaded.Key()) {
+			t.Errorf("expected key %q, got %q", infoSet.Key(), reloaded.Key())
+		}
+	})
+}
+
+func TestPoker_Utility(t *testing.T) {
+	root := NewGame()
+	tree.Visit(root, func(node cfr.GameTreeNode) {
+		if node.Type() != cfr.TerminalNodeType {
+			return
+		}
+
+		// Leduc Hold'em is zero-sum.
+		if u := node.Utility(player0) + node.Utility(player1); u != 0 {
+			t.Errorf("utilities sum to %v: %v", u, node)
+		}
+	})
+}
+
+func TestPoker_VanillaCFR(t *testing.T) {
+	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
+	opt := cfr.New(policy)
+	result := testCFR(t, opt, policy, 1000)
+	if result.Exploitability > 0.05 {
+		t.Errorf("expected exploitability < 0.05, got %v", result.Exploitability)
+	}
+}
+
+func TestPoker_ChanceSamplingCFR(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
+	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
+	opt := cfr.NewChanceSampling(rng, policy)
+	testCFR(t, opt, policy, 20000)
+}
+
+func TestPoker_ExternalSamplingCFR(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
+	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
+	es := sampling.NewExternalSampler()
+	opt := cfr.NewMCCFR(rng, policy, es)
+	testCFR(t, opt, policy, 20000)
+}
+
+func TestPoker_OutcomeSamplingCFR(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
+	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
+	os := sampling.NewOutcomeSampler(rng, 0.3)
+	opt := cfr.NewMCCFR(rng, policy, os)
+	testCFR(t, opt, policy, 20000)
+}
+
+func TestPoker_OnlineOutcomeSamplingCFR(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
+	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
+	os := sampling.NewOutcomeSampler(rng, 0.3)
+	opt := cfr.NewOnlineOutcomeSamplingCFR(rng, policy, os)
+	testCFR(t, opt, policy, 20000)
+}
+
+func TestPoker_VRMCCFR(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
+	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
+	rs1 := sampling.NewRobustSampler(rng, 2)
+	rs2 := sampling.NewRobustSampler(rng, 1)
+	opt := cfr.NewVRMCCFR(rng, policy, rs1, rs2)
+	testCFR(t, opt, policy, 20000)
+}
+
+func TestPoker_AverageStrategySamplingCFR(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
+	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
+	params := sampling.AverageStrategyParams{
+		Epsilon: 0.05,
+		Beta:    1000000,
+		Tau:     1000,
+	}
+	as := sampling.NewAverageStrategySampler(rng, params)
+	opt := cfr.NewMCCFR(rng, policy, as)
+	testCFR(t, opt, policy, 20000)
+}
+
+func TestPoker_RobustSamplingCFR(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
+	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
+	rs := sampling.NewRobustSampler(rng, 1)
+	opt := cfr.NewGeneralizedSampling(rng, policy, rs)
+	testCFR(t, opt, policy, 20000)
+}
+
+func TestPoker_MultiOutcomeSamplingCFR(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
+	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
+	mos := sampling.NewMultiOutcomeSampler(rng, 1, 0.1)
+	opt := cfr.NewGeneralizedSampling(rng, policy, mos)
+	testCFR(t, opt, policy, 20000)
+}
+
+func TestPoker_CFRPlus(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
+	plus := cfr.DiscountParams{UseRegretMatchingPlus: true}
+	policy := cfr.NewPolicyTable(plus)
+	es := sampling.NewExternalSampler()
+	opt := cfr.NewGeneralizedSampling(rng, policy, es)
+	testCFR(t, opt, policy, 20000)
+}
+
+func TestPoker_LinearCFR(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
+	linear := cfr.DiscountParams{LinearWeighting: true}
+	policy := cfr.NewPolicyTable(linear)
+	es := sampling.NewExternalSampler()
+	opt := cfr.NewGeneralizedSampling(rng, policy, es)
+	testCFR(t, opt, policy, 20000)
+}
+
+func TestPoker_DiscountedCFR(t *testing.T) {
+	abg := cfr.DiscountParams{
+		// From https://arxiv.org/pdf/1809.04040.pdf
+		//   we found that setting α=3/2, β=0, and γ=2
+		//   led to performance that was consistently stronger than CFR+
+		DiscountAlpha: 1.5,
+		DiscountBeta:  0.0,
+		DiscountGamma: 2.0,
+	}
+
+	policy := cfr.NewPolicyTable(abg)
+	opt := cfr.New(policy)
+	testCFR(t, opt, policy, 1000)
+}
+
+func TestPoker_PredictiveCFRPlus(t *testing.T) {
+	plus := cfr.NewPolicyTable(cfr.DiscountParams{UseRegretMatchingPlus: true})
+	plusResult := testCFR(t, cfr.New(plus), plus, 200)
+
+	pcfrPlus := cfr.NewPolicyTable(cfr.DiscountParams{
+		UseRegretMatchingPlus: true,
+		Predictive:            true,
+	})
+	result := testCFR(t, cfr.New(pcfrPlus), pcfrPlus, 200)
+	if result.Exploitability > plusResult.Exploitability {
+		t.Errorf("expected PCFR+ exploitability < CFR+ exploitability (%v), got %v",
+			plusResult.Exploitability, result.Exploitability)
+	}
+}
+
+func TestPoker_OptimisticCFR(t *testing.T) {
+	optimistic := cfr.DiscountParams{PredictionWeight: 2.0}
+	policy := cfr.NewPolicyTable(optimistic)
+	opt := cfr.New(policy)
+	result := testCFR(t, opt, policy, 1000)
+	if result.Exploitability > 0.05 {
+		t.Errorf("expected exploitability < 0.05, got %v", result.Exploitability)
+	}
+}
+
+func TestPoker_PruningCFR(t *testing.T) {
+	pruning := cfr.PruningParams{
+		Enabled:          true,
+		MaxUtility:       13,
+		Threshold:        -50.0,
+		WarmupIterations: 100,
+		RevisitInterval:  20,
+	}
+
+	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
+	opt := cfr.NewWithPruning(policy, pruning)
+	result := testCFR(t, opt, policy, 1000)
+	if result.Exploitability > 0.05 {
+		t.Errorf("expected exploitability < 0.05, got %v", result.Exploitability)
+	}
+}
+
+func TestPoker_PruningExternalSamplingCFR(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
+	pruning := cfr.PruningParams{
+		Enabled:          true,
+		MaxUtility:       13,
+		Threshold:        -500.0,
+		WarmupIterations: 1000,
+		RevisitInterval:  20,
+	}
+
+	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
+	es := sampling.NewExternalSampler()
+	opt := cfr.NewMCCFRWithPruning(rng, policy, es, pruning)
+	testCFR(t, opt, policy, 20000)
+}
+
+func BenchmarkPoker_VanillaCFR(b *testing.B) {
+	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
+	opt := cfr.New(policy)
+	b.ResetTimer()
+	runCFR(b, opt, policy, b.N)
+}
+
+func BenchmarkPoker_ExternalSamplingCFR(b *testing.B) {
+	rng := rand.New(rand.NewSource(123))
+	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
+	es := sampling.NewExternalSampler()
+	opt := cfr.NewGeneralizedSampling(rng, policy, es)
+	b.ResetTimer()
+	runCFR(b, opt, policy, b.N)
+}
+
+func BenchmarkPoker_OutcomeSamplingCFR(b *testing.B) {
+	rng := rand.New(rand.NewSource(123))
+	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
+	os := sampling.NewOutcomeSampler(rng, 0.05)
+	opt := cfr.NewGeneralizedSampling(rng, policy, os)
+	b.ResetTimer()
+	runCFR(b, opt, policy, b.N)
+}
+
+func testCFR(t *testing.T, opt cfr.Solver, policy cfr.StrategyProfile, nIter int) exploitability.Result {
+	root := runCFR(t, opt, policy, nIter)
+	result := exploitability.Compute(root, policy)
+	t.Logf("Exploitability: %.4f, NashConv: %.4f, game value: %.4f",
+		result.Exploitability, result.NashConv, result.Values[player0])
+	return result
+}
+
+type logger interface {
+	Logf(string, ...interface{})
+}
+
+func runCFR(log logger, opt cfr.Solver, policy cfr.StrategyProfile, nIter int) cfr.GameTreeNode {
+	root := NewGame()
+	var expectedValue float32
+	for i := 1; i <= nIter; i++ {
+		expectedValue += opt.Run(root)
+		if nIter/10 > 0 && i%(nIter/10) == 0 {
+			log.Logf("[iter=%d] Expected game value: %.4f", i, expectedValue/float32(i))
+		}
+
+		policy.Update()
+	}
+
+	return root
+}
+
+type randomGuessModel struct{}
+
+func (m randomGuessModel) Train(samples deepcfr.Buffer) deepcfr.TrainedModel {
+	return m
+}
+
+func (m randomGuessModel) Predict(infoSet cfr.InfoSet, nActions int) []float32 {
+	result := make([]float32, nActions)
+	for i := range result {
+		result[i] = 1.0 / float32(nActions)
+	}
+
+	return result
+}
+
+func TestPoker_SingleDeepCFR(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
+	model := &randomGuessModel{}
+	gob.Register(model)
+	buf0 := deepcfr.NewReservoirBuffer(rng, 10, 1)
+	buf1 := deepcfr.NewReservoirBuffer(rng, 10, 1)
+	deepCFR := deepcfr.NewSingleDeepCFR(model, []deepcfr.Buffer{buf0, buf1})
+	root := NewGame()
+	es := sampling.NewExternalSampler()
+	opt := cfr.NewGeneralizedSampling(rng, deepCFR, es)
+	for i := 1; i <= 100; i++ {
+		opt.Run(root)
+	}
+
+	deepCFR.Update()
+
+	for i := 1; i <= 100; i++ {
+		opt.Run(root)
+	}
+
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+	if err := enc.Encode(deepCFR); err != nil {
+		t.Error(err)
+	}
+
+	dec := gob.NewDecoder(&buf)
+	var reloaded deepcfr.SingleDeepCFR
+	if err := dec.Decode(&reloaded); err != nil {
+		t.Error(err)
+	}
+
+	for player := range []int{player0, player1} {
+		for _, sample := range reloaded.GetBuffer(player).GetSamples() {
+			var infoSet pokerInfoSet
+			if err := infoSet.UnmarshalBinary(sample.(*deepcfr.RegretSample).InfoSet); err != nil {
+				t.Error(err)
+			}
+		}
+	}
+}
+
+func TestPoker_VRSingleDeepCFR(t *testing.T) {
+	rng := rand.New(rand.NewSource(123))
+	model := &randomGuessModel{}
+	gob.Register(model)
+	buf0 := deepcfr.NewReservoirBuffer(rng, 10, 1)
+	buf1 := deepcfr.NewReservoirBuffer(rng, 10, 1)
+	buf2 := deepcfr.NewReservoirBuffer(rng, 10, 1)
+	buf3 := deepcfr.NewReservoirBuffer(rng, 10, 1)
+	deepCFR := deepcfr.NewVRSingleDeepCFR(model, []deepcfr.Buffer{buf0, buf1}, []deepcfr.Buffer{buf2, buf3})
+	root := NewGame()
+	rs1 := sampling.NewRobustSampler(rng, 2)
+	rs2 := sampling.NewRobustSampler(rng, 1)
+	opt := cfr.NewVRMCCFR(rng, deepCFR, rs1, rs2)
+	for i := 1; i <= 100; i++ {
+		opt.Run(root)
+	}
+
+	deepCFR.Update()
+
+	for i := 1; i <= 100; i++ {
+		opt.Run(root)
+	}
+
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+	if err := enc.Encode(deepCFR); err != nil {
+		t.Error(err)
+	}
+
+	dec := gob.NewDecoder(&buf)
+	var reloaded deepcfr.VRSingleDeepCFR
+	if err := dec.Decode(&reloaded); err != nil {
+		t.Error(err)
+	}
+
+	t.Logf("Reloaded buffer 0 (%d samples)", reloaded.GetBuffer(0).Len())
+	t.Logf("Reloaded buffer 1 (%d samples)", reloaded.GetBuffer(1).Len())
+}
+
+func TestMarshalStrategy(t *testing.T) {
+	root := NewGame()
+	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
+	opt := cfr.New(policy)
+	opt.Run(root)
+	policy.Update()
+
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+	if err := enc.Encode(policy); err != nil {
+		t.Error(err)
+	}
+
+	dec := gob.NewDecoder(&buf)
+	var reloaded cfr.PolicyTable
+	if err := dec.Decode(&reloaded); err != nil {
+		t.Error(err)
+	}
+
+	// Verify that current strategy and average strategy are unchanged
+	// after marshalling round trip.
+	tree.Visit(root, func(node cfr.GameTreeNode) {
+		if node.Type() != cfr.PlayerNodeType {
+			return
+		}
+
+		p1 := policy.GetPolicy(node).GetStrategy()
+		p2 := reloaded.GetPolicy(node).GetStrategy()
+		if !reflect.DeepEqual(p1, p2) {
+			t.Errorf("expected %v, got %v", p1, p2)
+		}
+
+		avgStrat1 := policy.GetPolicy(node).GetAverageStrategy()
+		avgStrat2 := reloaded.GetPolicy(node).GetAverageStrategy()
+		if !reflect.DeepEqual(avgStrat1, avgStrat2) {
+			t.Errorf("expected %v, got %v", avgStrat1, avgStrat2)
+		}
+	})
+}
@@ -20,56 +20,90 @@ type Sampler interface {
 type MCCFR struct {
 	strategyProfile StrategyProfile
 	sampler         Sampler
+	pruner          *pruner
 
 	slicePool *floatSlicePool
 	mapPool   *keyIntMapPool
 	rng       *rand.Rand
+	randState
 
+	nPlayers         int
 	traversingPlayer int
 	sampledActions   map[string]int
 }
 
-func NewMCCFR(strategyProfile StrategyProfile, sampler Sampler) *MCCFR {
+func NewMCCFR(rng *rand.Rand, strategyProfile StrategyProfile, sampler Sampler) *MCCFR {
+	return NewMCCFRWithPruning(rng, strategyProfile, sampler, PruningParams{})
+}
+
+// NewMCCFRWithPruning creates a new MCCFR solver that performs regret-based
+// pruning at the nodes of the traversing player. It panics if pruning is
+// enabled and the sampler is not an ExhaustiveSampler.
+func NewMCCFRWithPruning(rng *rand.Rand, strategyProfile StrategyProfile, sampler Sampler, pruning PruningParams) *MCCFR {
+	if pruning.Enabled && !isExhaustive(sampler) {
+		panic(fmt.Errorf("pruning requires a sampler that traverses every action, got %T", sampler))
+	}
+
 	return &MCCFR{
 		strategyProfile: strategyProfile,
 		sampler:         sampler,
+		pruner:          newPruner(pruning),
 		slicePool:       &floatSlicePool{},
 		mapPool:         &keyIntMapPool{},
-		rng:             rand.New(rand.NewSource(rand.Int63())),
+		rng:             rng,
 	}
 }
 
+// StrategyProfile implements Solver.
+func (c *MCCFR) StrategyProfile() StrategyProfile {
+	return c.strategyProfile
+}
+
+// Seed implements Solver.
+func (c *MCCFR) Seed(seed int64) {
+	c.rng.Seed(seed)
+}
+
+// Run performs one iteration of MCCFR, updating the regrets of one player
+// (in turn), and returns the sampled value of the game for the first player.
 func (c *MCCFR) Run(node GameTreeNode) float32 {
 	iter := c.strategyProfile.Iter()
-	c.traversingPlayer = int(iter % 2)
+	c.nPlayers = NumPlayers(node)
+	c.traversingPlayer = iter % c.nPlayers
+	c.pruner.start(iter, c.nPlayers)
 	c.sampledActions = c.mapPool.alloc()
 	defer c.mapPool.free(c.sampledActions)
-	return c.runHelper(node, node.Player(), 1.0)
+	ev := c.runHelper(node, 1.0)
+	defer c.slicePool.free(ev)
+	return ev[0]
 }
 
-func (c *MCCFR) runHelper(node GameTreeNode, lastPlayer int, sampleProb float32) float32 {
-	var ev float32
+// runHelper returns the sampled counterfactual utility of each player at the
+// given node. The returned slice must be freed by the caller.
+func (c *MCCFR) runHelper(node GameTreeNode, sampleProb float32) []float32 {
+	var ev []float32
 	switch node.Type() {
 	case TerminalNodeType:
-		ev = float32(node.Utility(lastPlayer)) / sampleProb
+		ev = c.slicePool.alloc(c.nPlayers)
+		getUtilities(node, ev)
+		f32.ScalUnitary(1.0/sampleProb, ev)
 	case ChanceNodeType:
-		ev = c.handleChanceNode(node, lastPlayer, sampleProb)
+		ev = c.handleChanceNode(node, sampleProb)
 	default:
-		sgn := getSign(lastPlayer, node.Player())
-		ev = sgn * c.handlePlayerNode(node, sampleProb)
+		ev = c.handlePlayerNode(node, sampleProb)
 	}
 
 	node.Close()
 	return ev
 }
 
-func (c *MCCFR) handleChanceNode(node GameTreeNode, lastPlayer int, sampleProb float32) float32 {
-	child, _ := node.SampleChild()
+func (c *MCCFR) handleChanceNode(node GameTreeNode, sampleProb float32) []float32 {
+	child, _ := SampleChanceNode(c.rng, node)
 	// Sampling probabilities cancel out in the calculation of counterfactual value.
-	return c.runHelper(child, lastPlayer, sampleProb)
+	return c.runHelper(child, sampleProb)
 }
 
-func (c *MCCFR) handlePlayerNode(node GameTreeNode, sampleProb float32) float32 {
+func (c *MCCFR) handlePlayerNode(node GameTreeNode, sampleProb float32) []float32 {
 	if node.Player() == c.traversingPlayer {
 		return c.handleTraversingPlayerNode(node, sampleProb)
 	} else {
@@ -77,46 +111,50 @@ func (c *MCCFR) handlePlayerNode(node GameTreeNode, sampleProb float32) float32
 	}
 }
 
-func (c *MCCFR) handleTraversingPlayerNode(node GameTreeNode, sampleProb float32) float32 {
-	player := node.Player()
+func (c *MCCFR) handleTraversingPlayerNode(node GameTreeNode, sampleProb float32) []float32 {
 	nChildren := node.NumChildren()
 	if nChildren == 1 {
 		// Optimization to skip trivial nodes with no real choice.
 		child := node.GetChild(0)
-		return c.runHelper(child, player, sampleProb)
+		return c.runHelper(child, sampleProb)
 	}
 
 	policy := c.strategyProfile.GetPolicy(node)
+	strategy := policy.GetStrategy()
 	qs := c.slicePool.alloc(nChildren)
 	copy(qs, c.sampler.Sample(node, policy))
+	pruned := c.pruner.prune(node, policy, strategy)
+
 	regrets := c.slicePool.alloc(nChildren)
 	oldSampledActions := c.sampledActions
 	c.sampledActions = c.mapPool.alloc()
 
+	ev := c.slicePool.alloc(c.nPlayers)
 	for i, q := range qs {
-		child := node.GetChild(i)
-		var util float32
-		if q > 0 {
-			util = c.runHelper(child, player, q*sampleProb)
+		if q > 0 && !pruned.isPruned(i) {
+			child := node.GetChild(i)
+			childEV := c.runHelper(child, q*sampleProb)
+			regrets[i] = childEV[c.traversingPlayer]
+			f32.AxpyUnitary(strategy[i], childEV, ev)
+			c.slicePool.free(childEV)
 		}
-
-		regrets[i] = util
 	}
 
-	cfValue := f32.DotUnitary(policy.GetStrategy(), regrets)
+	cfValue := ev[c.traversingPlayer]
 	f32.AddConst(-cfValue, regrets)
+	pruned.update(regrets, 1.0/sampleProb, cfValue)
 	policy.AddRegret(1.0/sampleProb, qs, regrets)
 
 	c.slicePool.free(qs)
 	c.slicePool.free(regrets)
 	c.mapPool.free(c.sampledActions)
 	c.sampledActions = oldSampledActions
-	return cfValue
+	return ev
 }
 
 // Sample player action according to strategy, do not update policy.
 // Save selected action so that they are reused if this infoset is hit again.
-func (c *MCCFR) handleSampledPlayerNode(node GameTreeNode, sampleProb float32) float32 {
+func (c *MCCFR) handleSampledPlayerNode(node GameTreeNode, sampleProb float32) []float32 {
 	policy := c.strategyProfile.GetPolicy(node)
 
 	// Update average strategy for this node.
@@ -128,7 +166,7 @@ func (c *MCCFR) handleSampledPlayerNode(node GameTreeNode, sampleProb float32) f
 	// Sampling probabilities cancel out in the calculation of counterfactual value,
 	// so we don't include them here.
 	child := node.GetChild(getOrSample(c.sampledActions, node, policy, c.rng))
-	return c.runHelper(child, node.Player(), sampleProb)
+	return c.runHelper(child, sampleProb)
 }
 
 func getOrSample(sampledActions map[string]int, node GameTreeNode, policy NodePolicy, rng *rand.Rand) int {
@@ -32,7 +32,7 @@ func (rr *RandomRollout) Evaluate(rng *rand.Rand, node cfr.GameTreeNode, opponen
 		current := node
 		for current.Type() != cfr.TerminalNodeType {
 			if current.Type() == cfr.ChanceNodeType {
-				current, _ = current.SampleChild()
+				current, _ = cfr.SampleChanceNode(rng, current)
 			} else if current.Player() == player {
 				action := rng.Intn(current.NumChildren())
 				current = current.GetChild(action)
@@ -108,7 +108,7 @@ func (s *OneSidedISMCTS) simulate(rng *rand.Rand, node cfr.GameTreeNode, opponen
 	case cfr.TerminalNodeType:
 		ev = float32(node.Utility(lastPlayer))
 	case cfr.ChanceNodeType:
-		child, _ := node.SampleChild()
+		child, _ := cfr.SampleChanceNode(rng, node)
 		ev = s.simulate(rng, child, opponent, lastPlayer)
 	default:
 		sgn := getSign(lastPlayer, node.Player())
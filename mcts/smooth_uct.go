@@ -313,7 +313,7 @@ func (s *SmoothUCT) simulate(rng *rand.Rand, node cfr.GameTreeNode, lastPlayer i
 	case cfr.TerminalNodeType:
 		ev = float32(node.Utility(lastPlayer))
 	case cfr.ChanceNodeType:
-		child, _ := node.SampleChild()
+		child, _ := cfr.SampleChanceNode(rng, node)
 		ev = s.simulate(rng, child, lastPlayer, isOutOfTree)
 	default:
 		sgn := getSign(lastPlayer, node.Player())
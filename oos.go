@@ -13,52 +13,73 @@ type OnlineOutcomeSamplingCFR struct {
 	slicePool *floatSlicePool
 	mapPool   *keyIntMapPool
 	rng       *rand.Rand
+	randState
 
+	nPlayers         int
 	traversingPlayer int
 	sampledActions   map[string]int
 }
 
-func NewOnlineOutcomeSamplingCFR(strategyProfile StrategyProfile, sampler Sampler) *OnlineOutcomeSamplingCFR {
+func NewOnlineOutcomeSamplingCFR(rng *rand.Rand, strategyProfile StrategyProfile, sampler Sampler) *OnlineOutcomeSamplingCFR {
 	return &OnlineOutcomeSamplingCFR{
 		strategyProfile: strategyProfile,
 		sampler:         sampler,
 		slicePool:       &floatSlicePool{},
 		mapPool:         &keyIntMapPool{},
-		rng:             rand.New(rand.NewSource(rand.Int63())),
+		rng:             rng,
 	}
 }
 
+// StrategyProfile implements Solver.
+func (c *OnlineOutcomeSamplingCFR) StrategyProfile() StrategyProfile {
+	return c.strategyProfile
+}
+
+// Seed implements Solver.
+func (c *OnlineOutcomeSamplingCFR) Seed(seed int64) {
+	c.rng.Seed(seed)
+}
+
+// Run performs one iteration of online outcome sampling CFR, updating the regrets of
+// one player (in turn), and returns the sampled value of the game for the
+// first player.
 func (c *OnlineOutcomeSamplingCFR) Run(node GameTreeNode) float32 {
 	iter := c.strategyProfile.Iter()
-	c.traversingPlayer = int(iter % 2)
+	c.nPlayers = NumPlayers(node)
+	c.traversingPlayer = iter % c.nPlayers
 	c.sampledActions = c.mapPool.alloc()
 	defer c.mapPool.free(c.sampledActions)
-	return c.runHelper(node, node.Player(), 1.0)
+	ev := c.runHelper(node, 1.0)
+	defer c.slicePool.free(ev)
+	return ev[0]
 }
 
-func (c *OnlineOutcomeSamplingCFR) runHelper(node GameTreeNode, lastPlayer int, sampleProb float32) float32 {
-	var ev float32
+// runHelper returns the sampled counterfactual utility of each player at the
+// given node. The returned slice must be freed by the caller.
+func (c *OnlineOutcomeSamplingCFR) runHelper(node GameTreeNode, sampleProb float32) []float32 {
+	var ev []float32
 	switch node.Type() {
 	case TerminalNodeType:
-		ev = float32(node.Utility(lastPlayer)) / sampleProb
+		ev = c.slicePool.alloc(c.nPlayers)
+		getUtilities(node, ev)
+		f32.ScalUnitary(1.0/sampleProb, ev)
 	case ChanceNodeType:
-		ev = c.handleChanceNode(node, lastPlayer, sampleProb)
+		ev = c.handleChanceNode(node, sampleProb)
 	default:
-		sgn := getSign(lastPlayer, node.Player())
-		ev = sgn * c.handlePlayerNode(node, sampleProb)
+		ev = c.handlePlayerNode(node, sampleProb)
 	}
 
 	node.Close()
 	return ev
 }
 
-func (c *OnlineOutcomeSamplingCFR) handleChanceNode(node GameTreeNode, lastPlayer int, sampleProb float32) float32 {
-	child, _ := node.SampleChild()
+func (c *OnlineOutcomeSamplingCFR) handleChanceNode(node GameTreeNode, sampleProb float32) []float32 {
+	child, _ := SampleChanceNode(c.rng, node)
 	// Sampling probabilities cancel out in the calculation of counterfactual value.
-	return c.runHelper(child, lastPlayer, sampleProb)
+	return c.runHelper(child, sampleProb)
 }
 
-func (c *OnlineOutcomeSamplingCFR) handlePlayerNode(node GameTreeNode, sampleProb float32) float32 {
+func (c *OnlineOutcomeSamplingCFR) handlePlayerNode(node GameTreeNode, sampleProb float32) []float32 {
 	if node.Player() == c.traversingPlayer {
 		return c.handleTraversingPlayerNode(node, sampleProb)
 	} else {
@@ -66,13 +87,13 @@ func (c *OnlineOutcomeSamplingCFR) handlePlayerNode(node GameTreeNode, samplePro
 	}
 }
 
-func (c *OnlineOutcomeSamplingCFR) handleTraversingPlayerNode(node GameTreeNode, sampleProb float32) float32 {
+func (c *OnlineOutcomeSamplingCFR) handleTraversingPlayerNode(node GameTreeNode, sampleProb float32) []float32 {
 	player := node.Player()
 	nChildren := node.NumChildren()
 	if nChildren == 1 {
 		// Optimization to skip trivial nodes with no real choice.
 		child := node.GetChild(0)
-		return c.runHelper(child, player, sampleProb)
+		return c.runHelper(child, sampleProb)
 	}
 
 	policy := c.strategyProfile.GetPolicy(node)
@@ -83,21 +104,24 @@ func (c *OnlineOutcomeSamplingCFR) handleTraversingPlayerNode(node GameTreeNode,
 	oldSampledActions := c.sampledActions
 	c.sampledActions = c.mapPool.alloc()
 	strategy := policy.GetStrategy()
+	ev := c.slicePool.alloc(c.nPlayers)
 	for i, q := range qs {
 		child := node.GetChild(i)
-		var util float32
 		if q > 0 {
+			var childEV []float32
 			if isNew {
-				util = c.randomRollout(child, player, q*sampleProb)
+				childEV = c.randomRollout(child, player, q*sampleProb)
 			} else {
-				util = c.runHelper(child, player, q*sampleProb)
+				childEV = c.runHelper(child, q*sampleProb)
 			}
-		}
 
-		regrets[i] = util
+			regrets[i] = childEV[player]
+			f32.AxpyUnitary(strategy[i], childEV, ev)
+			c.slicePool.free(childEV)
+		}
 	}
 
-	cfValue := f32.DotUnitary(strategy, regrets)
+	cfValue := ev[player]
 	f32.AddConst(-cfValue, regrets)
 	policy.AddRegret(1.0/sampleProb, qs, regrets)
 
@@ -105,10 +129,13 @@ func (c *OnlineOutcomeSamplingCFR) handleTraversingPlayerNode(node GameTreeNode,
 	c.slicePool.free(regrets)
 	c.mapPool.free(c.sampledActions)
 	c.sampledActions = oldSampledActions
-	return cfValue
+	return ev
 }
 
-func (c *OnlineOutcomeSamplingCFR) randomRollout(node GameTreeNode, player int, sampleProb float32) float32 {
+// randomRollout returns the utility of each player at a terminal node reached
+// by sampling actions uniformly at random, weighted by the inverse probability
+// of the given player's actions. The returned slice must be freed by the caller.
+func (c *OnlineOutcomeSamplingCFR) randomRollout(node GameTreeNode, player int, sampleProb float32) []float32 {
 	x := float64(1.0)
 	for node.Type() != TerminalNodeType {
 		nChildren := node.NumChildren()
@@ -121,12 +148,15 @@ func (c *OnlineOutcomeSamplingCFR) randomRollout(node GameTreeNode, player int,
 		defer node.Close()
 	}
 
-	return float32(x * node.Utility(player))
+	ev := c.slicePool.alloc(c.nPlayers)
+	getUtilities(node, ev)
+	f32.ScalUnitary(float32(x), ev)
+	return ev
 }
 
 // Sample player action according to strategy, do not update policy.
 // Save selected action so that they are reused if this infoset is hit again.
-func (c *OnlineOutcomeSamplingCFR) handleSampledPlayerNode(node GameTreeNode, sampleProb float32) float32 {
+func (c *OnlineOutcomeSamplingCFR) handleSampledPlayerNode(node GameTreeNode, sampleProb float32) []float32 {
 	policy := c.strategyProfile.GetPolicy(node)
 
 	// Update average strategy for this node.
@@ -138,5 +168,5 @@ func (c *OnlineOutcomeSamplingCFR) handleSampledPlayerNode(node GameTreeNode, sa
 	// Sampling probabilities cancel out in the calculation of counterfactual value,
 	// so we don't include them here.
 	child := node.GetChild(getOrSample(c.sampledActions, node, policy, c.rng))
-	return c.runHelper(child, node.Player(), sampleProb)
+	return c.runHelper(child, sampleProb)
 }
@@ -0,0 +1,58 @@
+package cfr
+
+import (
+	"math/rand"
+	"sync"
+)
+
+// ParallelMCCFR runs many MCCFR traversals in parallel against a shared
+// StrategyProfile, which must be safe for concurrent use
+// (such as ConcurrentPolicyTable).
+//
+// Each worker has its own MCCFR instance, Sampler and random number generator,
+// since none of them are safe for concurrent use.
+type ParallelMCCFR struct {
+	workers []*MCCFR
+}
+
+// NewParallelMCCFR creates a new ParallelMCCFR with the given number of workers.
+// Each worker's random number generator is seeded from rng, and newSampler is
+// called once per worker to create its Sampler.
+//
+// Note that because the workers update the StrategyProfile concurrently,
+// runs are not reproducible even for a fixed seed.
+func NewParallelMCCFR(rng *rand.Rand, strategyProfile StrategyProfile, newSampler func(rng *rand.Rand) Sampler, nWorkers int) *ParallelMCCFR {
+	workers := make([]*MCCFR, nWorkers)
+	for i := range workers {
+		workerRng := rand.New(rand.NewSource(rng.Int63()))
+		workers[i] = NewMCCFR(workerRng, strategyProfile, newSampler(workerRng))
+	}
+
+	return &ParallelMCCFR{workers}
+}
+
+// Run performs one MCCFR traversal in each worker and returns the mean
+// of their values.
+//
+// GameTreeNodes are not safe for concurrent traversal, so newGame is
+// called once per worker to create the root of a separate game tree.
+func (c *ParallelMCCFR) Run(newGame func() GameTreeNode) float32 {
+	var wg sync.WaitGroup
+	values := make([]float32, len(c.workers))
+	for i, worker := range c.workers {
+		wg.Add(1)
+		go func(i int, worker *MCCFR) {
+			values[i] = worker.Run(newGame())
+			wg.Done()
+		}(i, worker)
+	}
+
+	wg.Wait()
+
+	var total float32
+	for _, v := range values {
+		total += v
+	}
+
+	return total / float32(len(values))
+}
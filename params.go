@@ -13,6 +13,17 @@ type DiscountParams struct {
 	DiscountAlpha         float32 // Discounted CFR
 	DiscountBeta          float32 // Discounted CFR
 	DiscountGamma         float32 // Discounted CFR
+
+	// Predictive regret matching uses the instantaneous regret of the last
+	// iteration as a prediction of the next, and adds it to the accumulated
+	// regret when computing the next strategy. Combined with
+	// UseRegretMatchingPlus this is Predictive CFR+ (PCFR+).
+	// See: https://arxiv.org/abs/2007.14358
+	Predictive bool
+	// PredictionWeight scales the predicted regret (optimistic regret matching).
+	// If zero it defaults to 1 when Predictive is set. A non-zero PredictionWeight
+	// enables prediction even if Predictive is not set.
+	PredictionWeight float32
 }
 
 // Gets the discount factors as configured by the parameters for the
@@ -53,3 +64,16 @@ func (p DiscountParams) GetDiscountFactors(iter int) (positive, negative, sum fl
 
 	return
 }
+
+// GetPredictionWeight returns the weight with which the last instantaneous
+// regret is added to the accumulated regret when computing the next strategy.
+// It is zero if predictive regret matching is not enabled.
+func (p DiscountParams) GetPredictionWeight() float32 {
+	if p.PredictionWeight != 0 {
+		return p.PredictionWeight
+	} else if p.Predictive {
+		return 1.0
+	}
+
+	return 0.0
+}
@@ -20,8 +20,9 @@ func init() {
 // PolicyTable implements traditional (tabular) CFR by storing accumulated
 // regrets and strategy sums for each InfoSet, which is looked up by its Key().
 type PolicyTable struct {
-	params DiscountParams
-	iter   int
+	params    DiscountParams
+	minimizer RegretMinimizer
+	iter      int
 
 	// Map of InfoSet Key -> the policy for that infoset.
 	policiesByKey map[string]*policy.Policy
@@ -30,8 +31,16 @@ type PolicyTable struct {
 
 // NewPolicyTable creates a new PolicyTable with the given DiscountParams.
 func NewPolicyTable(params DiscountParams) *PolicyTable {
+	return NewPolicyTableWithRegretMinimizer(params, nil)
+}
+
+// NewPolicyTableWithRegretMinimizer creates a new PolicyTable that uses the given
+// RegretMinimizer to compute the strategy at each InfoSet. If rm is nil,
+// regret matching is used.
+func NewPolicyTableWithRegretMinimizer(params DiscountParams, rm RegretMinimizer) *PolicyTable {
 	return &PolicyTable{
 		params:        params,
+		minimizer:     rm,
 		iter:          1,
 		policiesByKey: make(map[string]*policy.Policy),
 		mayNeedUpdate: make(map[*policy.Policy]struct{}),
@@ -41,9 +50,9 @@ func NewPolicyTable(params DiscountParams) *PolicyTable {
 // Update performs regret matching for all nodes within this strategy profile that have
 // been touched since the lapt call to Update().
 func (pt *PolicyTable) Update() {
-	discountPos, discountNeg, discountSum := pt.params.GetDiscountFactors(pt.iter)
+	params := pt.params.updateParams(pt.iter, pt.minimizer)
 	for p := range pt.mayNeedUpdate {
-		p.NextStrategy(discountPos, discountNeg, discountSum)
+		p.NextStrategy(params)
 		delete(pt.mayNeedUpdate, p)
 	}
 
@@ -106,6 +115,12 @@ func (pt *PolicyTable) UnmarshalBinary(buf []byte) error {
 		pt.policiesByKey[key] = &p
 	}
 
+	rm, err := decodeRegretMinimizer(dec)
+	if err != nil {
+		return err
+	}
+
+	pt.minimizer = rm
 	pt.mayNeedUpdate = make(map[*policy.Policy]struct{})
 	return nil
 }
@@ -136,5 +151,9 @@ func (pt *PolicyTable) MarshalBinary() ([]byte, error) {
 		}
 	}
 
+	if err := encodeRegretMinimizer(enc, pt.minimizer); err != nil {
+		return nil, err
+	}
+
 	return buf.Bytes(), nil
 }
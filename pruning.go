@@ -0,0 +1,229 @@
+package cfr
+
+// PruningParams configure regret-based pruning, in which actions with
+// negative accumulated regret are temporarily not traversed.
+// See Brown & Sandholm, "Regret-Based Pruning in Extensive-Form Games" (NIPS 2015).
+//
+// Actions are pruned at an InfoSet for a whole iteration, and only if they
+// are not played by the current strategy, so pruning does not change the
+// values of the other actions. While an action is pruned, an upper bound on
+// the regret it could have accumulated (had its value been MaxUtility) is
+// kept for it, and it remains pruned as long as its regret plus this bound
+// is below Threshold. With a Threshold of zero, an action is therefore only
+// pruned while its regret could not possibly have become positive.
+//
+// When the action is next traversed, the bound is added to its regret to
+// catch up for the skipped iterations. Brown & Sandholm catch up with the
+// value of a best response in the pruned subtree to the opponents' average
+// strategy over the skipped iterations, which is tighter but requires another
+// traversal. Both overestimate the regret of the skipped iterations, so pruned
+// actions recover at least as quickly as without pruning. The bounds are kept
+// by the solver and are not saved with the StrategyProfile.
+//
+// In addition, vanilla CFR skips subtrees which no player reaches (such as
+// one that follows a zero-probability action, when the opponent's reach is
+// already zero in a two-player game), since no regrets or strategy weights
+// are updated in them.
+//
+// Pruning requires that the NodePolicies of the StrategyProfile implement
+// RegretSumPolicy, as those of PolicyTable and ConcurrentPolicyTable do.
+// MCCFR also requires an ExhaustiveSampler, such as external sampling.
+// An empty PruningParams disables pruning.
+type PruningParams struct {
+	// Enabled turns on regret-based pruning.
+	Enabled bool
+	// An upper bound on the utility of any player at any terminal node.
+	MaxUtility float32
+	// Actions are pruned while their accumulated regret, plus the bound on
+	// the regret they may have accumulated while pruned, is below Threshold.
+	// It should not be positive.
+	Threshold float32
+	// Pruning is not performed during the first WarmupIterations.
+	WarmupIterations int
+	// If positive, pruning is not performed on every RevisitInterval'th
+	// iteration, so that the regrets of pruned actions are updated exactly.
+	// For solvers that update one player per iteration (MCCFR), this
+	// counts the iterations of each player.
+	RevisitInterval int
+}
+
+// RegretSumPolicy may optionally be implemented by NodePolicies that
+// maintain a table of accumulated regrets.
+type RegretSumPolicy interface {
+	// GetRegretSum returns the accumulated regret of each action.
+	GetRegretSum() []float32
+}
+
+// ExhaustiveSampler may optionally be implemented by Samplers that traverse
+// every action of the nodes they are used at, as external sampling does.
+// MCCFR only prunes with such Samplers, since otherwise the values of its
+// nodes are scaled by their sampling probabilities, but the bounds on the
+// regret of pruned actions are not.
+type ExhaustiveSampler interface {
+	// SamplesAllActions returns true if Sample always returns a
+	// probability of 1 for every action.
+	SamplesAllActions() bool
+}
+
+// isExhaustive returns true if the Sampler traverses every action.
+func isExhaustive(sampler Sampler) bool {
+	es, ok := sampler.(ExhaustiveSampler)
+	return ok && es.SamplesAllActions()
+}
+
+// isActive returns true if actions should be pruned on the given iteration.
+// Solvers that update one player per iteration pass the number of players,
+// so that the revisits of each player fall on its own iterations.
+func (p PruningParams) isActive(iter, nPlayers int) bool {
+	if !p.Enabled || iter <= p.WarmupIterations {
+		return false
+	}
+
+	return p.RevisitInterval <= 0 || (iter/nPlayers)%p.RevisitInterval != 0
+}
+
+// pruner keeps the pruned actions of each InfoSet for a solver.
+type pruner struct {
+	params   PruningParams
+	iter     int
+	active   bool
+	infoSets map[string]*prunedActions
+}
+
+func newPruner(params PruningParams) *pruner {
+	return &pruner{
+		params:   params,
+		infoSets: make(map[string]*prunedActions),
+	}
+}
+
+// start begins a new iteration, and sets whether actions are pruned on it.
+func (p *pruner) start(iter, nPlayers int) {
+	p.iter++
+	p.active = p.params.isActive(iter, nPlayers)
+}
+
+// prunedActions are the actions of an InfoSet that are pruned on the current
+// iteration, and the bounds on the regret of the iterations they skipped.
+type prunedActions struct {
+	maxUtility float32
+	iter       int // The iteration on which pruned was decided.
+	pruned     []bool
+	// Upper bound on the (weighted) regret that each action may have
+	// accumulated since it was last traversed.
+	bounds []float32
+	// The bound that each action has yet to catch up for, on the first
+	// visit of the current iteration that adds regret.
+	catchUp []float32
+}
+
+// prune returns the actions to prune at the given node, which are decided on
+// the first visit to its InfoSet in each iteration. It returns nil if no
+// actions of the InfoSet have been pruned.
+func (p *pruner) prune(node GameTreeNode, policy NodePolicy, strategy []float32) *prunedActions {
+	if !p.params.Enabled {
+		return nil
+	}
+
+	key := node.InfoSetKey(node.Player())
+	a := p.infoSets[string(key)]
+	if a != nil && a.iter == p.iter {
+		return a
+	}
+
+	var regretSum []float32
+	if p.active {
+		regretSum = getRegretSum(policy)
+	}
+
+	if a == nil {
+		if !anyPruned(regretSum, strategy, p.params.Threshold) {
+			return nil
+		}
+
+		a = &prunedActions{
+			maxUtility: p.params.MaxUtility,
+			pruned:     make([]bool, len(strategy)),
+			bounds:     make([]float32, len(strategy)),
+			catchUp:    make([]float32, len(strategy)),
+		}
+		p.infoSets[string(key)] = a
+	}
+
+	a.iter = p.iter
+	for i := range strategy {
+		a.pruned[i] = regretSum != nil && strategy[i] == 0 &&
+			regretSum[i]+a.bounds[i] < p.params.Threshold
+		if !a.pruned[i] {
+			a.catchUp[i] += a.bounds[i]
+			a.bounds[i] = 0
+		}
+	}
+
+	return a
+}
+
+// anyPruned returns true if any action with the given regrets would be
+// pruned without a bound on its skipped iterations.
+func anyPruned(regretSum, strategy []float32, threshold float32) bool {
+	for i, r := range regretSum {
+		if strategy[i] == 0 && r < threshold {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getRegretSum returns the accumulated regrets of the given policy, or nil
+// if they are not available and so no actions may be pruned.
+func getRegretSum(policy NodePolicy) []float32 {
+	if rp, ok := policy.(RegretSumPolicy); ok {
+		return rp.GetRegretSum()
+	}
+
+	return nil
+}
+
+// isPruned returns true if the ith action should not be traversed.
+func (a *prunedActions) isPruned(i int) bool {
+	return a != nil && a.pruned[i]
+}
+
+// update adjusts the instantaneous regrets of a visit before they are added
+// to the policy with weight w, given the value of the node to its player.
+// The regret of pruned actions is zeroed, and instead the bound on it is
+// accumulated. The accumulated bound of an action that was traversed is
+// added to its regret.
+func (a *prunedActions) update(regrets []float32, w, value float32) {
+	if a == nil {
+		return
+	}
+
+	for i := range regrets {
+		if a.pruned[i] {
+			regrets[i] = 0
+			a.bounds[i] += w * (a.maxUtility - value)
+		} else if a.catchUp[i] != 0 && w > 0 {
+			regrets[i] += a.catchUp[i] / w
+			a.catchUp[i] = 0
+		}
+	}
+}
+
+// isReached returns false if no player reaches a node with the given reach
+// probabilities, in which case no regrets or strategy weights are updated
+// in its subtree.
+func isReached(reach []float32, reachChance float32) bool {
+	if reachChance == 0 {
+		return false
+	}
+
+	for _, r := range reach {
+		if r != 0 {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,106 @@
+package cfr_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/timpalpant/go-cfr"
+	"github.com/timpalpant/go-cfr/exploitability"
+	"github.com/timpalpant/go-cfr/kuhn"
+	"github.com/timpalpant/go-cfr/sampling"
+)
+
+// countingNode counts the number of nodes that are traversed below it,
+// which are closed by the solver once they have been visited.
+type countingNode struct {
+	cfr.GameTreeNode
+	nTraversed *int
+}
+
+func (n countingNode) GetChild(i int) cfr.GameTreeNode {
+	return countingNode{n.GameTreeNode.GetChild(i), n.nTraversed}
+}
+
+func (n countingNode) SampleChild() (cfr.GameTreeNode, float64) {
+	child, p := n.GameTreeNode.SampleChild()
+	return countingNode{child, n.nTraversed}, p
+}
+
+func (n countingNode) Close() {
+	*n.nTraversed++
+	n.GameTreeNode.Close()
+}
+
+func TestPruning(t *testing.T) {
+	testCases := map[string]struct {
+		newSolver func(policy cfr.StrategyProfile, pruning cfr.PruningParams) cfr.Solver
+		pruning   cfr.PruningParams
+		nIter     int
+		// Maximum percentage of the unpruned traversals made with pruning.
+		// The subtrees of Kuhn poker are too small for sampled traversals
+		// to prune many of them.
+		maxTraversed int
+	}{
+		"cfr": {
+			newSolver: func(policy cfr.StrategyProfile, pruning cfr.PruningParams) cfr.Solver {
+				return cfr.NewWithPruning(policy, pruning)
+			},
+			pruning:      cfr.PruningParams{Enabled: true, MaxUtility: 2, Threshold: -10, WarmupIterations: 100, RevisitInterval: 20},
+			nIter:        2000,
+			maxTraversed: 90,
+		},
+		"mccfr": {
+			newSolver: func(policy cfr.StrategyProfile, pruning cfr.PruningParams) cfr.Solver {
+				rng := rand.New(rand.NewSource(123))
+				return cfr.NewMCCFRWithPruning(rng, policy, sampling.NewExternalSampler(), pruning)
+			},
+			pruning:      cfr.PruningParams{Enabled: true, MaxUtility: 2, Threshold: -20, WarmupIterations: 1000},
+			nIter:        20000,
+			maxTraversed: 99,
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			train := func(pruning cfr.PruningParams) (int, float64) {
+				var nTraversed int
+				root := countingNode{kuhn.NewGame(), &nTraversed}
+				policy := cfr.NewPolicyTable(cfr.DiscountParams{})
+				opt := tc.newSolver(policy, pruning)
+				for i := 0; i < tc.nIter; i++ {
+					opt.Run(root)
+					policy.Update()
+				}
+
+				return nTraversed, exploitability.Exploitability(kuhn.NewGame(), policy)
+			}
+
+			nUnpruned, unprunedExploitability := train(cfr.PruningParams{})
+			nPruned, prunedExploitability := train(tc.pruning)
+			t.Logf("traversed %d nodes without pruning (exploitability %.4f), %d with pruning (%.4f)",
+				nUnpruned, unprunedExploitability, nPruned, prunedExploitability)
+			if nPruned >= nUnpruned*tc.maxTraversed/100 {
+				t.Errorf("expected pruning to skip traversals, traversed %d of %d nodes", nPruned, nUnpruned)
+			}
+
+			if prunedExploitability > 0.01 {
+				t.Errorf("expected exploitability < 0.01, got %v", prunedExploitability)
+			}
+		})
+	}
+}
+
+func TestPruning_OutcomeSampler(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("expected panic pruning with an outcome sampler")
+		}
+	}()
+
+	rng := rand.New(rand.NewSource(123))
+	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
+	pruning := cfr.PruningParams{Enabled: true, MaxUtility: 2, Threshold: -20, WarmupIterations: 1000}
+	opt := cfr.NewMCCFRWithPruning(rng, policy, sampling.NewOutcomeSampler(rng, 0.6), pruning)
+	opt.Run(kuhn.NewGame())
+}
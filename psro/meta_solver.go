@@ -0,0 +1,229 @@
+package psro
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/timpalpant/go-cfr/seqform"
+)
+
+// MetaSolver computes a mixed strategy for each player over its population
+// of policies, given the payoffs of the meta-game.
+type MetaSolver interface {
+	Solve(payoffs *PayoffTensor) [][]float64
+}
+
+// Uniform is a MetaSolver that mixes uniformly over each population.
+// PSRO with a Uniform meta-solver is equivalent to fictitious play.
+type Uniform struct{}
+
+// Solve implements MetaSolver.
+func (u Uniform) Solve(payoffs *PayoffTensor) [][]float64 {
+	result := make([][]float64, payoffs.NumPlayers())
+	for player, n := range payoffs.Shape() {
+		result[player] = uniformDist(n)
+	}
+
+	return result
+}
+
+// RegretMatching is a MetaSolver that returns the average strategies of
+// regret matching in self-play on the meta-game. It may be used for games
+// with any number of players, including general-sum games.
+type RegretMatching struct {
+	// Number of iterations of regret matching. Defaults to 1000.
+	Iterations int
+}
+
+// Solve implements MetaSolver.
+func (rm RegretMatching) Solve(payoffs *PayoffTensor) [][]float64 {
+	nIter := rm.Iterations
+	if nIter <= 0 {
+		nIter = 1000
+	}
+
+	shape := payoffs.Shape()
+	strategies := make([][]float64, len(shape))
+	regrets := make([][]float64, len(shape))
+	averages := make([][]float64, len(shape))
+	for player, n := range shape {
+		strategies[player] = uniformDist(n)
+		regrets[player] = make([]float64, n)
+		averages[player] = make([]float64, n)
+	}
+
+	for t := 0; t < nIter; t++ {
+		for player := range strategies {
+			addScaled(averages[player], 1.0, strategies[player])
+		}
+
+		// Simultaneous updates: all players respond to the current strategies.
+		utilities := actionUtilities(payoffs, strategies)
+		for player, u := range utilities {
+			var ev float64
+			for i, p := range strategies[player] {
+				ev += p * u[i]
+			}
+
+			for i := range u {
+				regrets[player][i] += u[i] - ev
+			}
+		}
+
+		for player := range strategies {
+			regretMatching(regrets[player], strategies[player])
+		}
+	}
+
+	for _, avg := range averages {
+		normalize(avg)
+	}
+
+	return averages
+}
+
+// actionUtilities returns the expected utility to each player of playing
+// each policy in its population, when all other players play the given strategies.
+func actionUtilities(payoffs *PayoffTensor, strategies [][]float64) [][]float64 {
+	result := make([][]float64, len(strategies))
+	for player, s := range strategies {
+		result[player] = make([]float64, len(s))
+	}
+
+	forEachIndex(payoffs.Shape(), func(indices []int) {
+		u := payoffs.Get(indices)
+		for player, i := range indices {
+			p := 1.0
+			for opponent, j := range indices {
+				if opponent != player {
+					p *= strategies[opponent][j]
+				}
+			}
+
+			result[player][i] += p * u[player]
+		}
+	})
+
+	return result
+}
+
+func regretMatching(regrets, strategy []float64) {
+	var total float64
+	for i, r := range regrets {
+		strategy[i] = 0
+		if r > 0 {
+			strategy[i] = r
+			total += r
+		}
+	}
+
+	if total > 0 {
+		for i := range strategy {
+			strategy[i] /= total
+		}
+	} else {
+		copy(strategy, uniformDist(len(strategy)))
+	}
+}
+
+// LinearProgram is a MetaSolver that computes an exact Nash equilibrium of a
+// two-player zero-sum meta-game by linear programming. It panics if the
+// meta-game does not have two players.
+type LinearProgram struct{}
+
+// Solve implements MetaSolver.
+func (lp LinearProgram) Solve(payoffs *PayoffTensor) [][]float64 {
+	shape := payoffs.Shape()
+	if len(shape) != 2 {
+		panic(fmt.Errorf("linear program meta-solver requires two players, got %d", len(shape)))
+	}
+
+	// The payoff matrix of each player, indexed by [own policy][opponent policy].
+	a := make([][][]float64, 2)
+	for player := range a {
+		a[player] = make([][]float64, shape[player])
+		for i := range a[player] {
+			a[player][i] = make([]float64, shape[1-player])
+		}
+	}
+
+	forEachIndex(shape, func(indices []int) {
+		u := payoffs.Get(indices)
+		a[0][indices[0]][indices[1]] = u[0]
+		a[1][indices[1]][indices[0]] = u[1]
+	})
+
+	return [][]float64{maxMin(a[0]), maxMin(a[1])}
+}
+
+// maxMin returns the mixed strategy x that maximizes min_j sum_i x_i a_ij.
+//
+// With the payoffs shifted so that c_ji = k - a_ij > 0, x is proportional to
+// the solution of the linear program
+//
+//	maximize sum_i y_i  subject to  sum_i c_ji y_i <= 1 for all j,  y >= 0
+func maxMin(a [][]float64) []float64 {
+	m, n := len(a), len(a[0])
+	k := math.Inf(-1)
+	for _, row := range a {
+		for _, x := range row {
+			k = math.Max(k, x)
+		}
+	}
+
+	k += 1.0
+
+	// Variables: y, and slacks (one per column of a).
+	nVars := m + n
+	rows := make([][]float64, n)
+	b := make([]float64, n)
+	for j := range rows {
+		rows[j] = make([]float64, nVars)
+		for i := 0; i < m; i++ {
+			rows[j][i] = k - a[i][j]
+		}
+
+		rows[j][m+j] = 1.0
+		b[j] = 1.0
+	}
+
+	c := make([]float64, nVars)
+	for i := 0; i < m; i++ {
+		c[i] = 1.0
+	}
+
+	y, _, err := seqform.Simplex(rows, b, c)
+	if err != nil {
+		panic(err)
+	}
+
+	result := y[:m]
+	for i, p := range result {
+		if p < 0 {
+			result[i] = 0
+		}
+	}
+
+	normalize(result)
+	return result
+}
+
+func uniformDist(n int) []float64 {
+	result := make([]float64, n)
+	for i := range result {
+		result[i] = 1.0 / float64(n)
+	}
+
+	return result
+}
+
+func normalize(v []float64) {
+	var total float64
+	for _, x := range v {
+		total += x
+	}
+
+	for i := range v {
+		v[i] /= total
+	}
+}
@@ -0,0 +1,290 @@
+package psro
+
+import (
+	"math/rand"
+
+	"github.com/timpalpant/go-cfr"
+	"github.com/timpalpant/go-cfr/exploitability"
+	"github.com/timpalpant/go-cfr/mcts"
+	"github.com/timpalpant/go-cfr/sampling"
+)
+
+// Oracle computes a new policy for a player in response to the populations
+// and meta-strategies of the other players.
+type Oracle interface {
+	NewPolicy(rng *rand.Rand, player int, opponents *Opponents) Policy
+}
+
+// Opponents are the populations of policies of each player,
+// and the meta-strategy with which each player mixes over its population.
+type Opponents struct {
+	root           cfr.GameTreeNode
+	populations    [][]Policy
+	metaStrategies [][]float64
+}
+
+// Root returns the root node of the game.
+func (o *Opponents) Root() cfr.GameTreeNode {
+	return o.root
+}
+
+// Sample returns one policy for each player, sampled from its population
+// according to its meta-strategy.
+func (o *Opponents) Sample(rng *rand.Rand) []Policy {
+	result := make([]Policy, len(o.populations))
+	for player, population := range o.populations {
+		x := rng.Float64()
+		var cumProb float64
+		result[player] = population[len(population)-1]
+		for i, p := range o.metaStrategies[player] {
+			cumProb += p
+			if cumProb > x {
+				result[player] = population[i]
+				break
+			}
+		}
+	}
+
+	return result
+}
+
+// Aggregate returns a StrategyProfile in which each player plays the mixture
+// of the policies in its population according to its meta-strategy.
+//
+// The behavioral strategy at each InfoSet weights each policy by the meta-strategy
+// and the probability with which the policy plays to reach the InfoSet, so that it
+// is equivalent to selecting a policy at the start of the game. This requires
+// walking the full game tree.
+func (o *Opponents) Aggregate() *cfr.FixedStrategyProfile {
+	reach := make([][]float64, len(o.populations))
+	for player, population := range o.populations {
+		reach[player] = make([]float64, len(population))
+		for i := range reach[player] {
+			reach[player][i] = 1.0
+		}
+	}
+
+	strategies := make(map[string][]float64)
+	o.aggregate(o.root, reach, strategies)
+
+	profile := cfr.NewFixedStrategyProfile()
+	for key, s := range strategies {
+		normalize(s)
+		strategy := make([]float32, len(s))
+		for i, p := range s {
+			strategy[i] = float32(p)
+		}
+
+		profile.SetStrategy([]byte(key), strategy)
+	}
+
+	return profile
+}
+
+func (o *Opponents) aggregate(node cfr.GameTreeNode, reach [][]float64, strategies map[string][]float64) {
+	switch node.Type() {
+	case cfr.TerminalNodeType:
+	case cfr.ChanceNodeType:
+		for i := 0; i < node.NumChildren(); i++ {
+			o.aggregate(node.GetChild(i), reach, strategies)
+		}
+	default:
+		player := node.Player()
+		population := o.populations[player]
+		policies := make([][]float32, len(population))
+		for k, policy := range population {
+			policies[k] = policy.GetPolicy(node)
+		}
+
+		// Assuming perfect recall, the player's own reach probability under each
+		// policy is the same for all nodes in the InfoSet.
+		key := string(node.InfoSetKey(player))
+		if _, ok := strategies[key]; !ok {
+			strategies[key] = o.mix(player, policies, reach[player])
+		}
+
+		ownReach := reach[player]
+		for i := 0; i < node.NumChildren(); i++ {
+			childReach := make([]float64, len(ownReach))
+			for k, pv := range policies {
+				childReach[k] = ownReach[k] * float64(pv[i])
+			}
+
+			reach[player] = childReach
+			o.aggregate(node.GetChild(i), reach, strategies)
+		}
+
+		reach[player] = ownReach
+	}
+
+	node.Close()
+}
+
+// mix returns the (unnormalized) mixture of the given policies of a player,
+// weighted by the player's meta-strategy and its reach probability under each.
+func (o *Opponents) mix(player int, policies [][]float32, reach []float64) []float64 {
+	result := make([]float64, len(policies[0]))
+	var total float64
+	for k, pv := range policies {
+		w := o.metaStrategies[player][k] * reach[k]
+		total += w
+		for i, p := range pv {
+			result[i] += w * float64(p)
+		}
+	}
+
+	if total == 0 {
+		// InfoSet is not reached by any policy with positive weight.
+		for k, pv := range policies {
+			for i, p := range pv {
+				result[i] += o.metaStrategies[player][k] * float64(p)
+			}
+		}
+	}
+
+	return result
+}
+
+// NewGame returns the root of a single-agent view of the game for the given player,
+// in which the nodes of all other players are chance nodes that play according to
+// policies sampled from their populations with Sample.
+//
+// Any solver may be used on the returned game to train a response for the player.
+// A new game should be created for each iteration or simulation, so that a new
+// set of opponent policies is sampled.
+func (o *Opponents) NewGame(rng *rand.Rand, player int) cfr.GameTreeNode {
+	return &responseNode{
+		GameTreeNode: o.root,
+		player:       player,
+		opponents:    o.Sample(rng),
+	}
+}
+
+// responseNode wraps a GameTreeNode so that the nodes of all players other than
+// the responding player are chance nodes, which play according to fixed policies.
+type responseNode struct {
+	cfr.GameTreeNode
+	player    int
+	opponents []Policy
+	parent    *responseNode
+
+	probs []float32
+}
+
+func (n *responseNode) isOpponentNode() bool {
+	return n.GameTreeNode.Type() == cfr.PlayerNodeType && n.GameTreeNode.Player() != n.player
+}
+
+// Type implements cfr.GameTreeNode.
+func (n *responseNode) Type() cfr.NodeType {
+	if n.isOpponentNode() {
+		return cfr.ChanceNodeType
+	}
+
+	return n.GameTreeNode.Type()
+}
+
+// GetChild implements cfr.GameTreeNode.
+func (n *responseNode) GetChild(i int) cfr.GameTreeNode {
+	return &responseNode{
+		GameTreeNode: n.GameTreeNode.GetChild(i),
+		player:       n.player,
+		opponents:    n.opponents,
+		parent:       n,
+	}
+}
+
+// Parent implements cfr.GameTreeNode.
+func (n *responseNode) Parent() cfr.GameTreeNode {
+	if n.parent == nil {
+		return nil
+	}
+
+	return n.parent
+}
+
+// GetChildProbability implements cfr.GameTreeNode.
+func (n *responseNode) GetChildProbability(i int) float64 {
+	if n.isOpponentNode() {
+		if n.probs == nil {
+			n.probs = n.opponents[n.GameTreeNode.Player()].GetPolicy(n.GameTreeNode)
+		}
+
+		return float64(n.probs[i])
+	}
+
+	return n.GameTreeNode.GetChildProbability(i)
+}
+
+// SampleChild implements cfr.GameTreeNode.
+func (n *responseNode) SampleChild() (cfr.GameTreeNode, float64) {
+	return sampling.SampleChanceNode(n)
+}
+
+// NumPlayers implements cfr.MultiPlayerNode.
+func (n *responseNode) NumPlayers() int {
+	return cfr.NumPlayers(n.GameTreeNode)
+}
+
+// BestResponseOracle is an Oracle that computes an exact best response to the
+// aggregate mixture of the opponents' populations. PSRO with a BestResponseOracle
+// and LinearProgram meta-solver is the double oracle algorithm.
+//
+// It requires walking the full game tree, so is only tractable for small games.
+type BestResponseOracle struct{}
+
+// NewPolicy implements Oracle.
+func (o BestResponseOracle) NewPolicy(rng *rand.Rand, player int, opponents *Opponents) Policy {
+	aggregate := exploitability.AverageStrategy(opponents.Aggregate())
+	return exploitability.NewBestResponse(opponents.Root(), player, aggregate)
+}
+
+// CFROracle is an Oracle that trains a response by running a CFR solver
+// on the single-agent game returned by Opponents.NewGame.
+// The new policy is the average strategy of the trained StrategyProfile.
+type CFROracle struct {
+	// NewSolver returns the Solver to train with, such as cfr.New.
+	NewSolver      func(rng *rand.Rand, profile cfr.StrategyProfile) cfr.Solver
+	DiscountParams cfr.DiscountParams
+	// Number of iterations in which the responding player is updated.
+	Iterations int
+	// Alternating must be set if the solver updates one player per iteration
+	// (Iter() % nPlayers), as the Monte Carlo solvers do, so that only the
+	// responding player's iterations are counted. The other iterations are
+	// still run, since they update its average strategy.
+	Alternating bool
+}
+
+// NewPolicy implements Oracle.
+func (o CFROracle) NewPolicy(rng *rand.Rand, player int, opponents *Opponents) Policy {
+	profile := cfr.NewPolicyTable(o.DiscountParams)
+	solver := o.NewSolver(rng, profile)
+	nPlayers := cfr.NumPlayers(opponents.Root())
+	for i := 0; i < o.Iterations; {
+		if !o.Alternating || profile.Iter()%nPlayers == player {
+			i++
+		}
+
+		solver.Run(opponents.NewGame(rng, player))
+		profile.Update()
+	}
+
+	return exploitability.AverageStrategy(profile)
+}
+
+// SmoothUCTOracle is an Oracle that trains a response with Smooth UCT
+// on the single-agent game returned by Opponents.NewGame.
+type SmoothUCTOracle struct {
+	C, Gamma, Eta, D, Temperature float32
+	Simulations                   int
+}
+
+// NewPolicy implements Oracle.
+func (o SmoothUCTOracle) NewPolicy(rng *rand.Rand, player int, opponents *Opponents) Policy {
+	search := mcts.NewSmoothUCT(o.C, o.Gamma, o.Eta, o.D, o.Temperature)
+	for i := 0; i < o.Simulations; i++ {
+		search.Run(rng, opponents.NewGame(rng, player))
+	}
+
+	return search
+}
@@ -0,0 +1,127 @@
+package psro
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/timpalpant/go-cfr"
+	"github.com/timpalpant/go-cfr/sampling"
+)
+
+// PayoffTensor holds the payoffs of the meta-game for each combination of
+// policies from the populations of each player.
+type PayoffTensor struct {
+	shape   []int
+	entries map[string][]float64
+}
+
+func newPayoffTensor(nPlayers int) *PayoffTensor {
+	return &PayoffTensor{
+		shape:   make([]int, nPlayers),
+		entries: make(map[string][]float64),
+	}
+}
+
+// NumPlayers returns the number of players in the meta-game.
+func (t *PayoffTensor) NumPlayers() int {
+	return len(t.shape)
+}
+
+// Shape returns the number of policies in the population of each player.
+func (t *PayoffTensor) Shape() []int {
+	return t.shape
+}
+
+// Get returns the expected utility of each player when each player p plays
+// the indices[p]'th policy of its population, or nil if it is not known.
+func (t *PayoffTensor) Get(indices []int) []float64 {
+	return t.entries[tensorKey(indices)]
+}
+
+func (t *PayoffTensor) set(indices []int, utilities []float64) {
+	t.entries[tensorKey(indices)] = utilities
+}
+
+func tensorKey(indices []int) string {
+	return fmt.Sprint(indices)
+}
+
+// forEachIndex calls fn with every combination of indices into a tensor
+// with the given shape. The indices slice is reused between calls.
+func forEachIndex(shape []int, fn func(indices []int)) {
+	for _, n := range shape {
+		if n == 0 {
+			return
+		}
+	}
+
+	indices := make([]int, len(shape))
+	for {
+		fn(indices)
+
+		// Increment indices, with the last index varying fastest.
+		i := len(indices) - 1
+		for ; i >= 0; i-- {
+			indices[i]++
+			if indices[i] < shape[i] {
+				break
+			}
+
+			indices[i] = 0
+		}
+
+		if i < 0 {
+			return
+		}
+	}
+}
+
+// expectedUtilities walks the game tree and returns the expected utility
+// of each player when each player plays the given policy.
+func expectedUtilities(node cfr.GameTreeNode, profile []Policy) []float64 {
+	ev := make([]float64, len(profile))
+	switch node.Type() {
+	case cfr.TerminalNodeType:
+		for player := range ev {
+			ev[player] = node.Utility(player)
+		}
+	case cfr.ChanceNodeType:
+		for i := 0; i < node.NumChildren(); i++ {
+			p := node.GetChildProbability(i)
+			addScaled(ev, p, expectedUtilities(node.GetChild(i), profile))
+		}
+	default:
+		pv := profile[node.Player()].GetPolicy(node)
+		for i, p := range pv {
+			if p > 0 {
+				addScaled(ev, float64(p), expectedUtilities(node.GetChild(i), profile))
+			}
+		}
+	}
+
+	node.Close()
+	return ev
+}
+
+// simulate plays one game in which each player plays the given policy,
+// and returns the utility of each player.
+func simulate(rng *rand.Rand, node cfr.GameTreeNode, profile []Policy) []float64 {
+	var result []float64
+	switch node.Type() {
+	case cfr.TerminalNodeType:
+		result = make([]float64, len(profile))
+		for player := range result {
+			result[player] = node.Utility(player)
+		}
+	case cfr.ChanceNodeType:
+		child, _ := cfr.SampleChanceNode(rng, node)
+		result = simulate(rng, child, profile)
+	default:
+		pv := profile[node.Player()].GetPolicy(node)
+		i := sampling.SampleOne(pv, rng.Float32())
+		result = simulate(rng, node.GetChild(i), profile)
+	}
+
+	node.Close()
+	return result
+}
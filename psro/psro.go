@@ -0,0 +1,166 @@
+// Package psro implements Policy-Space Response Oracles (PSRO), a
+// generalization of the double oracle algorithm to extensive-form games.
+//
+// PSRO maintains a population of policies for each player. On each iteration,
+// the meta-game between the populations is solved for a mixed strategy over
+// each population (by a MetaSolver), and an Oracle computes a new policy for
+// each player that responds to the meta-strategies of the other players.
+// The payoffs of the meta-game are estimated by simulating games between
+// the policies of each population.
+//
+// See: Lanctot et al., "A Unified Game-Theoretic Approach to Multiagent
+// Reinforcement Learning" (NIPS 2017).
+package psro
+
+import (
+	"math/rand"
+
+	"github.com/timpalpant/go-cfr"
+)
+
+// Policy is a fixed strategy that returns the vector of action probabilities
+// to play at a given node. It is equivalent to mcts.Policy.
+type Policy interface {
+	GetPolicy(node cfr.GameTreeNode) []float32
+}
+
+// Params configure how PSRO estimates the payoffs of the meta-game.
+type Params struct {
+	// Number of games simulated to estimate the payoffs of each combination
+	// of policies. If zero, the expected payoffs are computed exactly by
+	// walking the full game tree.
+	NumSimulations int
+}
+
+// PSRO implements Policy-Space Response Oracles.
+type PSRO struct {
+	rng        *rand.Rand
+	root       cfr.GameTreeNode
+	oracle     Oracle
+	metaSolver MetaSolver
+	params     Params
+
+	populations    [][]Policy
+	payoffs        *PayoffTensor
+	metaStrategies [][]float64
+}
+
+// New creates a new PSRO for the game rooted at the given node. The population
+// of each player is initialized with the given policy for that player.
+func New(rng *rand.Rand, root cfr.GameTreeNode, initial []Policy, oracle Oracle, metaSolver MetaSolver, params Params) *PSRO {
+	p := &PSRO{
+		rng:         rng,
+		root:        root,
+		oracle:      oracle,
+		metaSolver:  metaSolver,
+		params:      params,
+		populations: make([][]Policy, len(initial)),
+		payoffs:     newPayoffTensor(len(initial)),
+	}
+
+	for player, policy := range initial {
+		p.populations[player] = []Policy{policy}
+	}
+
+	p.updatePayoffs()
+	p.metaStrategies = p.metaSolver.Solve(p.payoffs)
+	return p
+}
+
+// Iterate performs one iteration of PSRO: a new policy is computed by the Oracle
+// for each player in response to the current meta-strategies, and then the
+// meta-game including the new policies is solved.
+func (p *PSRO) Iterate() {
+	newPolicies := make([]Policy, len(p.populations))
+	for player := range newPolicies {
+		newPolicies[player] = p.oracle.NewPolicy(p.rng, player, p.Opponents())
+	}
+
+	for player, policy := range newPolicies {
+		p.populations[player] = append(p.populations[player], policy)
+	}
+
+	p.updatePayoffs()
+	p.metaStrategies = p.metaSolver.Solve(p.payoffs)
+}
+
+// Populations returns the current population of policies of each player.
+func (p *PSRO) Populations() [][]Policy {
+	return p.populations
+}
+
+// Payoffs returns the current payoff tensor of the meta-game.
+func (p *PSRO) Payoffs() *PayoffTensor {
+	return p.payoffs
+}
+
+// MetaStrategies returns the current mixed strategy of each player
+// over its population of policies.
+func (p *PSRO) MetaStrategies() [][]float64 {
+	return p.metaStrategies
+}
+
+// Opponents returns the current populations and meta-strategies.
+func (p *PSRO) Opponents() *Opponents {
+	return &Opponents{
+		root:           p.root,
+		populations:    p.populations,
+		metaStrategies: p.metaStrategies,
+	}
+}
+
+// StrategyProfile returns a StrategyProfile that plays the mixture of each
+// player's population according to its current meta-strategy.
+// See Opponents.Aggregate.
+func (p *PSRO) StrategyProfile() *cfr.FixedStrategyProfile {
+	return p.Opponents().Aggregate()
+}
+
+// updatePayoffs estimates the payoffs of all combinations of policies
+// that are not yet in the payoff tensor.
+func (p *PSRO) updatePayoffs() {
+	shape := make([]int, len(p.populations))
+	for player, population := range p.populations {
+		shape[player] = len(population)
+	}
+
+	profile := make([]Policy, len(p.populations))
+	forEachIndex(shape, func(indices []int) {
+		if p.payoffs.Get(indices) != nil {
+			return
+		}
+
+		for player, i := range indices {
+			profile[player] = p.populations[player][i]
+		}
+
+		p.payoffs.set(indices, p.evaluate(profile))
+	})
+
+	p.payoffs.shape = shape
+}
+
+// evaluate returns the expected utility of each player when each player
+// plays the given policy.
+func (p *PSRO) evaluate(profile []Policy) []float64 {
+	if p.params.NumSimulations <= 0 {
+		return expectedUtilities(p.root, profile)
+	}
+
+	result := make([]float64, len(profile))
+	for i := 0; i < p.params.NumSimulations; i++ {
+		addScaled(result, 1.0, simulate(p.rng, p.root, profile))
+	}
+
+	for i := range result {
+		result[i] /= float64(p.params.NumSimulations)
+	}
+
+	return result
+}
+
+func addScaled(dst []float64, alpha float64, x []float64) {
+	for i, v := range x {
+		dst[i] += alpha * v
+	}
+}
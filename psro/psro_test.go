@@ -0,0 +1,159 @@
+package psro
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/timpalpant/go-cfr"
+	"github.com/timpalpant/go-cfr/exploitability"
+	"github.com/timpalpant/go-cfr/kuhn"
+	"github.com/timpalpant/go-cfr/sampling"
+)
+
+type uniformPolicy struct{}
+
+func (u uniformPolicy) GetPolicy(node cfr.GameTreeNode) []float32 {
+	n := node.NumChildren()
+	result := make([]float32, n)
+	for i := range result {
+		result[i] = 1.0 / float32(n)
+	}
+
+	return result
+}
+
+func newKuhnPSRO(oracle Oracle, metaSolver MetaSolver, params Params) *PSRO {
+	rng := rand.New(rand.NewSource(123))
+	root := kuhn.NewGame()
+	initial := []Policy{uniformPolicy{}, uniformPolicy{}}
+	return New(rng, root, initial, oracle, metaSolver, params)
+}
+
+func TestDoubleOracle(t *testing.T) {
+	root := kuhn.NewGame()
+	p := newKuhnPSRO(BestResponseOracle{}, LinearProgram{}, Params{})
+	initial := exploitability.Compute(root, p.StrategyProfile()).Exploitability
+	for i := 1; i <= 10; i++ {
+		p.Iterate()
+		result := exploitability.Compute(root, p.StrategyProfile())
+		t.Logf("[iter=%d] exploitability: %.4f, value: %.4f", i, result.Exploitability, result.Values[0])
+	}
+
+	final := exploitability.Compute(root, p.StrategyProfile())
+	if final.Exploitability > 0.01 {
+		t.Errorf("expected exploitability < 0.01 (initial: %v), got %v", initial, final.Exploitability)
+	}
+
+	if math.Abs(final.Values[0]+1.0/18) > 0.01 {
+		t.Errorf("expected value -1/18, got %v", final.Values[0])
+	}
+}
+
+func TestRegretMatching_RockPaperScissors(t *testing.T) {
+	payoffs := newPayoffTensor(2)
+	payoffs.shape = []int{3, 3}
+	rps := [][]float64{
+		{0, -1, 1},
+		{1, 0, -1},
+		{-1, 1, 0},
+	}
+
+	for i, row := range rps {
+		for j, u := range row {
+			payoffs.set([]int{i, j}, []float64{u, -u})
+		}
+	}
+
+	strategies := RegretMatching{Iterations: 10000}.Solve(payoffs)
+	for player, s := range strategies {
+		for i, p := range s {
+			if math.Abs(p-1.0/3) > 0.01 {
+				t.Errorf("player %d: expected uniform strategy, got %v at %d", player, p, i)
+			}
+		}
+	}
+
+	lpStrategies := LinearProgram{}.Solve(payoffs)
+	for player, s := range lpStrategies {
+		for i, p := range s {
+			if math.Abs(p-1.0/3) > 1e-6 {
+				t.Errorf("player %d: expected uniform strategy, got %v at %d", player, p, i)
+			}
+		}
+	}
+}
+
+func TestSimulatedPayoffs(t *testing.T) {
+	p := newKuhnPSRO(BestResponseOracle{}, Uniform{}, Params{NumSimulations: 20000})
+	p.Iterate()
+
+	exact := newKuhnPSRO(BestResponseOracle{}, Uniform{}, Params{})
+	exact.populations = p.Populations()
+	exact.payoffs = newPayoffTensor(2)
+	exact.updatePayoffs()
+
+	forEachIndex(p.Payoffs().Shape(), func(indices []int) {
+		simulated := p.Payoffs().Get(indices)
+		expected := exact.Payoffs().Get(indices)
+		for player := range expected {
+			if math.Abs(simulated[player]-expected[player]) > 0.05 {
+				t.Errorf("%v: expected payoff %v for player %d, got %v",
+					indices, expected[player], player, simulated[player])
+			}
+		}
+	})
+}
+
+func TestCFROracle(t *testing.T) {
+	oracle := CFROracle{
+		NewSolver: func(rng *rand.Rand, profile cfr.StrategyProfile) cfr.Solver {
+			return cfr.New(profile)
+		},
+		DiscountParams: cfr.DiscountParams{UseRegretMatchingPlus: true, LinearWeighting: true},
+		Iterations:     100,
+	}
+
+	testOracle(t, oracle, 0.1)
+}
+
+func TestCFROracle_MCCFR(t *testing.T) {
+	oracle := CFROracle{
+		NewSolver: func(rng *rand.Rand, profile cfr.StrategyProfile) cfr.Solver {
+			return cfr.NewMCCFR(rng, profile, sampling.NewExternalSampler())
+		},
+		DiscountParams: cfr.DiscountParams{UseRegretMatchingPlus: true, LinearWeighting: true},
+		Iterations:     100,
+		Alternating:    true,
+	}
+
+	testOracle(t, oracle, 0.1)
+}
+
+func TestSmoothUCTOracle(t *testing.T) {
+	oracle := SmoothUCTOracle{
+		C:           2,
+		Gamma:       0.1,
+		Eta:         0.9,
+		D:           0.001,
+		Temperature: 0.1,
+		Simulations: 20000,
+	}
+
+	testOracle(t, oracle, 0.2)
+}
+
+func testOracle(t *testing.T, oracle Oracle, maxExploitability float64) {
+	root := kuhn.NewGame()
+	p := newKuhnPSRO(oracle, LinearProgram{}, Params{})
+	initial := exploitability.Compute(root, p.StrategyProfile()).Exploitability
+	for i := 1; i <= 10; i++ {
+		p.Iterate()
+	}
+
+	final := exploitability.Compute(root, p.StrategyProfile()).Exploitability
+	t.Logf("exploitability: %.4f -> %.4f", initial, final)
+	if final > maxExploitability {
+		t.Errorf("expected exploitability < %v, got %v", maxExploitability, final)
+	}
+}
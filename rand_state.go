@@ -0,0 +1,48 @@
+package cfr
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+
+	"github.com/timpalpant/go-cfr/internal/randutil"
+)
+
+// randState implements RandStateSolver for the sampling Solvers in this
+// package. The Source of their random number generator is set by NewSolver.
+type randState struct {
+	src *randutil.Source
+}
+
+func (r *randState) setRandSource(src *randutil.Source) {
+	r.src = src
+}
+
+// MarshalRandState implements RandStateSolver.
+func (r *randState) MarshalRandState() ([]byte, error) {
+	if r.src == nil {
+		return nil, nil
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(r.src.State()); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalRandState implements RandStateSolver.
+func (r *randState) UnmarshalRandState(buf []byte) error {
+	if r.src == nil {
+		return fmt.Errorf("state of the random number generator cannot be restored: solver was not constructed by NewSolver")
+	}
+
+	var state randutil.State
+	if err := gob.NewDecoder(bytes.NewReader(buf)).Decode(&state); err != nil {
+		return err
+	}
+
+	r.src.SetState(state)
+	return nil
+}
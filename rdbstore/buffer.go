@@ -10,6 +10,7 @@ import (
 	rocksdb "github.com/tecbot/gorocksdb"
 
 	"github.com/timpalpant/go-cfr/deepcfr"
+	"github.com/timpalpant/go-cfr/internal/randutil"
 )
 
 // ReservoirBuffer implements a reservoir sampling buffer in which samples are
@@ -22,22 +23,28 @@ type ReservoirBuffer struct {
 	db      *rocksdb.DB
 	maxSize int
 
-	mx sync.Mutex
-	n  int
+	mx  sync.Mutex
+	n   int
+	src *randutil.Source
+	rng *rand.Rand
 }
 
 // NewReservoirBuffer returns a new ReservoirBuffer with the given max number of samples,
-// backed by a LevelDB database at the given directory path.
-func NewReservoirBuffer(params Params, maxSize int) (*ReservoirBuffer, error) {
+// backed by a LevelDB database at the given directory path. The random number
+// generator used for reservoir sampling is seeded from rng.
+func NewReservoirBuffer(rng *rand.Rand, params Params, maxSize int) (*ReservoirBuffer, error) {
 	db, err := rocksdb.OpenDb(params.Options, params.Path)
 	if err != nil {
 		return nil, err
 	}
 
+	src := randutil.NewSource(rng.Int63())
 	return &ReservoirBuffer{
 		params:  params,
 		db:      db,
 		maxSize: maxSize,
+		src:     src,
+		rng:     rand.New(src),
 	}, nil
 }
 
@@ -56,7 +63,7 @@ func (b *ReservoirBuffer) AddSample(s deepcfr.Sample) {
 	if b.n <= b.maxSize {
 		b.putSample(b.n-1, s)
 	} else {
-		m := rand.Intn(b.n)
+		m := b.rng.Intn(b.n)
 		if m < b.maxSize {
 			b.putSample(m, s)
 		}
@@ -112,17 +119,15 @@ func (b *ReservoirBuffer) putSample(idx int, s deepcfr.Sample) {
 	}
 }
 
-// GetSamples implements deepcfr.Buffer.
+// GetSamples implements deepcfr.Buffer. It reads all samples into memory;
+// use Iterate or Shuffle for large buffers.
 func (b *ReservoirBuffer) GetSamples() []deepcfr.Sample {
-	it := b.db.NewIterator(b.params.ReadOptions)
+	it := b.Iterate()
 	defer it.Close()
 
 	var samples []deepcfr.Sample
-	for it.SeekToFirst(); it.Valid(); it.Next() {
-		sample := mustDecodeSample(it.Value().Data())
-		samples = append(samples, sample)
-		it.Key().Free()
-		it.Value().Free()
+	for it.Next() {
+		samples = append(samples, it.Sample())
 	}
 
 	if err := it.Err(); err != nil {
@@ -132,8 +137,101 @@ func (b *ReservoirBuffer) GetSamples() []deepcfr.Sample {
 	return samples
 }
 
+// Iterate implements deepcfr.StreamingBuffer. Samples are decoded one at a
+// time from a database iterator, in the order of their keys.
+func (b *ReservoirBuffer) Iterate() deepcfr.SampleIterator {
+	it := b.db.NewIterator(b.params.ReadOptions)
+	it.SeekToFirst()
+	return &sampleIterator{it: it}
+}
+
+// Shuffle implements deepcfr.StreamingBuffer. Only a random permutation of the
+// sample indices is held in memory; each sample is read from the database as it
+// is visited.
+func (b *ReservoirBuffer) Shuffle(rng *rand.Rand) deepcfr.SampleIterator {
+	b.mx.Lock()
+	n := b.n
+	if n > b.maxSize {
+		n = b.maxSize
+	}
+	b.mx.Unlock()
+
+	return &shuffledIterator{
+		b:     b,
+		order: rng.Perm(n),
+	}
+}
+
+type sampleIterator struct {
+	it      *rocksdb.Iterator
+	sample  deepcfr.Sample
+	started bool
+}
+
+func (s *sampleIterator) Next() bool {
+	if s.started {
+		s.it.Next()
+	}
+
+	s.started = true
+	if !s.it.Valid() {
+		return false
+	}
+
+	value := s.it.Value()
+	s.sample = mustDecodeSample(value.Data())
+	value.Free()
+	return true
+}
+
+func (s *sampleIterator) Sample() deepcfr.Sample {
+	return s.sample
+}
+
+func (s *sampleIterator) Err() error {
+	return s.it.Err()
+}
+
+func (s *sampleIterator) Close() error {
+	s.it.Close()
+	return nil
+}
+
+type shuffledIterator struct {
+	b      *ReservoirBuffer
+	order  []int
+	sample deepcfr.Sample
+}
+
+func (s *shuffledIterator) Next() bool {
+	if len(s.order) == 0 {
+		return false
+	}
+
+	s.sample = s.b.GetSample(s.order[0])
+	s.order = s.order[1:]
+	return true
+}
+
+func (s *shuffledIterator) Sample() deepcfr.Sample {
+	return s.sample
+}
+
+func (s *shuffledIterator) Err() error {
+	return nil
+}
+
+func (s *shuffledIterator) Close() error {
+	return nil
+}
+
 // MarshalBinary implements encoding.BinaryMarshaler.
+// The state of the random number generator is saved,
+// so that a restored buffer samples the same as this one from now on.
 func (b *ReservoirBuffer) MarshalBinary() ([]byte, error) {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
 	var buf bytes.Buffer
 	enc := gob.NewEncoder(&buf)
 
@@ -149,6 +247,10 @@ func (b *ReservoirBuffer) MarshalBinary() ([]byte, error) {
 		return nil, err
 	}
 
+	if err := enc.Encode(b.src.State()); err != nil {
+		return nil, err
+	}
+
 	return buf.Bytes(), nil
 }
 
@@ -172,6 +274,11 @@ func (b *ReservoirBuffer) UnmarshalBinary(buf []byte) error {
 		return err
 	}
 
+	src, err := randutil.Decode(dec)
+	if err != nil {
+		return err
+	}
+
 	b.params.Options.SetCreateIfMissing(false)
 	db, err := rocksdb.OpenDb(b.params.Options, b.params.Path)
 	if err != nil {
@@ -179,6 +286,8 @@ func (b *ReservoirBuffer) UnmarshalBinary(buf []byte) error {
 	}
 
 	b.db = db
+	b.src = src
+	b.rng = rand.New(src)
 	return nil
 }
 
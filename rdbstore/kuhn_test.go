@@ -29,6 +29,26 @@ func TestVanilla(t *testing.T) {
 	testCFR(t, opt, policy, 1000)
 }
 
+func TestRegretMinimizer(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "cfr-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	params := DefaultParams(tmpDir)
+	defer params.Close()
+	rm := cfr.Hedge{LearningRate: 1.0, Decay: 0.5}
+	policy, err := NewWithRegretMinimizer(params, cfr.DiscountParams{}, rm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer policy.Close()
+
+	opt := cfr.New(policy)
+	testCFR(t, opt, policy, 1000)
+}
+
 func BenchmarkVanilla(b *testing.B) {
 	tmpDir, err := ioutil.TempDir("", "cfr-test-")
 	if err != nil {
@@ -54,11 +74,7 @@ type logger interface {
 	Logf(string, ...interface{})
 }
 
-type cfrImpl interface {
-	Run(cfr.GameTreeNode) float32
-}
-
-func testCFR(t *testing.T, opt cfrImpl, policy cfr.StrategyProfile, nIter int) {
+func testCFR(t *testing.T, opt cfr.Solver, policy cfr.StrategyProfile, nIter int) {
 	root := runCFR(t, opt, policy, nIter)
 	seen := make(map[string]struct{})
 	tree.Visit(root, func(node cfr.GameTreeNode) {
@@ -66,7 +82,7 @@ func testCFR(t *testing.T, opt cfrImpl, policy cfr.StrategyProfile, nIter int) {
 			return
 		}
 
-		key := node.InfoSet(node.Player()).Key()
+		key := string(node.InfoSet(node.Player()).Key())
 		if _, ok := seen[key]; ok {
 			return
 		}
@@ -80,7 +96,7 @@ func testCFR(t *testing.T, opt cfrImpl, policy cfr.StrategyProfile, nIter int) {
 	})
 }
 
-func runCFR(log logger, opt cfrImpl, policy cfr.StrategyProfile, nIter int) cfr.GameTreeNode {
+func runCFR(log logger, opt cfr.Solver, policy cfr.StrategyProfile, nIter int) cfr.GameTreeNode {
 	root := kuhn.NewGame()
 	var expectedValue float32
 	for i := 1; i <= nIter; i++ {
@@ -3,6 +3,7 @@ package rdbstore
 import (
 	"bytes"
 	"encoding/gob"
+	"io"
 
 	rocksdb "github.com/tecbot/gorocksdb"
 
@@ -22,6 +23,7 @@ func init() {
 type PolicyTable struct {
 	params    Params
 	discounts cfr.DiscountParams
+	minimizer cfr.RegretMinimizer
 
 	db            *rocksdb.DB
 	iter          int
@@ -30,6 +32,13 @@ type PolicyTable struct {
 
 // New creates a new PolicyTable backed by a LevelDB database at the given path.
 func New(params Params, discounts cfr.DiscountParams) (*PolicyTable, error) {
+	return NewWithRegretMinimizer(params, discounts, nil)
+}
+
+// NewWithRegretMinimizer creates a new PolicyTable backed by a LevelDB database
+// at the given path, that uses the given RegretMinimizer to compute the strategy
+// at each InfoSet. If rm is nil, regret matching is used.
+func NewWithRegretMinimizer(params Params, discounts cfr.DiscountParams, rm cfr.RegretMinimizer) (*PolicyTable, error) {
 	db, err := rocksdb.OpenDb(params.Options, params.Path)
 	if err != nil {
 		return nil, err
@@ -38,6 +47,7 @@ func New(params Params, discounts cfr.DiscountParams) (*PolicyTable, error) {
 	return &PolicyTable{
 		params:        params,
 		discounts:     discounts,
+		minimizer:     rm,
 		db:            db,
 		iter:          1,
 		mayNeedUpdate: make(map[string]struct{}),
@@ -61,6 +71,10 @@ func (pt *PolicyTable) MarshalBinary() ([]byte, error) {
 		return nil, err
 	}
 
+	if err := enc.Encode(regretMinimizerValue{pt.minimizer}); err != nil {
+		return nil, err
+	}
+
 	return buf.Bytes(), nil
 }
 
@@ -85,6 +99,15 @@ func (pt *PolicyTable) UnmarshalBinary(buf []byte) error {
 		return err
 	}
 
+	// Tables serialized before regret minimizers were configurable
+	// do not have one, and use the default (nil).
+	var rm regretMinimizerValue
+	if err := dec.Decode(&rm); err != nil && err != io.EOF {
+		return err
+	}
+
+	pt.minimizer = rm.RegretMinimizer
+
 	pt.params.Options.SetCreateIfMissing(false)
 	db, err := rocksdb.OpenDb(pt.params.Options, pt.params.Path)
 	if err != nil {
@@ -109,10 +132,18 @@ func (pt *PolicyTable) Iter() int {
 // Update implements cfr.StrategyProfile.
 func (pt *PolicyTable) Update() {
 	discountPos, discountNeg, discountSum := pt.discounts.GetDiscountFactors(pt.iter)
+	params := policy.UpdateParams{
+		Iter:                   pt.iter,
+		DiscountPositiveRegret: discountPos,
+		DiscountNegativeRegret: discountNeg,
+		DiscountStrategySum:    discountSum,
+		PredictionWeight:       pt.discounts.GetPredictionWeight(),
+		RegretMinimizer:        pt.minimizer,
+	}
 
 	for key := range pt.mayNeedUpdate {
 		p := pt.getPolicyByKey([]byte(key))
-		p.NextStrategy(discountPos, discountNeg, discountSum)
+		p.NextStrategy(params)
 
 		lPolicy := &ldbPolicy{
 			Policy: p,
@@ -164,6 +195,12 @@ func (pt *PolicyTable) getPolicyByKey(key []byte) *policy.Policy {
 	return nil
 }
 
+// regretMinimizerValue wraps a (possibly nil) RegretMinimizer so that it
+// can be gob-encoded.
+type regretMinimizerValue struct {
+	RegretMinimizer cfr.RegretMinimizer
+}
+
 // ldbPolicy implements cfr.NodePolicy, with all updates immediately persisted
 // to the underlying LevelDB database.
 type ldbPolicy struct {
@@ -0,0 +1,242 @@
+package cfr
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+
+	"github.com/timpalpant/go-cfr/internal/randutil"
+)
+
+// SamplerConfig specifies a registered Sampler and its parameters.
+type SamplerConfig struct {
+	Name   string
+	Params map[string]float64
+}
+
+// SolverConfig specifies a registered Solver variant and its parameters.
+// It is intended to be loaded from a configuration file, for example
+// to sweep over algorithms in an experiment.
+type SolverConfig struct {
+	Variant string
+	// The Sampler used by Monte Carlo variants. For VRMCCFR, this is
+	// used to sample the actions of the traversing player.
+	Sampler SamplerConfig
+	// The Sampler used by VRMCCFR to sample the actions of the
+	// non-traversing players. If empty, Sampler is used.
+	NotTraversingSampler SamplerConfig
+	// By default, VRMCCFR assumes a two-player game in which the utilities
+	// of the players sum to UtilitySum (zero for a zero-sum game). If
+	// GeneralSum is set, it makes no assumption about the utilities.
+	GeneralSum     bool
+	UtilitySum     float32
+	DiscountParams DiscountParams
+	// Regret-based pruning, used by the "cfr" variant and by the "mccfr"
+	// variant with the external sampler.
+	Pruning PruningParams
+	// Seed for the random number generator shared by the solver and its samplers.
+	Seed int64
+}
+
+// SolverFactory constructs a Solver that updates the given StrategyProfile,
+// and uses the given random number generator.
+type SolverFactory func(rng *rand.Rand, profile StrategyProfile, config SolverConfig) (Solver, error)
+
+// ProfileFactory constructs the StrategyProfile that is used by NewSolver
+// for a Solver variant.
+type ProfileFactory func(config SolverConfig) StrategyProfile
+
+// SamplerFactory constructs a Sampler with the given parameters,
+// that uses the given random number generator.
+type SamplerFactory func(rng *rand.Rand, params map[string]float64) (Sampler, error)
+
+var (
+	registryMx       sync.Mutex
+	solverFactories  = make(map[string]SolverFactory)
+	profileFactories = make(map[string]ProfileFactory)
+	samplerFactories = make(map[string]SamplerFactory)
+)
+
+// RegisterSolver makes a Solver variant available to NewSolver by name.
+// It panics if a variant is registered twice with the same name.
+func RegisterSolver(variant string, factory SolverFactory) {
+	registryMx.Lock()
+	defer registryMx.Unlock()
+	if _, ok := solverFactories[variant]; ok {
+		panic(fmt.Errorf("solver %q is already registered", variant))
+	}
+
+	solverFactories[variant] = factory
+}
+
+// RegisterStrategyProfile sets the StrategyProfile that NewSolver creates for
+// a Solver variant that does not use a PolicyTable.
+// It panics if a StrategyProfile is registered twice for the same variant.
+func RegisterStrategyProfile(variant string, factory ProfileFactory) {
+	registryMx.Lock()
+	defer registryMx.Unlock()
+	if _, ok := profileFactories[variant]; ok {
+		panic(fmt.Errorf("strategy profile for %q is already registered", variant))
+	}
+
+	profileFactories[variant] = factory
+}
+
+// RegisterSampler makes a Sampler available to NewSampler by name.
+// It panics if a Sampler is registered twice with the same name.
+//
+// The samplers in package sampling are registered when it is imported.
+func RegisterSampler(name string, factory SamplerFactory) {
+	registryMx.Lock()
+	defer registryMx.Unlock()
+	if _, ok := samplerFactories[name]; ok {
+		panic(fmt.Errorf("sampler %q is already registered", name))
+	}
+
+	samplerFactories[name] = factory
+}
+
+// Solvers returns the sorted names of all registered Solver variants.
+func Solvers() []string {
+	registryMx.Lock()
+	defer registryMx.Unlock()
+	var result []string
+	for variant := range solverFactories {
+		result = append(result, variant)
+	}
+
+	sort.Strings(result)
+	return result
+}
+
+// Samplers returns the sorted names of all registered Samplers.
+func Samplers() []string {
+	registryMx.Lock()
+	defer registryMx.Unlock()
+	var result []string
+	for name := range samplerFactories {
+		result = append(result, name)
+	}
+
+	sort.Strings(result)
+	return result
+}
+
+// NewSolver constructs the Solver described by the given config,
+// with a new StrategyProfile from NewStrategyProfile.
+func NewSolver(config SolverConfig) (Solver, error) {
+	return NewSolverWithProfile(NewStrategyProfile(config), config)
+}
+
+// NewStrategyProfile returns a new StrategyProfile for the Solver described
+// by the given config. This is the StrategyProfile registered for the variant
+// with RegisterStrategyProfile, or otherwise a new PolicyTable using
+// config.DiscountParams.
+func NewStrategyProfile(config SolverConfig) StrategyProfile {
+	registryMx.Lock()
+	factory, ok := profileFactories[config.Variant]
+	registryMx.Unlock()
+	if ok {
+		return factory(config)
+	}
+
+	return NewPolicyTable(config.DiscountParams)
+}
+
+// NewSolverWithProfile constructs the Solver described by the given config
+// that updates the given StrategyProfile. Its random number generator is
+// seeded from config.Seed, and if the Solver implements RandStateSolver
+// its state can be saved.
+func NewSolverWithProfile(profile StrategyProfile, config SolverConfig) (Solver, error) {
+	registryMx.Lock()
+	factory, ok := solverFactories[config.Variant]
+	registryMx.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown solver: %q (registered: %v)", config.Variant, Solvers())
+	}
+
+	src := randutil.NewSource(config.Seed)
+	solver, err := factory(rand.New(src), profile, config)
+	if err != nil {
+		return nil, err
+	}
+
+	if rs, ok := solver.(interface{ setRandSource(*randutil.Source) }); ok {
+		rs.setRandSource(src)
+	}
+
+	return solver, nil
+}
+
+// NewSampler constructs the Sampler described by the given config.
+func NewSampler(rng *rand.Rand, config SamplerConfig) (Sampler, error) {
+	registryMx.Lock()
+	factory, ok := samplerFactories[config.Name]
+	registryMx.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown sampler: %q (registered: %v)", config.Name, Samplers())
+	}
+
+	return factory(rng, config.Params)
+}
+
+func newSampledSolver(newSolver func(*rand.Rand, StrategyProfile, Sampler) Solver) SolverFactory {
+	return func(rng *rand.Rand, profile StrategyProfile, config SolverConfig) (Solver, error) {
+		sampler, err := NewSampler(rng, config.Sampler)
+		if err != nil {
+			return nil, err
+		}
+
+		return newSolver(rng, profile, sampler), nil
+	}
+}
+
+func init() {
+	RegisterSolver("cfr", func(rng *rand.Rand, profile StrategyProfile, config SolverConfig) (Solver, error) {
+		return NewWithPruning(profile, config.Pruning), nil
+	})
+	RegisterSolver("chance-sampling", func(rng *rand.Rand, profile StrategyProfile, config SolverConfig) (Solver, error) {
+		return NewChanceSampling(rng, profile), nil
+	})
+	RegisterSolver("mccfr", func(rng *rand.Rand, profile StrategyProfile, config SolverConfig) (Solver, error) {
+		sampler, err := NewSampler(rng, config.Sampler)
+		if err != nil {
+			return nil, err
+		}
+
+		if config.Pruning.Enabled && !isExhaustive(sampler) {
+			return nil, fmt.Errorf("pruning requires the external sampler, got %q", config.Sampler.Name)
+		}
+
+		return NewMCCFRWithPruning(rng, profile, sampler, config.Pruning), nil
+	})
+	RegisterSolver("generalized-sampling", newSampledSolver(func(rng *rand.Rand, profile StrategyProfile, sampler Sampler) Solver {
+		return NewGeneralizedSampling(rng, profile, sampler)
+	}))
+	RegisterSolver("online-outcome-sampling", newSampledSolver(func(rng *rand.Rand, profile StrategyProfile, sampler Sampler) Solver {
+		return NewOnlineOutcomeSamplingCFR(rng, profile, sampler)
+	}))
+	RegisterSolver("vr-mccfr", func(rng *rand.Rand, profile StrategyProfile, config SolverConfig) (Solver, error) {
+		traversingSampler, err := NewSampler(rng, config.Sampler)
+		if err != nil {
+			return nil, err
+		}
+
+		notTraversingConfig := config.NotTraversingSampler
+		if notTraversingConfig.Name == "" {
+			notTraversingConfig = config.Sampler
+		}
+
+		notTraversingSampler, err := NewSampler(rng, notTraversingConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		if config.GeneralSum {
+			return NewGeneralSumVRMCCFR(rng, profile, traversingSampler, notTraversingSampler), nil
+		}
+
+		return NewConstantSumVRMCCFR(rng, profile, traversingSampler, notTraversingSampler, config.UtilitySum), nil
+	})
+}
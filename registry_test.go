@@ -0,0 +1,85 @@
+package cfr_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/timpalpant/go-cfr"
+	"github.com/timpalpant/go-cfr/exploitability"
+	"github.com/timpalpant/go-cfr/kuhn"
+	_ "github.com/timpalpant/go-cfr/sampling"
+	_ "github.com/timpalpant/go-cfr/xfp"
+)
+
+// Each solver configuration, as it would be loaded from a configuration file,
+// and the exploitability it should reach on Kuhn poker.
+var solverTestCases = []struct {
+	config            string
+	maxExploitability float64
+}{
+	{`{"Variant": "cfr", "DiscountParams": {"UseRegretMatchingPlus": true}}`, 0.002},
+	{`{"Variant": "chance-sampling"}`, 0.015},
+	{`{"Variant": "mccfr", "Sampler": {"Name": "external"}}`, 0.025},
+	{`{"Variant": "mccfr", "Sampler": {"Name": "outcome", "Params": {"explorationEps": 0.6}}}`, 0.05},
+	{`{"Variant": "mccfr", "Sampler": {"Name": "multi-outcome", "Params": {"k": 2, "explorationEps": 0.6}}}`, 0.025},
+	{`{"Variant": "mccfr", "Sampler": {"Name": "average-strategy", "Params": {"epsilon": 0.05, "tau": 1000, "beta": 1000000}}}`, 0.025},
+	{`{"Variant": "generalized-sampling", "Sampler": {"Name": "robust", "Params": {"k": 1}}}`, 0.015},
+	{`{"Variant": "online-outcome-sampling", "Sampler": {"Name": "outcome", "Params": {"explorationEps": 0.6}}}`, 0.06},
+	{`{"Variant": "vr-mccfr", "Sampler": {"Name": "robust", "Params": {"k": 2}},
+	  "NotTraversingSampler": {"Name": "robust", "Params": {"k": 1}}}`, 0.04},
+	{`{"Variant": "vr-mccfr", "Sampler": {"Name": "robust", "Params": {"k": 2}},
+	  "NotTraversingSampler": {"Name": "robust", "Params": {"k": 1}}, "GeneralSum": true}`, 0.04},
+	{`{"Variant": "xfp"}`, 0.005},
+}
+
+func TestNewSolver(t *testing.T) {
+	root := kuhn.NewGame()
+	for _, tc := range solverTestCases {
+		var config cfr.SolverConfig
+		if err := json.Unmarshal([]byte(tc.config), &config); err != nil {
+			t.Fatal(err)
+		}
+
+		solver, err := cfr.NewSolver(config)
+		if err != nil {
+			t.Errorf("%+v: %v", config, err)
+			continue
+		}
+
+		solver.Seed(123)
+		profile := solver.StrategyProfile()
+		for i := 0; i < 20000; i++ {
+			solver.Run(root)
+			profile.Update()
+		}
+
+		result := exploitability.Compute(root, profile)
+		t.Logf("%s (%s): exploitability %.4f", config.Variant, config.Sampler.Name, result.Exploitability)
+		if result.Exploitability > tc.maxExploitability {
+			t.Errorf("%+v: expected exploitability < %v, got %v", config, tc.maxExploitability, result.Exploitability)
+		}
+	}
+}
+
+func TestNewSolver_InvalidConfig(t *testing.T) {
+	configs := []cfr.SolverConfig{
+		{Variant: "unknown"},
+		{Variant: "mccfr"},
+		{Variant: "mccfr", Sampler: cfr.SamplerConfig{Name: "unknown"}},
+		{Variant: "mccfr", Sampler: cfr.SamplerConfig{Name: "outcome"}},
+		{Variant: "mccfr", Sampler: cfr.SamplerConfig{
+			Name:   "robust",
+			Params: map[string]float64{"k": 1, "eps": 0.1},
+		}},
+		{Variant: "mccfr", Sampler: cfr.SamplerConfig{
+			Name:   "outcome",
+			Params: map[string]float64{"explorationEps": 0.6},
+		}, Pruning: cfr.PruningParams{Enabled: true, MaxUtility: 2}},
+	}
+
+	for _, config := range configs {
+		if _, err := cfr.NewSolver(config); err == nil {
+			t.Errorf("%+v: expected error", config)
+		}
+	}
+}
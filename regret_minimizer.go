@@ -0,0 +1,173 @@
+package cfr
+
+import (
+	"encoding/gob"
+	"io"
+	"math"
+
+	"github.com/timpalpant/go-cfr/internal/f32"
+	"github.com/timpalpant/go-cfr/internal/policy"
+)
+
+func init() {
+	gob.Register(RegretMatching{})
+	gob.Register(DiscountedRegretMatching{})
+	gob.Register(Hedge{})
+}
+
+// RegretMinimizer is the local regret minimizer used at each information set
+// of a PolicyTable to compute the next strategy from the accumulated regrets.
+//
+// Implementations must be registered with gob so that they can be persisted
+// along with the PolicyTable.
+type RegretMinimizer interface {
+	// UpdateRegrets is called at the end of each iteration with the accumulated
+	// regrets of an information set, and may modify them in place
+	// (for example, to discount them).
+	UpdateRegrets(iter int, regretSum []float32)
+	// Strategy sets strategy to the next strategy to play given the
+	// accumulated (or predicted) regrets.
+	Strategy(iter int, regrets, strategy []float32)
+}
+
+// RegretMatching plays each action in proportion to its positive accumulated regret.
+// If Plus is set, negative accumulated regrets are reset to zero after each iteration (RM+).
+//
+// RegretMatching is the default RegretMinimizer.
+type RegretMatching struct {
+	Plus bool
+}
+
+// UpdateRegrets implements RegretMinimizer.
+func (rm RegretMatching) UpdateRegrets(iter int, regretSum []float32) {
+	if rm.Plus {
+		for i, x := range regretSum {
+			if x < 0 {
+				regretSum[i] = 0
+			}
+		}
+	}
+}
+
+// Strategy implements RegretMinimizer.
+func (rm RegretMatching) Strategy(iter int, regrets, strategy []float32) {
+	policy.RegretMatching(regrets, strategy)
+}
+
+// DiscountedRegretMatching is regret matching in which positive accumulated
+// regrets are multiplied by t^Alpha / (t^Alpha + 1) and negative accumulated
+// regrets by t^Beta / (t^Beta + 1) after each iteration t.
+//
+// Unlike DiscountParams, zero values of Alpha and Beta are not special, and
+// correspond to halving the accumulated regrets on every iteration.
+// See: https://arxiv.org/abs/1809.04040
+type DiscountedRegretMatching struct {
+	Alpha float32
+	Beta  float32
+}
+
+// UpdateRegrets implements RegretMinimizer.
+func (rm DiscountedRegretMatching) UpdateRegrets(iter int, regretSum []float32) {
+	positive := discountFactor(iter, rm.Alpha)
+	negative := discountFactor(iter, rm.Beta)
+	for i, x := range regretSum {
+		if x > 0 {
+			regretSum[i] *= positive
+		} else {
+			regretSum[i] *= negative
+		}
+	}
+}
+
+// Strategy implements RegretMinimizer.
+func (rm DiscountedRegretMatching) Strategy(iter int, regrets, strategy []float32) {
+	policy.RegretMatching(regrets, strategy)
+}
+
+// t^x / (t^x + 1)
+func discountFactor(iter int, x float32) float32 {
+	tx := float32(math.Pow(float64(iter), float64(x)))
+	return tx / (tx + 1.0)
+}
+
+// Hedge (exponential weights) plays each action with probability proportional
+// to exp(eta * R), where R is the accumulated regret of the action and the
+// learning rate on iteration t is eta = LearningRate / t^Decay.
+//
+// A Decay of 0.5 corresponds to the usual 1/sqrt(t) schedule.
+// The scale of a good LearningRate depends on the range of utilities in the game.
+type Hedge struct {
+	// If not positive, DefaultHedgeLearningRate is used. (With a learning
+	// rate of zero, Hedge would play the uniform strategy forever.)
+	LearningRate float32
+	Decay        float32
+}
+
+// DefaultHedgeLearningRate is the learning rate of a Hedge with a LearningRate
+// that is not positive.
+const DefaultHedgeLearningRate = 1.0
+
+// UpdateRegrets implements RegretMinimizer.
+func (h Hedge) UpdateRegrets(iter int, regretSum []float32) {}
+
+// Strategy implements RegretMinimizer.
+func (h Hedge) Strategy(iter int, regrets, strategy []float32) {
+	eta := float64(h.LearningRate)
+	if eta <= 0 {
+		eta = DefaultHedgeLearningRate
+	}
+
+	if h.Decay != 0 {
+		eta /= math.Pow(float64(iter), float64(h.Decay))
+	}
+
+	// Subtract the maximum regret for numerical stability.
+	maxRegret := regrets[0]
+	for _, r := range regrets[1:] {
+		if r > maxRegret {
+			maxRegret = r
+		}
+	}
+
+	for i, r := range regrets {
+		strategy[i] = float32(math.Exp(eta * float64(r-maxRegret)))
+	}
+
+	f32.ScalUnitary(1.0/f32.Sum(strategy), strategy)
+}
+
+// updateParams returns the parameters to update each policy at the end of
+// the given iteration.
+func (p DiscountParams) updateParams(iter int, rm RegretMinimizer) policy.UpdateParams {
+	discountPos, discountNeg, discountSum := p.GetDiscountFactors(iter)
+	return policy.UpdateParams{
+		Iter:                   iter,
+		DiscountPositiveRegret: discountPos,
+		DiscountNegativeRegret: discountNeg,
+		DiscountStrategySum:    discountSum,
+		PredictionWeight:       p.GetPredictionWeight(),
+		RegretMinimizer:        rm,
+	}
+}
+
+// regretMinimizerValue wraps a (possibly nil) RegretMinimizer so that it
+// can be gob-encoded.
+type regretMinimizerValue struct {
+	RegretMinimizer RegretMinimizer
+}
+
+func encodeRegretMinimizer(enc *gob.Encoder, rm RegretMinimizer) error {
+	return enc.Encode(regretMinimizerValue{rm})
+}
+
+// decodeRegretMinimizer decodes a RegretMinimizer written by encodeRegretMinimizer.
+// Tables serialized before regret minimizers were configurable do not have one,
+// and decode to the default (nil).
+func decodeRegretMinimizer(dec *gob.Decoder) (RegretMinimizer, error) {
+	var v regretMinimizerValue
+	if err := dec.Decode(&v); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return v.RegretMinimizer, nil
+}
@@ -5,7 +5,6 @@ import (
 
 	"github.com/timpalpant/go-cfr"
 	"github.com/timpalpant/go-cfr/internal/f32"
-	"github.com/timpalpant/go-cfr/internal/policy"
 )
 
 type AverageStrategyParams struct {
@@ -14,6 +13,12 @@ type AverageStrategyParams struct {
 	Beta    float32
 }
 
+// strategySummer is implemented by tabular NodePolicies
+// that track the accumulated strategy sum.
+type strategySummer interface {
+	GetStrategySum() []float32
+}
+
 // AverageStrategySampler implements cfr.Sampler by sampling some player actions
 // according to the current average strategy strategy.
 type AverageStrategySampler struct {
@@ -22,10 +27,10 @@ type AverageStrategySampler struct {
 	p      []float32
 }
 
-func NewAverageStrategySampler(params AverageStrategyParams) *AverageStrategySampler {
+func NewAverageStrategySampler(rng *rand.Rand, params AverageStrategyParams) *AverageStrategySampler {
 	return &AverageStrategySampler{
 		params: params,
-		rng:    rand.New(rand.NewSource(rand.Int63())),
+		rng:    rng,
 	}
 }
 
@@ -34,7 +39,7 @@ func (as *AverageStrategySampler) Sample(node cfr.GameTreeNode, pol cfr.NodePoli
 	as.p = extend(as.p, nChildren)
 
 	x := as.rng.Float32()
-	s := pol.(*policy.Policy).GetStrategySum()
+	s := pol.(strategySummer).GetStrategySum()
 	sSum := f32.Sum(s)
 	for i := range as.p {
 		rho := computeRho(s[i], sSum, as.params)
@@ -19,3 +19,8 @@ func (es *ExternalSampler) Sample(node cfr.GameTreeNode, policy cfr.NodePolicy)
 
 	return es.p[:nChildren]
 }
+
+// SamplesAllActions implements cfr.ExhaustiveSampler.
+func (es *ExternalSampler) SamplesAllActions() bool {
+	return true
+}
@@ -17,11 +17,11 @@ type MultiOutcomeSampler struct {
 	pool *floatSlicePool
 }
 
-func NewMultiOutcomeSampler(k int, explorationEps float32) *MultiOutcomeSampler {
+func NewMultiOutcomeSampler(rng *rand.Rand, k int, explorationEps float32) *MultiOutcomeSampler {
 	return &MultiOutcomeSampler{
 		k:    k,
 		eps:  explorationEps,
-		rng:  rand.New(rand.NewSource(rand.Int63())),
+		rng:  rng,
 		p:    make([]float32, k),
 		pool: &floatSlicePool{},
 	}
@@ -1,15 +1,17 @@
 package sampling
 
 import (
+	"math/rand"
 	"testing"
 )
 
 func TestChooseK(t *testing.T) {
 	p0 := []float32{0.01, 0.1, 0.1, 0.79}
 	eps := float32(0.05)
+	rng := rand.New(rand.NewSource(123))
 
 	for k := 1; k <= 4; k++ {
-		s := NewMultiOutcomeSampler(k, eps)
+		s := NewMultiOutcomeSampler(rng, k, eps)
 		pk := s.chooseK(p0)
 		t.Logf("k=%d: %v", k, pk)
 	}
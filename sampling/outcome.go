@@ -14,10 +14,10 @@ type OutcomeSampler struct {
 	p   []float32
 }
 
-func NewOutcomeSampler(explorationEps float32) *OutcomeSampler {
+func NewOutcomeSampler(rng *rand.Rand, explorationEps float32) *OutcomeSampler {
 	return &OutcomeSampler{
 		eps: explorationEps,
-		rng: rand.New(rand.NewSource(rand.Int63())),
+		rng: rng,
 	}
 }
 
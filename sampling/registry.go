@@ -0,0 +1,65 @@
+package sampling
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/timpalpant/go-cfr"
+)
+
+func init() {
+	cfr.RegisterSampler("external", func(rng *rand.Rand, params map[string]float64) (cfr.Sampler, error) {
+		if err := checkParams(params); err != nil {
+			return nil, err
+		}
+
+		return NewExternalSampler(), nil
+	})
+	cfr.RegisterSampler("outcome", func(rng *rand.Rand, params map[string]float64) (cfr.Sampler, error) {
+		if err := checkParams(params, "explorationEps"); err != nil {
+			return nil, err
+		}
+
+		return NewOutcomeSampler(rng, float32(params["explorationEps"])), nil
+	})
+	cfr.RegisterSampler("multi-outcome", func(rng *rand.Rand, params map[string]float64) (cfr.Sampler, error) {
+		if err := checkParams(params, "k", "explorationEps"); err != nil {
+			return nil, err
+		}
+
+		return NewMultiOutcomeSampler(rng, int(params["k"]), float32(params["explorationEps"])), nil
+	})
+	cfr.RegisterSampler("robust", func(rng *rand.Rand, params map[string]float64) (cfr.Sampler, error) {
+		if err := checkParams(params, "k"); err != nil {
+			return nil, err
+		}
+
+		return NewRobustSampler(rng, int(params["k"])), nil
+	})
+	cfr.RegisterSampler("average-strategy", func(rng *rand.Rand, params map[string]float64) (cfr.Sampler, error) {
+		if err := checkParams(params, "epsilon", "tau", "beta"); err != nil {
+			return nil, err
+		}
+
+		return NewAverageStrategySampler(rng, AverageStrategyParams{
+			Epsilon: float32(params["epsilon"]),
+			Tau:     float32(params["tau"]),
+			Beta:    float32(params["beta"]),
+		}), nil
+	})
+}
+
+// checkParams verifies that exactly the given parameters are specified.
+func checkParams(params map[string]float64, names ...string) error {
+	for _, name := range names {
+		if _, ok := params[name]; !ok {
+			return fmt.Errorf("missing sampler parameter: %q", name)
+		}
+	}
+
+	if len(params) != len(names) {
+		return fmt.Errorf("unexpected sampler parameters: %v (expected %v)", params, names)
+	}
+
+	return nil
+}
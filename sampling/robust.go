@@ -14,10 +14,10 @@ type RobustSampler struct {
 	rng *rand.Rand
 }
 
-func NewRobustSampler(k int) *RobustSampler {
+func NewRobustSampler(rng *rand.Rand, k int) *RobustSampler {
 	return &RobustSampler{
 		k:   k,
-		rng: rand.New(rand.NewSource(rand.Int63())),
+		rng: rng,
 	}
 }
 
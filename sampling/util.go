@@ -10,6 +10,9 @@ import (
 const tol = 1e-3
 
 // Sample one child of the given Chance node, according to its probability distribution.
+//
+// It uses the global math/rand source, and so is suitable for implementing
+// GameTreeNode.SampleChild. Use cfr.SampleChanceNode to sample reproducibly.
 func SampleChanceNode(node cfr.GameTreeNode) (cfr.GameTreeNode, float64) {
 	x := rand.Float64()
 	var cumProb float64
@@ -0,0 +1,321 @@
+// Package seqform computes exact equilibria of small two-player zero-sum
+// extensive-form games by solving the sequence-form linear program.
+//
+// See: Koller, Megiddo and von Stengel, "Fast Algorithms for Finding
+// Randomized Strategies in Game Trees" (STOC 1994).
+//
+// The full game tree is enumerated and the resulting linear program is
+// solved with a dense simplex method, so this is only tractable for small
+// games such as Kuhn poker. It is intended as a ground-truth reference for
+// testing other solvers.
+package seqform
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/timpalpant/go-cfr"
+)
+
+// Sequence is a sequence of actions of one player, identified by the last
+// InfoSet in which the player acted and the action taken there.
+type Sequence struct {
+	InfoSet string
+	Action  int
+}
+
+// InfoSet is an information set of one player in the sequence form.
+type InfoSet struct {
+	Key string
+	// Parent is the index of the sequence of the player's actions leading to this InfoSet.
+	Parent int
+	// First is the index of the sequence of taking the first action in this InfoSet.
+	// The sequences of the other actions follow consecutively.
+	First      int
+	NumActions int
+}
+
+// Game is the sequence-form representation of a two-player extensive-form game.
+type Game struct {
+	// Sequences of each player. The first sequence of each player
+	// is the empty sequence, with an empty InfoSet key.
+	Sequences [2][]Sequence
+	// InfoSets of each player.
+	InfoSets [2][]InfoSet
+	// Payoff[i][j] is the expected utility to player 0 (weighted by the
+	// probability of chance outcomes) when player 0 plays sequence i
+	// and player 1 plays sequence j.
+	Payoff [][]float64
+
+	infoSetIdx [2]map[string]int
+}
+
+// New enumerates the game tree rooted at the given node and builds its sequence form.
+// The game must have two players and perfect recall.
+func New(root cfr.GameTreeNode) (*Game, error) {
+	if n := cfr.NumPlayers(root); n != 2 {
+		return nil, fmt.Errorf("sequence form requires a two-player game, got %d players", n)
+	}
+
+	g := &Game{}
+	for player := range g.Sequences {
+		g.Sequences[player] = []Sequence{{}}
+		g.infoSetIdx[player] = make(map[string]int)
+	}
+
+	// Build the sequences first so that the size of the payoff matrix is known.
+	if err := g.buildSequences(root, [2]int{}); err != nil {
+		return nil, err
+	}
+
+	g.Payoff = make([][]float64, len(g.Sequences[0]))
+	for i := range g.Payoff {
+		g.Payoff[i] = make([]float64, len(g.Sequences[1]))
+	}
+
+	if err := g.buildPayoffs(root, [2]int{}, 1.0); err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
+
+func (g *Game) buildSequences(node cfr.GameTreeNode, seqs [2]int) error {
+	defer node.Close()
+	switch node.Type() {
+	case cfr.TerminalNodeType:
+		return nil
+	case cfr.PlayerNodeType:
+		player := node.Player()
+		is, err := g.getInfoSet(node, seqs[player])
+		if err != nil {
+			return err
+		}
+
+		for i := 0; i < node.NumChildren(); i++ {
+			childSeqs := seqs
+			childSeqs[player] = is.First + i
+			if err := g.buildSequences(node.GetChild(i), childSeqs); err != nil {
+				return err
+			}
+		}
+	default:
+		for i := 0; i < node.NumChildren(); i++ {
+			if err := g.buildSequences(node.GetChild(i), seqs); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// getInfoSet returns the InfoSet of the given node, adding it (and the
+// sequences of its actions) if it has not been seen before.
+func (g *Game) getInfoSet(node cfr.GameTreeNode, parent int) (InfoSet, error) {
+	player := node.Player()
+	key := string(node.InfoSetKey(player))
+	if idx, ok := g.infoSetIdx[player][key]; ok {
+		is := g.InfoSets[player][idx]
+		if is.Parent != parent {
+			return is, fmt.Errorf("infoset %q is reached by different sequences: game does not have perfect recall", key)
+		} else if is.NumActions != node.NumChildren() {
+			return is, fmt.Errorf("infoset %q has %d actions but node has %d children",
+				key, is.NumActions, node.NumChildren())
+		}
+
+		return is, nil
+	}
+
+	is := InfoSet{
+		Key:        key,
+		Parent:     parent,
+		First:      len(g.Sequences[player]),
+		NumActions: node.NumChildren(),
+	}
+
+	for i := 0; i < is.NumActions; i++ {
+		g.Sequences[player] = append(g.Sequences[player], Sequence{key, i})
+	}
+
+	g.infoSetIdx[player][key] = len(g.InfoSets[player])
+	g.InfoSets[player] = append(g.InfoSets[player], is)
+	return is, nil
+}
+
+func (g *Game) buildPayoffs(node cfr.GameTreeNode, seqs [2]int, reachChance float64) error {
+	defer node.Close()
+	switch node.Type() {
+	case cfr.TerminalNodeType:
+		u0, u1 := node.Utility(0), node.Utility(1)
+		if math.Abs(u0+u1) > eps {
+			return fmt.Errorf("game is not zero-sum: utilities are (%v, %v) at %v", u0, u1, node)
+		}
+
+		g.Payoff[seqs[0]][seqs[1]] += reachChance * u0
+	case cfr.PlayerNodeType:
+		player := node.Player()
+		is := g.InfoSets[player][g.infoSetIdx[player][string(node.InfoSetKey(player))]]
+		for i := 0; i < node.NumChildren(); i++ {
+			childSeqs := seqs
+			childSeqs[player] = is.First + i
+			if err := g.buildPayoffs(node.GetChild(i), childSeqs, reachChance); err != nil {
+				return err
+			}
+		}
+	default:
+		for i := 0; i < node.NumChildren(); i++ {
+			p := node.GetChildProbability(i)
+			if err := g.buildPayoffs(node.GetChild(i), seqs, p*reachChance); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Constraints returns the linear constraints E x = e that the realization plan x
+// of the given player must satisfy: the empty sequence is played with probability 1,
+// and the probabilities of the actions at each InfoSet sum to that of its parent sequence.
+func (g *Game) Constraints(player int) (E [][]float64, e []float64) {
+	nSeqs := len(g.Sequences[player])
+	E = make([][]float64, len(g.InfoSets[player])+1)
+	e = make([]float64, len(E))
+	E[0] = make([]float64, nSeqs)
+	E[0][0] = 1.0
+	e[0] = 1.0
+	for i, is := range g.InfoSets[player] {
+		row := make([]float64, nSeqs)
+		row[is.Parent] = -1.0
+		for j := 0; j < is.NumActions; j++ {
+			row[is.First+j] = 1.0
+		}
+
+		E[i+1] = row
+	}
+
+	return E, e
+}
+
+// Solve computes an exact equilibrium of the game. It returns a StrategyProfile
+// that plays the equilibrium strategy of both players, and the value of the game
+// to player 0.
+func (g *Game) Solve() (*cfr.FixedStrategyProfile, float64, error) {
+	x, value, err := g.solvePlayer(0)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	y, _, err := g.solvePlayer(1)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	profile := cfr.NewFixedStrategyProfile()
+	g.setStrategies(profile, 0, x)
+	g.setStrategies(profile, 1, y)
+	return profile, value, nil
+}
+
+// Solve computes an exact equilibrium of the two-player zero-sum game rooted at
+// the given node. See Game.Solve.
+func Solve(root cfr.GameTreeNode) (*cfr.FixedStrategyProfile, float64, error) {
+	g, err := New(root)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return g.Solve()
+}
+
+// solvePlayer computes a maxmin realization plan of the given player,
+// and the value of the game to player 0.
+//
+// With A the payoff matrix from the player's perspective, the player solves
+//
+//	maximize f^T q  subject to  F^T q - A^T x <= 0,  E x = e,  x >= 0
+//
+// where E x = e and F y = f are the constraints of the player and opponent,
+// and q is the (free) dual vector of the opponent's best response.
+func (g *Game) solvePlayer(player int) ([]float64, float64, error) {
+	opponent := 1 - player
+	E, e := g.Constraints(player)
+	F, f := g.Constraints(opponent)
+	nX, nY := len(g.Sequences[player]), len(g.Sequences[opponent])
+	nQ := len(F)
+
+	// Variables: x, q+, q-, slacks (one per opponent sequence).
+	nVars := nX + 2*nQ + nY
+	var a [][]float64
+	var b []float64
+	for j := 0; j < nY; j++ {
+		row := make([]float64, nVars)
+		for i := 0; i < nX; i++ {
+			row[i] = -g.payoff(player, i, j)
+		}
+
+		for k := 0; k < nQ; k++ {
+			row[nX+k] = F[k][j]
+			row[nX+nQ+k] = -F[k][j]
+		}
+
+		row[nX+2*nQ+j] = 1.0
+		a = append(a, row)
+		b = append(b, 0)
+	}
+
+	for k, eRow := range E {
+		row := make([]float64, nVars)
+		copy(row, eRow)
+		a = append(a, row)
+		b = append(b, e[k])
+	}
+
+	c := make([]float64, nVars)
+	for k := 0; k < nQ; k++ {
+		c[nX+k] = f[k]
+		c[nX+nQ+k] = -f[k]
+	}
+
+	solution, value, err := Simplex(a, b, c)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if player != 0 {
+		value = -value
+	}
+
+	return solution[:nX], value, nil
+}
+
+// payoff returns the payoff to the given player when it plays its ith sequence
+// and its opponent plays their jth sequence.
+func (g *Game) payoff(player, i, j int) float64 {
+	if player == 0 {
+		return g.Payoff[i][j]
+	}
+
+	return -g.Payoff[j][i]
+}
+
+// setStrategies converts the given realization plan of a player into
+// behavioral strategies at each of its InfoSets.
+func (g *Game) setStrategies(profile *cfr.FixedStrategyProfile, player int, x []float64) {
+	for _, is := range g.InfoSets[player] {
+		strategy := make([]float32, is.NumActions)
+		if parent := x[is.Parent]; parent > eps {
+			for i := range strategy {
+				strategy[i] = float32(math.Max(x[is.First+i], 0) / parent)
+			}
+		} else {
+			// InfoSet is never reached by this player's strategy.
+			for i := range strategy {
+				strategy[i] = 1.0 / float32(is.NumActions)
+			}
+		}
+
+		profile.SetStrategy([]byte(is.Key), strategy)
+	}
+}
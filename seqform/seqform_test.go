@@ -0,0 +1,78 @@
+package seqform
+
+import (
+	"math"
+	"testing"
+
+	"github.com/timpalpant/go-cfr/exploitability"
+	"github.com/timpalpant/go-cfr/kuhn"
+)
+
+func TestSimplex(t *testing.T) {
+	// maximize 3x + 5y s.t. x <= 4, 2y <= 12, 3x + 2y <= 18.
+	a := [][]float64{
+		{1, 0, 1, 0, 0},
+		{0, 2, 0, 1, 0},
+		{3, 2, 0, 0, 1},
+	}
+	b := []float64{4, 12, 18}
+	c := []float64{3, 5, 0, 0, 0}
+
+	x, value, err := Simplex(a, b, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if math.Abs(value-36) > 1e-9 || math.Abs(x[0]-2) > 1e-9 || math.Abs(x[1]-6) > 1e-9 {
+		t.Errorf("expected x=2, y=6, value=36; got x=%v, value=%v", x, value)
+	}
+}
+
+func TestSimplex_Infeasible(t *testing.T) {
+	// x + y = 1 and x + y = 2.
+	a := [][]float64{{1, 1}, {1, 1}}
+	b := []float64{1, 2}
+	c := []float64{1, 0}
+	if _, _, err := Simplex(a, b, c); err != ErrInfeasible {
+		t.Errorf("expected %v, got %v", ErrInfeasible, err)
+	}
+}
+
+func TestKuhn(t *testing.T) {
+	root := kuhn.NewGame()
+	g, err := New(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for player, infoSets := range g.InfoSets {
+		if len(infoSets) != 6 {
+			t.Errorf("expected 6 infosets for player %d, got %d", player, len(infoSets))
+		}
+	}
+
+	profile, value, err := g.Solve()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if math.Abs(value-(-1.0/18)) > 1e-6 {
+		t.Errorf("expected game value %v, got %v", -1.0/18, value)
+	}
+
+	result := exploitability.Compute(root, profile)
+	t.Logf("Exploitability: %v, values: %v", result.Exploitability, result.Values)
+	if result.NashConv > 1e-5 {
+		t.Errorf("expected NashConv = 0, got %v", result.NashConv)
+	}
+
+	if math.Abs(result.Values[0]-value) > 1e-5 {
+		t.Errorf("expected value of solution %v, got %v", value, result.Values[0])
+	}
+}
+
+func TestNPlayerKuhn(t *testing.T) {
+	if _, _, err := Solve(kuhn.NewNPlayerGame(3)); err == nil {
+		t.Error("expected error solving three-player game")
+	}
+}
@@ -0,0 +1,199 @@
+package seqform
+
+import (
+	"errors"
+	"math"
+)
+
+const eps = 1e-9
+
+var (
+	// ErrInfeasible is returned by Simplex if the linear program has no feasible solution.
+	ErrInfeasible = errors.New("linear program is infeasible")
+	// ErrUnbounded is returned by Simplex if the objective is unbounded.
+	ErrUnbounded = errors.New("linear program is unbounded")
+)
+
+// Simplex solves the linear program in standard form:
+//
+//	maximize c^T x subject to A x = b, x >= 0
+//
+// using the two-phase (dense tableau) simplex method with Bland's rule,
+// and returns the optimal x and objective value.
+//
+// It is intended for the small programs that result from enumerating a
+// game tree, and makes no attempt to exploit sparsity.
+func Simplex(a [][]float64, b, c []float64) ([]float64, float64, error) {
+	m, n := len(a), len(c)
+
+	// Tableau with one artificial variable per row, so that the artificial
+	// variables form the initial basis. The last column is the right-hand side.
+	t := newTableau(m, n+m)
+	for i, row := range a {
+		sign := 1.0
+		if b[i] < 0 {
+			sign = -1.0
+		}
+
+		for j, x := range row {
+			t.rows[i][j] = sign * x
+		}
+
+		t.rows[i][n+i] = 1.0
+		t.rows[i][n+m] = sign * b[i]
+		t.basis[i] = n + i
+	}
+
+	// Phase 1: minimize the sum of the artificial variables.
+	phase1 := make([]float64, n+m)
+	for j := n; j < n+m; j++ {
+		phase1[j] = -1.0
+	}
+
+	t.setObjective(phase1)
+	if err := t.optimize(n + m); err != nil {
+		return nil, 0, err
+	}
+
+	if t.value() < -eps {
+		return nil, 0, ErrInfeasible
+	}
+
+	// Drive any (degenerate) artificial variables out of the basis.
+	for i, j := range t.basis {
+		if j < n {
+			continue
+		}
+
+		for k := 0; k < n; k++ {
+			if math.Abs(t.rows[i][k]) > eps {
+				t.pivot(i, k)
+				break
+			}
+		}
+	}
+
+	// Phase 2: optimize the real objective over the original variables.
+	phase2 := make([]float64, n+m)
+	copy(phase2, c)
+	t.setObjective(phase2)
+	if err := t.optimize(n); err != nil {
+		return nil, 0, err
+	}
+
+	x := make([]float64, n)
+	for i, j := range t.basis {
+		if j < n {
+			x[j] = t.rows[i][n+m]
+		}
+	}
+
+	return x, t.value(), nil
+}
+
+type tableau struct {
+	rows  [][]float64
+	obj   []float64 // Reduced costs, with the negated objective value last.
+	basis []int
+}
+
+func newTableau(m, n int) *tableau {
+	rows := make([][]float64, m)
+	for i := range rows {
+		rows[i] = make([]float64, n+1)
+	}
+
+	return &tableau{
+		rows:  rows,
+		obj:   make([]float64, n+1),
+		basis: make([]int, m),
+	}
+}
+
+// setObjective sets the objective to maximize c^T x, expressed in terms
+// of the non-basic variables of the current basis.
+func (t *tableau) setObjective(c []float64) {
+	copy(t.obj, c)
+	t.obj[len(t.obj)-1] = 0
+	for i, j := range t.basis {
+		if cj := c[j]; cj != 0 {
+			for k, x := range t.rows[i] {
+				t.obj[k] -= cj * x
+			}
+		}
+	}
+}
+
+// value returns the objective value of the current basic solution.
+func (t *tableau) value() float64 {
+	return -t.obj[len(t.obj)-1]
+}
+
+// optimize pivots until no improving column among the first nCols exists.
+func (t *tableau) optimize(nCols int) error {
+	rhs := len(t.obj) - 1
+	for {
+		// Bland's rule: the entering variable is the lowest-indexed
+		// column with positive reduced cost, which prevents cycling.
+		col := -1
+		for j := 0; j < nCols; j++ {
+			if t.obj[j] > eps {
+				col = j
+				break
+			}
+		}
+
+		if col < 0 {
+			return nil
+		}
+
+		// Ratio test, breaking ties by the lowest-indexed basic variable.
+		row := -1
+		var best float64
+		for i, r := range t.rows {
+			if r[col] <= eps {
+				continue
+			}
+
+			ratio := r[rhs] / r[col]
+			if row < 0 || ratio < best-eps ||
+				(ratio < best+eps && t.basis[i] < t.basis[row]) {
+				row, best = i, ratio
+			}
+		}
+
+		if row < 0 {
+			return ErrUnbounded
+		}
+
+		t.pivot(row, col)
+	}
+}
+
+func (t *tableau) pivot(row, col int) {
+	pr := t.rows[row]
+	scale := 1.0 / pr[col]
+	for k := range pr {
+		pr[k] *= scale
+	}
+
+	eliminate := func(r []float64) {
+		f := r[col]
+		if f == 0 {
+			return
+		}
+
+		for k, x := range pr {
+			r[k] -= f * x
+		}
+	}
+
+	for i, r := range t.rows {
+		if i != row {
+			eliminate(r)
+		}
+	}
+
+	eliminate(t.obj)
+	t.basis[row] = col
+}
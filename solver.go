@@ -0,0 +1,55 @@
+package cfr
+
+// Solver is the interface implemented by all of the CFR variants.
+//
+// Solvers that sample are constructed with a *rand.Rand, which is used for
+// all of their random choices (including sampling chance nodes). Sharing it
+// with the solver's Samplers makes a run reproducible from a single seed.
+type Solver interface {
+	// Run performs one iteration of the solver starting from the given
+	// root node, and returns the (possibly sampled) value of the game for
+	// the first player (player 0), regardless of which players' regrets
+	// were updated on the iteration.
+	//
+	// The caller is responsible for calling StrategyProfile().Update()
+	// after each iteration.
+	Run(root GameTreeNode) float32
+	// StrategyProfile returns the StrategyProfile that is updated by this solver.
+	StrategyProfile() StrategyProfile
+	// Seed resets the random number generator used by the solver
+	// (and any Samplers sharing it). It has no effect for solvers
+	// that are deterministic.
+	Seed(seed int64)
+}
+
+// RandStateSolver is implemented by Solvers that can save and restore the
+// state of their random number generator (and that of any Samplers sharing it),
+// so that a run that is resumed from a checkpoint continues exactly as if it
+// had not been interrupted. It is implemented by the sampling Solvers in
+// this package, whose state is known only if they were constructed by
+// NewSolver: the state of a *rand.Rand passed to their constructors
+// cannot be saved.
+type RandStateSolver interface {
+	Solver
+	// MarshalRandState returns the current state of the random number generator,
+	// or nil if it is not known. It does not change the random numbers that
+	// are generated.
+	MarshalRandState() ([]byte, error)
+	// UnmarshalRandState restores a state returned by MarshalRandState.
+	UnmarshalRandState(buf []byte) error
+}
+
+var (
+	_ Solver = &CFR{}
+	_ Solver = &ChanceSamplingCFR{}
+	_ Solver = &MCCFR{}
+	_ Solver = &GeneralizedSamplingCFR{}
+	_ Solver = &OnlineOutcomeSamplingCFR{}
+	_ Solver = &VRMCCFR{}
+
+	_ RandStateSolver = &ChanceSamplingCFR{}
+	_ RandStateSolver = &MCCFR{}
+	_ RandStateSolver = &GeneralizedSamplingCFR{}
+	_ RandStateSolver = &OnlineOutcomeSamplingCFR{}
+	_ RandStateSolver = &VRMCCFR{}
+)
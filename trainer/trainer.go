@@ -0,0 +1,266 @@
+// Package trainer implements a driver that runs CFR iterations with
+// stopping criteria, per-iteration callbacks and periodic checkpoints.
+package trainer
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/timpalpant/go-cfr"
+	"github.com/timpalpant/go-cfr/exploitability"
+)
+
+// Params configure when the Trainer stops and checkpoints.
+// Zero values disable the corresponding behavior.
+type Params struct {
+	// Stop once the StrategyProfile has completed this many iterations.
+	// Iterations performed before resuming from a checkpoint are included.
+	MaxIterations int
+	// Stop once training has run for this long.
+	MaxDuration time.Duration
+	// Stop once the exploitability of the average strategy is at or below
+	// this value. Computing exploitability walks the entire game tree, and
+	// so is only feasible for small games.
+	TargetExploitability float64
+	// How often (in iterations) to compute exploitability when
+	// TargetExploitability is set. If zero, it is computed every iteration.
+	ExploitabilityInterval int
+
+	// The file that the StrategyProfile is checkpointed to.
+	CheckpointPath string
+	// How often (in iterations) to write a checkpoint. A final checkpoint
+	// is written when training stops if CheckpointPath is set, unless the
+	// final iteration was already checkpointed.
+	CheckpointInterval int
+}
+
+// Stats summarize the progress of training after an iteration.
+type Stats struct {
+	// The iteration that was just completed.
+	Iter int
+	// The value returned by the Solver for this iteration.
+	Value float32
+	// The average of the values returned by the Solver since training started.
+	AverageValue float32
+	// The time elapsed since training started.
+	Elapsed time.Duration
+	// The exploitability of the average strategy, or NaN if it was
+	// not computed on this iteration.
+	Exploitability float64
+}
+
+// Callback is invoked with the Stats after every iteration.
+type Callback func(Stats)
+
+// Trainer runs a Solver on a game, updating its StrategyProfile after each
+// iteration, until one of the configured stopping criteria is met.
+type Trainer struct {
+	solver    cfr.Solver
+	profile   cfr.StrategyProfile
+	params    Params
+	callbacks []Callback
+}
+
+// New returns a new Trainer that runs the given Solver,
+// and updates the Solver's StrategyProfile after each iteration.
+func New(solver cfr.Solver, params Params) *Trainer {
+	return &Trainer{
+		solver:  solver,
+		profile: solver.StrategyProfile(),
+		params:  params,
+	}
+}
+
+// AddCallback registers a function to be called after every iteration.
+func (t *Trainer) AddCallback(cb Callback) {
+	t.callbacks = append(t.callbacks, cb)
+}
+
+// Train runs iterations until one of the stopping criteria is met,
+// and returns the Stats of the final iteration.
+func (t *Trainer) Train(root cfr.GameTreeNode) (Stats, error) {
+	if t.params.MaxIterations == 0 && t.params.MaxDuration == 0 && t.params.TargetExploitability == 0 {
+		return Stats{}, fmt.Errorf("trainer: no stopping criteria configured")
+	}
+
+	start := time.Now()
+	stats := Stats{Iter: t.profile.Iter() - 1, Exploitability: math.NaN()}
+	var totalValue float64
+	var nIter int
+	lastCheckpoint := -1
+	for !t.isDone(stats) {
+		stats.Value = t.solver.Run(root)
+		t.profile.Update()
+
+		nIter++
+		totalValue += float64(stats.Value)
+		stats.Iter = t.profile.Iter() - 1
+		stats.AverageValue = float32(totalValue / float64(nIter))
+		stats.Elapsed = time.Since(start)
+		stats.Exploitability = math.NaN()
+		if t.params.TargetExploitability > 0 && isMultiple(stats.Iter, t.params.ExploitabilityInterval) {
+			stats.Exploitability = exploitability.Exploitability(root, t.profile)
+		}
+
+		for _, cb := range t.callbacks {
+			cb(stats)
+		}
+
+		if t.params.CheckpointInterval > 0 && isMultiple(stats.Iter, t.params.CheckpointInterval) {
+			if err := t.Checkpoint(); err != nil {
+				return stats, err
+			}
+
+			lastCheckpoint = stats.Iter
+		}
+	}
+
+	glog.Infof("Training stopped after iteration %d (%v elapsed)", stats.Iter, stats.Elapsed)
+	if t.params.CheckpointPath != "" && stats.Iter != lastCheckpoint {
+		if err := t.Checkpoint(); err != nil {
+			return stats, err
+		}
+	}
+
+	return stats, nil
+}
+
+func (t *Trainer) isDone(stats Stats) bool {
+	if t.params.MaxIterations > 0 && stats.Iter >= t.params.MaxIterations {
+		return true
+	}
+
+	if t.params.MaxDuration > 0 && stats.Elapsed >= t.params.MaxDuration {
+		return true
+	}
+
+	return t.params.TargetExploitability > 0 && stats.Exploitability <= t.params.TargetExploitability
+}
+
+func isMultiple(iter, interval int) bool {
+	return interval <= 1 || iter%interval == 0
+}
+
+// Checkpoint saves the StrategyProfile to the configured CheckpointPath.
+// The state of the Solver's random number generator is saved with the
+// profile if it is known (see cfr.RandStateSolver), so that a resumed run
+// samples the same as an uninterrupted one.
+//
+// The checkpoint is first written to a temporary file which is then renamed,
+// so that an existing checkpoint is not corrupted if writing fails.
+func (t *Trainer) Checkpoint() error {
+	if t.params.CheckpointPath == "" {
+		return fmt.Errorf("trainer: no checkpoint path configured")
+	}
+
+	buf, err := t.marshalCheckpoint()
+	if err != nil {
+		return err
+	}
+
+	// The temporary file must be in the same directory (and filesystem)
+	// as the checkpoint for the rename to succeed.
+	dir, name := filepath.Dir(t.params.CheckpointPath), filepath.Base(t.params.CheckpointPath)
+	f, err := ioutil.TempFile(dir, name+".tmp")
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(buf); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	glog.V(1).Infof("Saving checkpoint for iteration %d to %v", t.profile.Iter()-1, t.params.CheckpointPath)
+	return os.Rename(f.Name(), t.params.CheckpointPath)
+}
+
+// Resume restores the StrategyProfile (and the state of the Solver's random
+// number generator, if it was saved) from the configured CheckpointPath,
+// if it exists, so that training continues from the checkpointed iteration.
+// It returns false if there was no checkpoint to resume from.
+func (t *Trainer) Resume() (bool, error) {
+	if t.params.CheckpointPath == "" {
+		return false, fmt.Errorf("trainer: no checkpoint path configured")
+	}
+
+	buf, err := ioutil.ReadFile(t.params.CheckpointPath)
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	if err := t.unmarshalCheckpoint(buf); err != nil {
+		return false, err
+	}
+
+	glog.Infof("Resumed from checkpoint %v at iteration %d",
+		t.params.CheckpointPath, t.profile.Iter()-1)
+	return true, nil
+}
+
+// marshalCheckpoint encodes the StrategyProfile, followed by the state of the
+// Solver's random number generator (empty if it cannot be saved).
+func (t *Trainer) marshalCheckpoint() ([]byte, error) {
+	profile, err := t.profile.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	var randState []byte
+	if solver, ok := t.solver.(cfr.RandStateSolver); ok {
+		randState, err = solver.MarshalRandState()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+	if err := enc.Encode(profile); err != nil {
+		return nil, err
+	}
+
+	if err := enc.Encode(randState); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// unmarshalCheckpoint restores a checkpoint written by marshalCheckpoint.
+func (t *Trainer) unmarshalCheckpoint(buf []byte) error {
+	dec := gob.NewDecoder(bytes.NewReader(buf))
+	var profile, randState []byte
+	if err := dec.Decode(&profile); err != nil {
+		return err
+	}
+
+	if err := dec.Decode(&randState); err != nil {
+		return err
+	}
+
+	if err := t.profile.UnmarshalBinary(profile); err != nil {
+		return err
+	}
+
+	if solver, ok := t.solver.(cfr.RandStateSolver); ok && len(randState) > 0 {
+		return solver.UnmarshalRandState(randState)
+	}
+
+	return nil
+}
@@ -0,0 +1,228 @@
+package trainer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/timpalpant/go-cfr"
+	"github.com/timpalpant/go-cfr/kuhn"
+	_ "github.com/timpalpant/go-cfr/sampling"
+	"github.com/timpalpant/go-cfr/tree"
+)
+
+func TestTrainer_MaxIterations(t *testing.T) {
+	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
+	trainer := New(cfr.New(policy), Params{MaxIterations: 100})
+	var nCallbacks int
+	trainer.AddCallback(func(stats Stats) {
+		nCallbacks++
+		if stats.Iter != nCallbacks {
+			t.Errorf("expected iteration %d, got %d", nCallbacks, stats.Iter)
+		}
+	})
+
+	stats, err := trainer.Train(kuhn.NewGame())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if nCallbacks != 100 || stats.Iter != 100 {
+		t.Errorf("expected 100 iterations, got %d (%d callbacks)", stats.Iter, nCallbacks)
+	}
+}
+
+func TestTrainer_TargetExploitability(t *testing.T) {
+	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
+	trainer := New(cfr.New(policy), Params{
+		MaxIterations:          10000,
+		TargetExploitability:   0.01,
+		ExploitabilityInterval: 10,
+	})
+
+	stats, err := trainer.Train(kuhn.NewGame())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Logf("Reached exploitability %.4f after %d iterations", stats.Exploitability, stats.Iter)
+	if stats.Iter >= 10000 || stats.Exploitability > 0.01 {
+		t.Errorf("did not reach target exploitability: %+v", stats)
+	}
+}
+
+func TestTrainer_Resume(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trainer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	params := Params{
+		MaxIterations:      50,
+		CheckpointPath:     filepath.Join(dir, "checkpoint"),
+		CheckpointInterval: 20,
+	}
+
+	root := kuhn.NewGame()
+	policy := cfr.NewPolicyTable(cfr.DiscountParams{})
+	if _, err := New(cfr.New(policy), params).Train(root); err != nil {
+		t.Fatal(err)
+	}
+
+	params.MaxIterations = 100
+	resumed := cfr.NewPolicyTable(cfr.DiscountParams{})
+	trainer := New(cfr.New(resumed), params)
+	if ok, err := trainer.Resume(); err != nil || !ok {
+		t.Fatalf("failed to resume from checkpoint: %v", err)
+	}
+
+	if resumed.Iter() != 51 {
+		t.Errorf("expected to resume at iteration 51, got %d", resumed.Iter())
+	}
+
+	stats, err := trainer.Train(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.Iter != 100 {
+		t.Errorf("expected to stop after iteration 100, got %d", stats.Iter)
+	}
+
+	// The resumed profile must be identical to one trained without interruption.
+	expected := cfr.NewPolicyTable(cfr.DiscountParams{})
+	if _, err := New(cfr.New(expected), Params{MaxIterations: 100}).Train(root); err != nil {
+		t.Fatal(err)
+	}
+
+	tree.Visit(root, func(node cfr.GameTreeNode) {
+		if node.Type() != cfr.PlayerNodeType {
+			return
+		}
+
+		want := expected.GetPolicy(node).GetAverageStrategy()
+		got := resumed.GetPolicy(node).GetAverageStrategy()
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%v: expected strategy %v, got %v", node, want, got)
+		}
+	})
+}
+
+func TestTrainer_ResumeSampled(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trainer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	params := Params{
+		MaxIterations:      50,
+		CheckpointPath:     filepath.Join(dir, "checkpoint"),
+		CheckpointInterval: 20,
+	}
+
+	config := cfr.SolverConfig{
+		Variant: "mccfr",
+		Sampler: cfr.SamplerConfig{Name: "outcome", Params: map[string]float64{"explorationEps": 0.6}},
+		Seed:    123,
+	}
+	newSolver := func() cfr.Solver {
+		solver, err := cfr.NewSolver(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return solver
+	}
+
+	root := kuhn.NewGame()
+	if _, err := New(newSolver(), params).Train(root); err != nil {
+		t.Fatal(err)
+	}
+
+	params.MaxIterations = 100
+	resumed := newSolver()
+	trainer := New(resumed, params)
+	if ok, err := trainer.Resume(); err != nil || !ok {
+		t.Fatalf("failed to resume from checkpoint: %v", err)
+	}
+
+	if _, err := trainer.Train(root); err != nil {
+		t.Fatal(err)
+	}
+
+	// The resumed run must sample the same as one that was never checkpointed.
+	expected := newSolver()
+	if _, err := New(expected, Params{MaxIterations: 100}).Train(root); err != nil {
+		t.Fatal(err)
+	}
+
+	tree.Visit(root, func(node cfr.GameTreeNode) {
+		if node.Type() != cfr.PlayerNodeType {
+			return
+		}
+
+		want := expected.StrategyProfile().GetPolicy(node).GetAverageStrategy()
+		got := resumed.StrategyProfile().GetPolicy(node).GetAverageStrategy()
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%v: expected strategy %v, got %v", node, want, got)
+		}
+	})
+}
+
+// countingProfile counts the number of times it is checkpointed.
+type countingProfile struct {
+	*cfr.PolicyTable
+	nCheckpoints int
+}
+
+func (p *countingProfile) MarshalBinary() ([]byte, error) {
+	p.nCheckpoints++
+	return p.PolicyTable.MarshalBinary()
+}
+
+func TestTrainer_Checkpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trainer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// A bare filename is relative to the working directory.
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	policy := &countingProfile{PolicyTable: cfr.NewPolicyTable(cfr.DiscountParams{})}
+	params := Params{
+		MaxIterations:      50,
+		CheckpointPath:     "checkpoint",
+		CheckpointInterval: 10,
+	}
+
+	if _, err := New(cfr.New(policy), params).Train(kuhn.NewGame()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The final iteration is a multiple of the interval, and so
+	// is not checkpointed again when training stops.
+	if policy.nCheckpoints != 5 {
+		t.Errorf("expected 5 checkpoints, got %d", policy.nCheckpoints)
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 1 || files[0].Name() != "checkpoint" {
+		t.Errorf("expected only the checkpoint in %v, got %v", dir, files)
+	}
+}
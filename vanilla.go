@@ -6,112 +6,152 @@ import (
 
 type CFR struct {
 	strategyProfile StrategyProfile
+	pruner          *pruner
 	slicePool       *floatSlicePool
+
+	nPlayers int
 }
 
 func New(strategyProfile StrategyProfile) *CFR {
+	return NewWithPruning(strategyProfile, PruningParams{})
+}
+
+// NewWithPruning creates a new CFR solver that performs regret-based pruning.
+func NewWithPruning(strategyProfile StrategyProfile, pruning PruningParams) *CFR {
 	return &CFR{
 		strategyProfile: strategyProfile,
+		pruner:          newPruner(pruning),
 		slicePool:       &floatSlicePool{},
 	}
 }
 
+// StrategyProfile implements Solver.
+func (c *CFR) StrategyProfile() StrategyProfile {
+	return c.strategyProfile
+}
+
+// Seed implements Solver. It has no effect since CFR is deterministic.
+func (c *CFR) Seed(seed int64) {}
+
+// Run performs one iteration of CFR, updating the regrets of all players,
+// and returns the expected value of the game for the first player.
 func (c *CFR) Run(node GameTreeNode) float32 {
-	return c.runHelper(node, node.Player(), 1.0, 1.0, 1.0)
+	c.nPlayers = NumPlayers(node)
+	c.pruner.start(c.strategyProfile.Iter(), 1)
+	reach := c.slicePool.alloc(c.nPlayers)
+	defer c.slicePool.free(reach)
+	fillOnes(reach)
+	ev := c.runHelper(node, reach, 1.0)
+	defer c.slicePool.free(ev)
+	return ev[0]
 }
 
-func (c *CFR) runHelper(node GameTreeNode, lastPlayer int, reachP0, reachP1, reachChance float32) float32 {
-	var ev float32
+// runHelper returns the expected utility of each player at the given node.
+// The returned slice must be freed by the caller.
+func (c *CFR) runHelper(node GameTreeNode, reach []float32, reachChance float32) []float32 {
+	var ev []float32
 	switch node.Type() {
 	case TerminalNodeType:
-		ev = float32(node.Utility(lastPlayer))
+		ev = c.slicePool.alloc(c.nPlayers)
+		getUtilities(node, ev)
 	case ChanceNodeType:
-		ev = c.handleChanceNode(node, lastPlayer, reachP0, reachP1, reachChance)
+		ev = c.handleChanceNode(node, reach, reachChance)
 	default:
-		sgn := getSign(lastPlayer, node.Player())
-		ev = sgn * c.handlePlayerNode(node, reachP0, reachP1, reachChance)
+		ev = c.handlePlayerNode(node, reach, reachChance)
 	}
 
 	node.Close()
 	return ev
 }
 
-func (c *CFR) handleChanceNode(node GameTreeNode, lastPlayer int, reachP0, reachP1, reachChance float32) float32 {
-	var expectedValue float32
+func (c *CFR) handleChanceNode(node GameTreeNode, reach []float32, reachChance float32) []float32 {
+	expectedValue := c.slicePool.alloc(c.nPlayers)
 	for i := 0; i < node.NumChildren(); i++ {
 		child := node.GetChild(i)
 		p := float32(node.GetChildProbability(i))
-		expectedValue += p * c.runHelper(child, lastPlayer, reachP0, reachP1, reachChance*p)
+		childEV := c.runHelper(child, reach, reachChance*p)
+		f32.AxpyUnitary(p, childEV, expectedValue)
+		c.slicePool.free(childEV)
 	}
 
 	return expectedValue
 }
 
-func (c *CFR) handlePlayerNode(node GameTreeNode, reachP0, reachP1, reachChance float32) float32 {
+func (c *CFR) handlePlayerNode(node GameTreeNode, reach []float32, reachChance float32) []float32 {
 	player := node.Player()
 	nChildren := node.NumChildren()
 	if nChildren == 1 {
 		// Optimization to skip trivial nodes with no real choice.
 		child := node.GetChild(0)
-		return c.runHelper(child, player, reachP0, reachP1, reachChance)
+		return c.runHelper(child, reach, reachChance)
 	}
 
 	policy := c.strategyProfile.GetPolicy(node)
 	strategy := policy.GetStrategy()
+	pruned := c.pruner.prune(node, policy, strategy)
 	regrets := c.slicePool.alloc(nChildren)
 	defer c.slicePool.free(regrets)
-	var cfValue float32
+	ev := c.slicePool.alloc(c.nPlayers)
+	reachPlayer := reach[player]
 	for i := 0; i < nChildren; i++ {
-		child := node.GetChild(i)
+		if pruned.isPruned(i) {
+			continue
+		}
+
 		p := strategy[i]
-		var util float32
-		if player == 0 {
-			util = c.runHelper(child, player, p*reachP0, reachP1, reachChance)
-		} else {
-			util = c.runHelper(child, player, reachP0, p*reachP1, reachChance)
+		reach[player] = p * reachPlayer
+		if c.pruner.params.Enabled && !isReached(reach, reachChance) {
+			continue
 		}
 
-		regrets[i] = util
-		cfValue += p * util
+		child := node.GetChild(i)
+		childEV := c.runHelper(child, reach, reachChance)
+		regrets[i] = childEV[player]
+		f32.AxpyUnitary(p, childEV, ev)
+		c.slicePool.free(childEV)
 	}
+	reach[player] = reachPlayer
 
 	// Transform action utilities into instantaneous regrets by
 	// subtracting out the expected utility over all possible actions.
-	f32.AddConst(-cfValue, regrets)
-	counterFactualP := counterFactualProb(player, reachP0, reachP1, reachChance)
+	f32.AddConst(-ev[player], regrets)
+	counterFactualP := counterFactualProb(player, reach, reachChance)
+	pruned.update(regrets, counterFactualP, ev[player])
 	ones := c.slicePool.alloc(nChildren)
 	defer c.slicePool.free(ones)
-	for i := range ones {
-		ones[i] = 1.0
-	}
+	fillOnes(ones)
 	policy.AddRegret(counterFactualP, ones, regrets)
-	reachP := reachProb(player, reachP0, reachP1, reachChance)
+	reachP := reachProb(player, reach, reachChance)
 	policy.AddStrategyWeight(reachP)
-	return cfValue
+	return ev
 }
 
-func getSign(player1, player2 int) float32 {
-	if player1 == player2 {
-		return 1.0
+// getUtilities sets the utility of each player at the given terminal node.
+func getUtilities(node GameTreeNode, utilities []float32) {
+	for player := range utilities {
+		utilities[player] = float32(node.Utility(player))
 	}
-
-	return -1.0
 }
 
-func reachProb(player int, reachP0, reachP1, reachChance float32) float32 {
-	if player == 0 {
-		return reachP0 * reachChance
-	} else {
-		return reachP1 * reachChance
+func fillOnes(v []float32) {
+	for i := range v {
+		v[i] = 1.0
 	}
 }
 
+func reachProb(player int, reach []float32, reachChance float32) float32 {
+	return reach[player] * reachChance
+}
+
 // The probability of reaching this node, assuming that the current player
 // tried to reach it.
-func counterFactualProb(player int, reachP0, reachP1, reachChance float32) float32 {
-	if player == 0 {
-		return reachP1 * reachChance
-	} else {
-		return reachP0 * reachChance
+func counterFactualProb(player int, reach []float32, reachChance float32) float32 {
+	p := reachChance
+	for i, r := range reach {
+		if i != player {
+			p *= r
+		}
 	}
+
+	return p
 }
@@ -1,6 +1,7 @@
 package cfr
 
 import (
+	"fmt"
 	"math/rand"
 
 	"github.com/timpalpant/go-cfr/internal/f32"
@@ -14,52 +15,100 @@ type VRMCCFR struct {
 	slicePool *floatSlicePool
 	mapPool   *keyIntMapPool
 	rng       *rand.Rand
+	randState
 
+	nPlayers         int
 	traversingPlayer int
 	sampledActions   map[string]int
+
+	// Whether the game is a two-player game in which the utilities of the
+	// players always sum to utilitySum.
+	constantSum bool
+	utilitySum  float32
+}
+
+// NewVRMCCFR returns a VRMCCFR for a two-player zero-sum game.
+// Use NewGeneralSumVRMCCFR for games with more players or that are not zero-sum.
+func NewVRMCCFR(rng *rand.Rand, strategyProfile StrategyProfile, traversingSampler, notTraversingSampler Sampler) *VRMCCFR {
+	return NewConstantSumVRMCCFR(rng, strategyProfile, traversingSampler, notTraversingSampler, 0)
 }
 
-func NewVRMCCFR(strategyProfile StrategyProfile, traversingSampler, notTraversingSampler Sampler) *VRMCCFR {
+// NewGeneralSumVRMCCFR returns a VRMCCFR for a game with any number of players,
+// whose utilities need not sum to a constant. Each player's baselines only
+// reduce the variance of that player's own sampled utilities.
+func NewGeneralSumVRMCCFR(rng *rand.Rand, strategyProfile StrategyProfile, traversingSampler, notTraversingSampler Sampler) *VRMCCFR {
 	return &VRMCCFR{
 		strategyProfile:      strategyProfile,
 		traversingSampler:    traversingSampler,
 		notTraversingSampler: notTraversingSampler,
 		slicePool:            &floatSlicePool{},
 		mapPool:              &keyIntMapPool{},
-		rng:                  rand.New(rand.NewSource(rand.Int63())),
+		rng:                  rng,
 	}
 }
 
+// NewConstantSumVRMCCFR returns a VRMCCFR for a two-player game in which the
+// utilities of the players always sum to utilitySum (zero for a zero-sum game).
+// The baselines of each player then also give baselines for the other player,
+// which reduces the variance of the sampled utilities of both players.
+func NewConstantSumVRMCCFR(rng *rand.Rand, strategyProfile StrategyProfile, traversingSampler, notTraversingSampler Sampler, utilitySum float32) *VRMCCFR {
+	c := NewGeneralSumVRMCCFR(rng, strategyProfile, traversingSampler, notTraversingSampler)
+	c.constantSum = true
+	c.utilitySum = utilitySum
+	return c
+}
+
+// StrategyProfile implements Solver.
+func (c *VRMCCFR) StrategyProfile() StrategyProfile {
+	return c.strategyProfile
+}
+
+// Seed implements Solver.
+func (c *VRMCCFR) Seed(seed int64) {
+	c.rng.Seed(seed)
+}
+
+// Run performs one iteration of VR-MCCFR, updating the regrets of one player
+// (in turn), and returns the sampled value of the game for the first player.
 func (c *VRMCCFR) Run(node GameTreeNode) float32 {
 	iter := c.strategyProfile.Iter()
-	c.traversingPlayer = int(iter % 2)
+	c.nPlayers = NumPlayers(node)
+	c.traversingPlayer = iter % c.nPlayers
+	if c.constantSum && c.nPlayers != 2 {
+		panic(fmt.Errorf("constant-sum VRMCCFR requires a two-player game, got %d players", c.nPlayers))
+	}
+
 	c.sampledActions = c.mapPool.alloc()
 	defer c.mapPool.free(c.sampledActions)
-	return c.runHelper(node, node.Player(), 1.0, 1.0)
+	ev := c.runHelper(node, 1.0, 1.0)
+	defer c.slicePool.free(ev)
+	return ev[0]
 }
 
-func (c *VRMCCFR) runHelper(node GameTreeNode, lastPlayer int, sampleProb, reachProb float32) float32 {
-	var ev float32
+// runHelper returns the (baseline-corrected) sampled utility of each player
+// at the given node. The returned slice must be freed by the caller.
+func (c *VRMCCFR) runHelper(node GameTreeNode, sampleProb, reachProb float32) []float32 {
+	var ev []float32
 	switch node.Type() {
 	case TerminalNodeType:
-		ev = float32(node.Utility(lastPlayer))
+		ev = c.slicePool.alloc(c.nPlayers)
+		getUtilities(node, ev)
 	case ChanceNodeType:
-		ev = c.handleChanceNode(node, lastPlayer, sampleProb, reachProb)
+		ev = c.handleChanceNode(node, sampleProb, reachProb)
 	default:
-		sgn := getSign(lastPlayer, node.Player())
-		ev = sgn * c.handlePlayerNode(node, sampleProb, reachProb)
+		ev = c.handlePlayerNode(node, sampleProb, reachProb)
 	}
 
 	node.Close()
 	return ev
 }
 
-func (c *VRMCCFR) handleChanceNode(node GameTreeNode, lastPlayer int, sampleProb, reachProb float32) float32 {
-	child, p := node.SampleChild()
-	return c.runHelper(child, lastPlayer, float32(p)*sampleProb, float32(p)*reachProb)
+func (c *VRMCCFR) handleChanceNode(node GameTreeNode, sampleProb, reachProb float32) []float32 {
+	child, p := SampleChanceNode(c.rng, node)
+	return c.runHelper(child, float32(p)*sampleProb, float32(p)*reachProb)
 }
 
-func (c *VRMCCFR) handlePlayerNode(node GameTreeNode, sampleProb, reachProb float32) float32 {
+func (c *VRMCCFR) handlePlayerNode(node GameTreeNode, sampleProb, reachProb float32) []float32 {
 	if node.Player() == c.traversingPlayer {
 		return c.handleTraversingPlayerNode(node, sampleProb, reachProb)
 	} else {
@@ -67,51 +116,46 @@ func (c *VRMCCFR) handlePlayerNode(node GameTreeNode, sampleProb, reachProb floa
 	}
 }
 
-func (c *VRMCCFR) handleTraversingPlayerNode(node GameTreeNode, sampleProb, reachProb float32) float32 {
+func (c *VRMCCFR) handleTraversingPlayerNode(node GameTreeNode, sampleProb, reachProb float32) []float32 {
 	player := node.Player()
 	nChildren := node.NumChildren()
 	if nChildren == 1 {
 		// Optimization to skip trivial nodes with no real choice.
 		child := node.GetChild(0)
-		return c.runHelper(child, player, sampleProb, reachProb)
+		return c.runHelper(child, sampleProb, reachProb)
 	}
 
 	policy := c.strategyProfile.GetPolicy(node)
 	baseline := policy.GetBaseline()
+	strategy := policy.GetStrategy()
 	qs := c.slicePool.alloc(nChildren)
 	copy(qs, c.traversingSampler.Sample(node, policy))
 	regrets := c.slicePool.alloc(nChildren)
 	oldSampledActions := c.sampledActions
 	c.sampledActions = c.mapPool.alloc()
 
+	ev := c.slicePool.alloc(c.nPlayers)
 	for i, q := range qs {
-		child := node.GetChild(i)
-		uHat := baseline[i]
-		if q > 0 {
-			u := c.runHelper(child, player, q*sampleProb, reachProb)
-			uHat += (u - baseline[i]) / q
-			policy.UpdateBaseline(1.0/q, i, u)
-		}
-
-		regrets[i] = uHat
+		uHat := c.estimateChildValue(node, policy, baseline, i, q, sampleProb, reachProb)
+		regrets[i] = uHat[player]
+		f32.AxpyUnitary(strategy[i], uHat, ev)
+		c.slicePool.free(uHat)
 	}
 
-	cfValue := f32.DotUnitary(policy.GetStrategy(), regrets)
-	f32.AddConst(-cfValue, regrets)
+	f32.AddConst(-ev[player], regrets)
 	policy.AddRegret(reachProb/sampleProb, qs, regrets)
 
 	c.slicePool.free(qs)
 	c.slicePool.free(regrets)
 	c.mapPool.free(c.sampledActions)
 	c.sampledActions = oldSampledActions
-	return cfValue
+	return ev
 }
 
 // Sample player action according to strategy, do not update policy.
 // Save selected action so that they are reused if this infoset is hit again.
-func (c *VRMCCFR) handleSampledPlayerNode(node GameTreeNode, sampleProb, reachProb float32) float32 {
+func (c *VRMCCFR) handleSampledPlayerNode(node GameTreeNode, sampleProb, reachProb float32) []float32 {
 	policy := c.strategyProfile.GetPolicy(node)
-	player := node.Player()
 	nChildren := node.NumChildren()
 	baseline := policy.GetBaseline()
 	strategy := policy.GetStrategy()
@@ -124,22 +168,47 @@ func (c *VRMCCFR) handleSampledPlayerNode(node GameTreeNode, sampleProb, reachPr
 
 	qs := c.slicePool.alloc(nChildren)
 	copy(qs, c.notTraversingSampler.Sample(node, policy))
-	regrets := c.slicePool.alloc(nChildren)
 
+	ev := c.slicePool.alloc(c.nPlayers)
 	for i, q := range qs {
 		p := strategy[i]
+		uHat := c.estimateChildValue(node, policy, baseline, i, q, sampleProb, p*reachProb)
+		f32.AxpyUnitary(p, uHat, ev)
+		c.slicePool.free(uHat)
+	}
+
+	c.slicePool.free(qs)
+	return ev
+}
+
+// estimateChildValue returns an unbiased estimate of the utility of each player
+// for the ith child of the given node, which is sampled with probability q.
+//
+// Baselines are maintained from the perspective of the player acting at each
+// node, and so are used to reduce the variance of that player's utility.
+// In two-player constant-sum games utilitySum minus the baseline is also used
+// for the other player; otherwise the other players' utilities are not corrected.
+func (c *VRMCCFR) estimateChildValue(node GameTreeNode, policy NodePolicy, baseline []float32, i int, q, sampleProb, reachProb float32) []float32 {
+	player := node.Player()
+	uHat := c.slicePool.alloc(c.nPlayers)
+	if q > 0 {
 		child := node.GetChild(i)
-		uHat := baseline[i]
-		if q > 0 {
-			u := c.runHelper(child, player, q*sampleProb, p*reachProb)
-			uHat += (u - baseline[i]) / q
-			policy.UpdateBaseline(1.0/q, i, u)
+		u := c.runHelper(child, q*sampleProb, reachProb)
+		f32.ScalUnitaryTo(uHat, 1.0/q, u)
+		uHat[player] = baseline[i] + (u[player]-baseline[i])/q
+		if c.constantSum {
+			other := c.utilitySum - baseline[i]
+			uHat[1-player] = other + (u[1-player]-other)/q
 		}
 
-		regrets[i] = uHat
+		policy.UpdateBaseline(1.0/q, i, u[player])
+		c.slicePool.free(u)
+	} else {
+		uHat[player] = baseline[i]
+		if c.constantSum {
+			uHat[1-player] = c.utilitySum - baseline[i]
+		}
 	}
 
-	c.slicePool.free(qs)
-	c.slicePool.free(regrets)
-	return f32.DotUnitary(policy.GetStrategy(), regrets)
+	return uHat
 }
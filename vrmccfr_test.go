@@ -0,0 +1,70 @@
+package cfr_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/timpalpant/go-cfr"
+	"github.com/timpalpant/go-cfr/sampling"
+)
+
+func TestVRMCCFR_ConstantSumBaseline(t *testing.T) {
+	// In these games player 1 should choose y after A and x after B.
+	// The utilities of the players sum to utilitySum.
+	testCases := map[string]struct {
+		utilitySum float32
+		payoffs    map[string][2]float64
+	}{
+		"zero-sum": {0, map[string][2]float64{
+			"Ax": {10, -10},
+			"Ay": {-5, 5},
+			"Bx": {-10, 10},
+			"By": {5, -5},
+		}},
+		"constant-sum": {20, map[string][2]float64{
+			"Ax": {20, 0},
+			"Ay": {5, 15},
+			"Bx": {0, 20},
+			"By": {15, 5},
+		}},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			rng := rand.New(rand.NewSource(123))
+			policy := cfr.NewPolicyTable(cfr.DiscountParams{})
+			rs := sampling.NewRobustSampler(rng, 1)
+			opt := cfr.NewConstantSumVRMCCFR(rng, policy, rs, rs, tc.utilitySum)
+			root := &sequentialGameNode{payoffs: tc.payoffs}
+			for i := 0; i < 1000; i++ {
+				opt.Run(root)
+				policy.Update()
+			}
+
+			if policy.Iter()%2 == 1 {
+				opt.Run(root)
+				policy.Update()
+			}
+
+			// Player 1's baselines are learned exactly, so when player 0 is
+			// traversing, the baselines derived from them leave no variance
+			// in its sampled value. Without them, the value depends on which
+			// of player 1's actions is sampled.
+			var expected float64
+			p0 := policy.GetPolicy(root).GetStrategy()
+			for i, a := range []string{"A", "B"} {
+				p1 := policy.GetPolicy(root.GetChild(i)).GetStrategy()
+				for j, b := range []string{"x", "y"} {
+					expected += float64(p0[i]*p1[j]) * tc.payoffs[a+b][0]
+				}
+			}
+
+			for i := 0; i < 100; i++ {
+				if ev := opt.Run(root); math.Abs(float64(ev)-expected) > 1e-3 {
+					t.Fatalf("expected sampled value %v, got %v", expected, ev)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package xfp
+
+import (
+	"bytes"
+	"encoding/gob"
+
+	"github.com/timpalpant/go-cfr"
+)
+
+func init() {
+	gob.Register(&StrategyProfile{})
+}
+
+// StrategyProfile implements cfr.StrategyProfile for fictitious play, by
+// holding the average strategy of each player in a cfr.FixedStrategyProfile.
+//
+// The current and average strategy of each NodePolicy are both the average
+// strategy. Regret and strategy updates are ignored: the average strategy is
+// only changed by XFP.Run, and the change takes effect on Update.
+type StrategyProfile struct {
+	iter    int
+	average *cfr.FixedStrategyProfile
+	pending map[string][]float32
+}
+
+// NewStrategyProfile returns a new StrategyProfile in which all players
+// play uniformly at random.
+func NewStrategyProfile() *StrategyProfile {
+	return &StrategyProfile{
+		iter:    1,
+		average: cfr.NewFixedStrategyProfile(),
+	}
+}
+
+// GetPolicy implements cfr.StrategyProfile.
+func (p *StrategyProfile) GetPolicy(node cfr.GameTreeNode) cfr.NodePolicy {
+	return p.average.GetPolicy(node)
+}
+
+// Update implements cfr.StrategyProfile. It replaces the average strategy
+// with the one computed by the last call to XFP.Run.
+func (p *StrategyProfile) Update() {
+	for key, strategy := range p.pending {
+		p.average.SetStrategy([]byte(key), strategy)
+	}
+
+	p.pending = nil
+	p.iter++
+}
+
+// Iter implements cfr.StrategyProfile.
+func (p *StrategyProfile) Iter() int {
+	return p.iter
+}
+
+// Close implements io.Closer.
+func (p *StrategyProfile) Close() error {
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (p *StrategyProfile) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+	if err := enc.Encode(p.iter); err != nil {
+		return nil, err
+	}
+
+	if err := enc.Encode(p.average); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (p *StrategyProfile) UnmarshalBinary(buf []byte) error {
+	r := bytes.NewReader(buf)
+	dec := gob.NewDecoder(r)
+	if err := dec.Decode(&p.iter); err != nil {
+		return err
+	}
+
+	p.average = cfr.NewFixedStrategyProfile()
+	p.pending = nil
+	return dec.Decode(p.average)
+}
@@ -0,0 +1,181 @@
+// Package xfp implements extensive-form fictitious play (XFP).
+//
+// On each iteration every player computes an exact best response to the
+// average strategies of the other players, and the best responses are mixed
+// into the average strategies in proportion to their sequence-form
+// realization weights, so that the average strategy is equivalent to the
+// average of the corresponding normal-form strategies.
+//
+// See: Heinrich, Lanctot and Silver, "Fictitious Self-Play in Extensive-Form Games" (ICML 2015).
+//
+// Best responses are computed by walking the full game tree, so XFP is
+// only tractable for games that are small enough to enumerate.
+package xfp
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/timpalpant/go-cfr"
+	"github.com/timpalpant/go-cfr/exploitability"
+)
+
+func init() {
+	cfr.RegisterSolver("xfp", func(rng *rand.Rand, profile cfr.StrategyProfile, config cfr.SolverConfig) (cfr.Solver, error) {
+		p, ok := profile.(*StrategyProfile)
+		if !ok {
+			return nil, fmt.Errorf("xfp requires an *xfp.StrategyProfile, got %T", profile)
+		}
+
+		return New(p), nil
+	})
+	cfr.RegisterStrategyProfile("xfp", func(config cfr.SolverConfig) cfr.StrategyProfile {
+		return NewStrategyProfile()
+	})
+}
+
+// XFP implements cfr.Solver for extensive-form fictitious play.
+type XFP struct {
+	strategyProfile *StrategyProfile
+}
+
+// New returns a new XFP solver that updates the given StrategyProfile.
+func New(strategyProfile *StrategyProfile) *XFP {
+	return &XFP{strategyProfile}
+}
+
+// StrategyProfile implements cfr.Solver.
+func (x *XFP) StrategyProfile() cfr.StrategyProfile {
+	return x.strategyProfile
+}
+
+// Seed implements cfr.Solver. It has no effect since XFP is deterministic.
+func (x *XFP) Seed(seed int64) {}
+
+// Run implements cfr.Solver. It computes a best response for each player to the
+// current average strategies, and the new average strategies that will take
+// effect on the next call to StrategyProfile().Update().
+//
+// It returns the expected value of the game for the first player when all
+// players play the current average strategies.
+func (x *XFP) Run(root cfr.GameTreeNode) float32 {
+	nPlayers := cfr.NumPlayers(root)
+	average := exploitability.AverageStrategy(x.strategyProfile)
+	m := &mixer{
+		profile:       x.strategyProfile,
+		bestResponses: make([]*exploitability.BestResponse, nPlayers),
+		infoSets:      make(map[string]*infoSetWeights),
+	}
+
+	for player := range m.bestResponses {
+		m.bestResponses[player] = exploitability.NewBestResponse(root, player, average)
+	}
+
+	avgReach := make([]float64, nPlayers)
+	brReach := make([]float64, nPlayers)
+	for i := range avgReach {
+		avgReach[i] = 1.0
+		brReach[i] = 1.0
+	}
+
+	ev := m.walk(root, avgReach, brReach)
+	alpha := 1.0 / float64(x.strategyProfile.Iter()+1)
+	x.strategyProfile.pending = m.mix(alpha)
+	return float32(ev[0])
+}
+
+// infoSetWeights are the realization weights of one player's InfoSet
+// under its average strategy and its best response.
+type infoSetWeights struct {
+	strategy []float32
+	action   int
+	avgReach float64
+	brReach  float64
+}
+
+type mixer struct {
+	profile       *StrategyProfile
+	bestResponses []*exploitability.BestResponse
+	infoSets      map[string]*infoSetWeights
+}
+
+// walk records the realization weights of every InfoSet, given the probability
+// that each player plays to reach node under its average strategy and under its
+// best response. It returns the expected utility of each player when all players
+// play their average strategy.
+func (m *mixer) walk(node cfr.GameTreeNode, avgReach, brReach []float64) []float64 {
+	ev := make([]float64, len(avgReach))
+	switch node.Type() {
+	case cfr.TerminalNodeType:
+		for player := range ev {
+			ev[player] = node.Utility(player)
+		}
+	case cfr.ChanceNodeType:
+		for i := 0; i < node.NumChildren(); i++ {
+			p := node.GetChildProbability(i)
+			addScaled(ev, p, m.walk(node.GetChild(i), avgReach, brReach))
+		}
+	default:
+		player := node.Player()
+		key := string(node.InfoSetKey(player))
+		w, ok := m.infoSets[key]
+		if !ok {
+			// Assuming perfect recall, the player's own reach probability is
+			// the same for all nodes in the InfoSet.
+			w = &infoSetWeights{
+				strategy: m.profile.GetPolicy(node).GetStrategy(),
+				action:   m.bestResponses[player].GetAction(node),
+				avgReach: avgReach[player],
+				brReach:  brReach[player],
+			}
+			m.infoSets[key] = w
+		}
+
+		childAvgReach := append([]float64(nil), avgReach...)
+		childBrReach := append([]float64(nil), brReach...)
+		for i := 0; i < node.NumChildren(); i++ {
+			p := float64(w.strategy[i])
+			childAvgReach[player] = avgReach[player] * p
+			childBrReach[player] = 0
+			if i == w.action {
+				childBrReach[player] = brReach[player]
+			}
+
+			addScaled(ev, p, m.walk(node.GetChild(i), childAvgReach, childBrReach))
+		}
+	}
+
+	node.Close()
+	return ev
+}
+
+// mix returns the new average strategy of each InfoSet, which plays the best response
+// with weight alpha and the previous average strategy with weight (1 - alpha).
+func (m *mixer) mix(alpha float64) map[string][]float32 {
+	result := make(map[string][]float32, len(m.infoSets))
+	for key, w := range m.infoSets {
+		avgWeight := (1 - alpha) * w.avgReach
+		brWeight := alpha * w.brReach
+		total := avgWeight + brWeight
+		if total <= 0 {
+			// InfoSet is not reached by either strategy.
+			continue
+		}
+
+		strategy := make([]float32, len(w.strategy))
+		for i, p := range w.strategy {
+			strategy[i] = float32(avgWeight * float64(p) / total)
+		}
+
+		strategy[w.action] += float32(brWeight / total)
+		result[key] = strategy
+	}
+
+	return result
+}
+
+func addScaled(dst []float64, alpha float64, x []float64) {
+	for i, v := range x {
+		dst[i] += alpha * v
+	}
+}
@@ -0,0 +1,105 @@
+package xfp
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/timpalpant/go-cfr"
+	"github.com/timpalpant/go-cfr/exploitability"
+	"github.com/timpalpant/go-cfr/kuhn"
+	"github.com/timpalpant/go-cfr/tree"
+)
+
+func TestKuhn(t *testing.T) {
+	root := kuhn.NewGame()
+	profile := NewStrategyProfile()
+	opt := New(profile)
+	var lastExploitability float64 = math.Inf(1)
+	for i := 1; i <= 1000; i++ {
+		opt.Run(root)
+		profile.Update()
+		if i%200 == 0 {
+			result := exploitability.Compute(root, profile)
+			t.Logf("[iter=%d] exploitability: %.4f, value: %.4f", i, result.Exploitability, result.Values[0])
+			if result.Exploitability > lastExploitability {
+				t.Errorf("exploitability increased from %v to %v", lastExploitability, result.Exploitability)
+			}
+
+			lastExploitability = result.Exploitability
+		}
+	}
+
+	if lastExploitability > 0.01 {
+		t.Errorf("expected exploitability < 0.01, got %v", lastExploitability)
+	}
+}
+
+func TestNPlayerKuhn(t *testing.T) {
+	root := kuhn.NewNPlayerGame(3)
+	profile := NewStrategyProfile()
+	opt := New(profile)
+	initial := exploitability.NashConv(root, profile)
+	for i := 0; i < 200; i++ {
+		opt.Run(root)
+		profile.Update()
+	}
+
+	nashConv := exploitability.NashConv(root, profile)
+	t.Logf("NashConv: %.4f -> %.4f", initial, nashConv)
+	if nashConv > initial/4 {
+		t.Errorf("expected NashConv to decrease from %v, got %v", initial, nashConv)
+	}
+}
+
+func TestNewSolver(t *testing.T) {
+	solver, err := cfr.NewSolver(cfr.SolverConfig{Variant: "xfp"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := solver.(*XFP); !ok {
+		t.Errorf("expected *XFP, got %T", solver)
+	}
+
+	_, err = cfr.NewSolverWithProfile(cfr.NewPolicyTable(cfr.DiscountParams{}), cfr.SolverConfig{Variant: "xfp"})
+	if err == nil {
+		t.Error("expected error creating XFP with a PolicyTable")
+	}
+}
+
+func TestMarshalStrategyProfile(t *testing.T) {
+	root := kuhn.NewGame()
+	profile := NewStrategyProfile()
+	opt := New(profile)
+	for i := 0; i < 10; i++ {
+		opt.Run(root)
+		profile.Update()
+	}
+
+	buf, err := profile.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var reloaded StrategyProfile
+	if err := reloaded.UnmarshalBinary(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if reloaded.Iter() != profile.Iter() {
+		t.Errorf("expected iter %d, got %d", profile.Iter(), reloaded.Iter())
+	}
+
+	tree.Visit(root, func(node cfr.GameTreeNode) {
+		if node.Type() != cfr.PlayerNodeType {
+			return
+		}
+
+		s1 := profile.GetPolicy(node).GetAverageStrategy()
+		s2 := reloaded.GetPolicy(node).GetAverageStrategy()
+		if !reflect.DeepEqual(s1, s2) {
+			t.Errorf("expected %v, got %v", s1, s2)
+		}
+	})
+}